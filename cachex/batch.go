@@ -0,0 +1,66 @@
+package cachex
+
+import (
+	"context"
+	"time"
+)
+
+func (c *cacheImpl) GetMany(keys []string) (map[string]any, []string) {
+	found, missing, err := c.GetManyCtx(context.Background(), keys)
+	if err != nil {
+		return map[string]any{}, keys
+	}
+	return found, missing
+}
+
+func (c *cacheImpl) PutMany(items map[string]any, expireSeconds int64) {
+	_ = c.PutManyCtx(context.Background(), items, expireSeconds)
+}
+
+func (c *cacheImpl) ForgetMany(keys []string) {
+	_ = c.ForgetManyCtx(context.Background(), keys)
+}
+
+func (c *cacheImpl) GetManyCtx(ctx context.Context, keys []string) (map[string]any, []string, error) {
+	found, missing, err := c.driver.GetManyCtx(ctx, c.keys(keys))
+	if err != nil {
+		return nil, nil, err
+	}
+	result := make(map[string]any, len(found))
+	for k, v := range found {
+		result[k[len(c.prefix):]] = v
+	}
+	for i, k := range missing {
+		missing[i] = k[len(c.prefix):]
+	}
+	return result, missing, nil
+}
+
+func (c *cacheImpl) PutManyCtx(ctx context.Context, items map[string]any, expireSeconds int64) error {
+	d := time.Duration(expireSeconds) * time.Second
+	if c.prefix == "" {
+		return c.driver.SetManyCtx(ctx, items, d)
+	}
+
+	prefixed := make(map[string]any, len(items))
+	for k, v := range items {
+		prefixed[c.key(k)] = v
+	}
+	return c.driver.SetManyCtx(ctx, prefixed, d)
+}
+
+func (c *cacheImpl) ForgetManyCtx(ctx context.Context, keys []string) error {
+	return c.driver.DeleteManyCtx(ctx, c.keys(keys))
+}
+
+// keys 返回加上命名空间前缀后的键列表
+func (c *cacheImpl) keys(keys []string) []string {
+	if c.prefix == "" {
+		return keys
+	}
+	prefixed := make([]string, len(keys))
+	for i, k := range keys {
+		prefixed[i] = c.key(k)
+	}
+	return prefixed
+}
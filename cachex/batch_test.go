@@ -0,0 +1,43 @@
+package cachex_test
+
+import (
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/yu1ec/go-pkg/cachex"
+	"github.com/yu1ec/go-pkg/cachex/driver/redis"
+)
+
+func TestBatch(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	defer mr.Close()
+
+	redisCache, err := cachex.New("redis", &redis.RedisConfig{Addr: mr.Addr()}, cachex.WithPrefix("app:"))
+	if err != nil {
+		t.Fatalf("failed to create redis cache: %v", err)
+	}
+
+	caches := map[string]cachex.Cache{
+		"memory": newMemoryCache(t),
+		"redis":  redisCache,
+	}
+
+	for name, c := range caches {
+		t.Run(name, func(t *testing.T) {
+			c.PutMany(map[string]any{"a": "1", "b": "2", "c": "3"}, 60)
+
+			found, missing := c.GetMany([]string{"a", "b", "x", "c", "y"})
+			assert.Equal(t, map[string]any{"a": "1", "b": "2", "c": "3"}, found)
+			assert.Equal(t, []string{"x", "y"}, missing)
+
+			c.ForgetMany([]string{"a", "b"})
+			found, missing = c.GetMany([]string{"a", "b", "c"})
+			assert.Equal(t, map[string]any{"c": "3"}, found)
+			assert.Equal(t, []string{"a", "b"}, missing)
+		})
+	}
+}
@@ -1,5 +1,7 @@
 package cachex
 
+import "context"
+
 type Cache interface {
 	// Get 从缓存中获取一个项目。返回该项或 nil，以及一个指示是否找到该键的布尔值。
 	Get(k string) (any, bool)
@@ -8,11 +10,79 @@ type Cache interface {
 	// Exists 检查给定的键是否存在于缓存中。
 	Exists(k string) bool
 	// Remember 如果缓存中不存在该键，则从 create 函数创建一个新值，并将其添加到缓存中。单位/秒
+	// create 返回错误时不会写入缓存
 	Remember(k string, expireSeconds int64, create func() (any, error)) (any, error)
+	// RememberStale 软过期模式的 Remember，softSeconds 后返回陈旧值并在后台刷新，hardSeconds 后值被删除。单位/秒
+	// 写入的值带有软过期的元数据，只应通过 RememberStale 读取
+	RememberStale(k string, softSeconds, hardSeconds int64, create func() (any, error)) (any, error)
 	// RememberForever 如果缓存中不存在该键，则从 create 函数创建一个新值，并将其添加到缓存中。
 	RememberForever(key string, create func() (any, error)) (any, error)
 	// Forget 删除给定的键。
 	Forget(key string)
 	// Flush 清空缓存
 	Flush()
+	// GetMany 批量获取缓存，返回找到的键值以及未找到的键
+	GetMany(keys []string) (map[string]any, []string)
+	// PutMany 批量写入缓存，所有项目使用相同的过期时间 单位/秒
+	PutMany(items map[string]any, expireSeconds int64)
+	// ForgetMany 批量删除缓存
+	ForgetMany(keys []string)
+	// Tags 返回带标签的缓存，通过它写入的键可以按标签批量删除，驱动不支持标签时其方法返回 ErrNotSupported
+	Tags(names ...string) TaggedCache
+	// CompareAndSwap 当键存在且当前值等于 old 时原子地写入 new 并返回 true，过期时间同 Put 单位/秒
+	// 驱动不支持时返回 ErrNotSupported
+	CompareAndSwap(k string, old, new any, expireSeconds int64) (bool, error)
+	// Update 原子地读取键的当前值并写入 fn 返回的新值，已存在的键保留剩余的过期时间
+	// fn 返回错误时不写入；发生并发冲突时 fn 可能被多次调用，因此不应有副作用
+	Update(k string, fn func(old any, exists bool) (any, error)) (any, error)
+	// OnRemoval 订阅项目因显式删除、过期或淘汰而离开缓存的事件，返回取消订阅的函数
+	// 驱动不支持时返回 ErrNotSupported；Flush 不会触发删除事件
+	OnRemoval(fn func(RemovalEvent)) (func(), error)
+	// Scan 遍历与 glob 模式 pattern 匹配的键（语法同 Redis 的 SCAN MATCH），fn 返回 false 时停止
+	// 驱动不支持时返回 ErrNotSupported；Redis 可能重复返回同一个键
+	Scan(pattern string, fn func(k string) bool) error
+	// Len 返回缓存中键的数量，可能包含已过期但尚未清理的键，驱动不支持时返回 ErrNotSupported
+	Len() (int, error)
+	// ForgetPattern 删除与 pattern 匹配的所有键，常用于修改键的格式后批量失效旧缓存
+	ForgetPattern(pattern string) error
+
+	ContextCache
+}
+
+// ContextCache 是 Cache 的 context 版本，ctx 会一直传递到驱动，用于取消、超时和链路追踪
+// 与 Cache 不同，所有操作都会返回错误：键不存在时返回 ErrCacheMiss，后端不可用时返回包装了 ErrUnavailable 的错误
+type ContextCache interface {
+	// GetCtx 从缓存中获取一个项目，键不存在时返回 ErrCacheMiss
+	GetCtx(ctx context.Context, k string) (any, error)
+	// PutCtx Put 的 context 版本
+	PutCtx(ctx context.Context, k string, value any, expireSeconds int64) error
+	// ExistsCtx Exists 的 context 版本，仅在后端出错时返回错误
+	ExistsCtx(ctx context.Context, k string) (bool, error)
+	// RememberCtx Remember 的 context 版本，ctx 会传递给 create，ctx 取消时返回 ctx.Err()
+	// 缓存后端不可用时直接调用 create，不会因为缓存故障而失败
+	RememberCtx(ctx context.Context, k string, expireSeconds int64, create func(ctx context.Context) (any, error)) (any, error)
+	// RememberStaleCtx RememberStale 的 context 版本
+	RememberStaleCtx(ctx context.Context, k string, softSeconds, hardSeconds int64, create func(ctx context.Context) (any, error)) (any, error)
+	// RememberForeverCtx RememberForever 的 context 版本
+	RememberForeverCtx(ctx context.Context, k string, create func(ctx context.Context) (any, error)) (any, error)
+	// ForgetCtx Forget 的 context 版本
+	ForgetCtx(ctx context.Context, k string) error
+	// FlushCtx Flush 的 context 版本
+	FlushCtx(ctx context.Context) error
+	// GetManyCtx GetMany 的 context 版本
+	GetManyCtx(ctx context.Context, keys []string) (map[string]any, []string, error)
+	// PutManyCtx PutMany 的 context 版本
+	PutManyCtx(ctx context.Context, items map[string]any, expireSeconds int64) error
+	// ForgetManyCtx ForgetMany 的 context 版本
+	ForgetManyCtx(ctx context.Context, keys []string) error
+	// CompareAndSwapCtx CompareAndSwap 的 context 版本
+	CompareAndSwapCtx(ctx context.Context, k string, old, new any, expireSeconds int64) (bool, error)
+	// UpdateCtx Update 的 context 版本
+	UpdateCtx(ctx context.Context, k string, fn func(old any, exists bool) (any, error)) (any, error)
+	// ScanCtx Scan 的 context 版本
+	ScanCtx(ctx context.Context, pattern string, fn func(k string) bool) error
+	// LenCtx Len 的 context 版本
+	LenCtx(ctx context.Context) (int, error)
+	// ForgetPatternCtx ForgetPattern 的 context 版本
+	ForgetPatternCtx(ctx context.Context, pattern string) error
 }
@@ -1,51 +1,118 @@
 package cachex
 
 import (
+	"context"
+	"errors"
+	"sync"
 	"time"
 
 	"github.com/yu1ec/go-pkg/cachex/driver"
+	"github.com/yu1ec/go-pkg/cachex/metrics"
 )
 
-func New(driverName string, config any) (Cache, error) {
+// lockRetryInterval 未获取到分布式锁时轮询缓存的间隔
+const lockRetryInterval = 50 * time.Millisecond
+
+// Missing 是负缓存的哨兵值，表示该键对应的数据已知不存在
+// 使用字符串以保证在各个驱动中都能原样往返
+const Missing = "\x00cachex:missing\x00"
+
+var (
+	// ErrMissing Remember 命中负缓存时返回的错误
+	ErrMissing = errors.New("cachex: key is known missing")
+
+	// ErrCacheMiss 键不存在或已过期
+	ErrCacheMiss = driver.ErrCacheMiss
+
+	// ErrKeyExists 键已存在
+	ErrKeyExists = driver.ErrKeyExists
+
+	// ErrUnavailable 缓存服务不可用，可通过 errors.Is 判断
+	ErrUnavailable = driver.ErrUnavailable
+
+	// ErrNotSupported 驱动不支持该操作
+	ErrNotSupported = driver.ErrNotSupported
+
+	// ErrConflict Update 多次重试后仍与其他写入冲突
+	ErrConflict = driver.ErrConflict
+
+	// ErrInvalidConfig 驱动配置的类型错误，或包含未知、无法解析的参数
+	ErrInvalidConfig = driver.ErrInvalidConfig
+)
+
+// IsMissing 判断 Get 返回的值是否为负缓存哨兵值
+func IsMissing(v any) bool {
+	s, ok := v.(string)
+	return ok && s == Missing
+}
+
+func New(driverName string, config any, opts ...Option) (Cache, error) {
 	d, err := driver.New(driverName, config)
 	if err != nil {
 		return nil, err
 	}
+	return newCache(d, opts...), nil
+}
 
-	return &cacheImpl{
-		driver: d,
-	}, nil
+// Open 根据 DSN 创建缓存，如 redis://:pass@host:6379/2?prefix=app&codec=json、memory://?implementation=lru&max_entries=10000
+// 或 file:///var/cache/app，协议名由驱动注册，需要导入对应的驱动包；未知的参数返回包装了 ErrInvalidConfig 的错误
+func Open(dsn string, opts ...Option) (Cache, error) {
+	d, err := driver.Open(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return newCache(d, opts...), nil
+}
 
+func newCache(d driver.Driver, opts ...Option) *cacheImpl {
+	c := &cacheImpl{
+		driver: d,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
 type cacheImpl struct {
 	driver driver.Driver
+	group  group
+	prefix string
+
+	lockTTL  time.Duration
+	lockWait time.Duration
+
+	negativeSeconds int64
+	negativeMatch   func(error) bool
+
+	name  string
+	stats metrics.Stats
+
+	earlyBeta  float64
+	refreshMu  sync.Mutex
+	refreshing map[string]struct{}
 }
 
 func (c *cacheImpl) Get(k string) (any, bool) {
-	return c.driver.Get(k)
+	v, err := c.GetCtx(context.Background(), k)
+	return v, err == nil
 }
 
 func (c *cacheImpl) Put(k string, v any, expireSeconds int64) {
-	d := time.Duration(expireSeconds) * time.Second
-	c.driver.Set(k, v, d)
+	_ = c.PutCtx(context.Background(), k, v, expireSeconds)
 }
 
 func (c *cacheImpl) Exists(k string) bool {
-	_, exists := c.driver.Get(k)
+	exists, _ := c.ExistsCtx(context.Background(), k)
 	return exists
 }
 
+// Remember 同一个键在进程内只有一个调用者执行 create，其余调用者等待并共享其结果
+// create 返回错误时不会写入缓存，开启负缓存后会写入 Missing 并在命中时返回 ErrMissing
 func (c *cacheImpl) Remember(k string, expireSeconds int64, create func() (any, error)) (any, error) {
-	v, exists := c.Get(k)
-	if exists {
-		return v, nil
-	}
-
-	v, err := create()
-	c.Put(k, v, expireSeconds)
-
-	return v, err
+	return c.RememberCtx(context.Background(), k, expireSeconds, func(context.Context) (any, error) {
+		return create()
+	})
 }
 
 func (c *cacheImpl) RememberForever(k string, create func() (any, error)) (any, error) {
@@ -53,9 +120,145 @@ func (c *cacheImpl) RememberForever(k string, create func() (any, error)) (any,
 }
 
 func (c *cacheImpl) Forget(k string) {
-	c.driver.Delete(k)
+	_ = c.ForgetCtx(context.Background(), k)
 }
 
 func (c *cacheImpl) Flush() {
-	c.driver.Flush()
+	_ = c.FlushCtx(context.Background())
+}
+
+func (c *cacheImpl) GetCtx(ctx context.Context, k string) (any, error) {
+	v, err := c.driver.GetCtx(ctx, c.key(k))
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func (c *cacheImpl) PutCtx(ctx context.Context, k string, v any, expireSeconds int64) error {
+	d := time.Duration(expireSeconds) * time.Second
+	return c.driver.SetCtx(ctx, c.key(k), v, d)
+}
+
+func (c *cacheImpl) ExistsCtx(ctx context.Context, k string) (bool, error) {
+	_, err := c.driver.GetCtx(ctx, c.key(k))
+	if errors.Is(err, ErrCacheMiss) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (c *cacheImpl) RememberCtx(ctx context.Context, k string, expireSeconds int64, create func(ctx context.Context) (any, error)) (any, error) {
+	if v, err := c.GetCtx(ctx, k); err == nil {
+		return c.cached(v)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return c.group.Do(ctx, k, func() (any, error) {
+		// 等待期间可能已被其他调用者填充
+		if v, err := c.GetCtx(ctx, k); err == nil {
+			return c.cached(v)
+		}
+
+		if locker, ok := driver.As[driver.Locker](c.driver); ok && c.lockTTL > 0 {
+			return c.rememberLocked(ctx, locker, k, expireSeconds, create)
+		}
+		return c.load(ctx, k, expireSeconds, create)
+	})
+}
+
+func (c *cacheImpl) RememberForeverCtx(ctx context.Context, k string, create func(ctx context.Context) (any, error)) (any, error) {
+	return c.RememberCtx(ctx, k, -1, create)
+}
+
+func (c *cacheImpl) ForgetCtx(ctx context.Context, k string) error {
+	return c.driver.DeleteCtx(ctx, c.key(k))
+}
+
+// FlushCtx 设置了前缀时只删除该前缀下的键，需要驱动实现 driver.PrefixFlusher
+func (c *cacheImpl) FlushCtx(ctx context.Context) error {
+	if c.prefix == "" {
+		return c.driver.FlushCtx(ctx)
+	}
+	flusher, ok := driver.As[driver.PrefixFlusher](c.driver)
+	if !ok {
+		return ErrNotSupported
+	}
+	return flusher.FlushPrefix(ctx, c.prefix)
+}
+
+// key 返回加上命名空间前缀后的键
+func (c *cacheImpl) key(k string) string {
+	return c.prefix + k
+}
+
+// rememberLocked 通过分布式锁保证多个实例之间只有一个执行 create
+func (c *cacheImpl) rememberLocked(ctx context.Context, locker driver.Locker, k string, expireSeconds int64, create func(ctx context.Context) (any, error)) (any, error) {
+	unlock, ok, err := locker.TryLock(ctx, c.key(k), c.lockTTL)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		// 锁服务不可用时退化为进程内保护
+		return c.load(ctx, k, expireSeconds, create)
+	}
+	if ok {
+		defer unlock()
+		if v, err := c.GetCtx(ctx, k); err == nil {
+			return c.cached(v)
+		}
+		return c.load(ctx, k, expireSeconds, create)
+	}
+
+	timer := time.NewTimer(c.lockWait)
+	defer timer.Stop()
+	ticker := time.NewTicker(lockRetryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timer.C:
+			return c.load(ctx, k, expireSeconds, create)
+		case <-ticker.C:
+			if v, err := c.GetCtx(ctx, k); err == nil {
+				return c.cached(v)
+			}
+		}
+	}
+}
+
+func (c *cacheImpl) load(ctx context.Context, k string, expireSeconds int64, create func(ctx context.Context) (any, error)) (any, error) {
+	v, err := c.call(ctx, create)
+	if err != nil {
+		if c.negativeSeconds > 0 && (c.negativeMatch == nil || c.negativeMatch(err)) {
+			_ = c.PutCtx(ctx, k, Missing, c.negativeSeconds)
+		}
+		return nil, err
+	}
+	// 写入失败不影响本次结果，下次调用会重新加载
+	_ = c.PutCtx(ctx, k, v, expireSeconds)
+
+	return v, nil
+}
+
+// call 执行加载函数，开启指标采集时记录调用次数与耗时
+func (c *cacheImpl) call(ctx context.Context, create func(ctx context.Context) (any, error)) (any, error) {
+	if c.stats == nil {
+		return create(ctx)
+	}
+	start := time.Now()
+	v, err := create(ctx)
+	c.stats.ObserveLoad(c.name, time.Since(start), err)
+	return v, err
+}
+
+// cached 将缓存中读取到的值转换为 Remember 的返回值
+func (c *cacheImpl) cached(v any) (any, error) {
+	if IsMissing(v) {
+		return nil, ErrMissing
+	}
+	return v, nil
 }
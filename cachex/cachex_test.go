@@ -0,0 +1,604 @@
+package cachex_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/yu1ec/go-pkg/cachex"
+	"github.com/yu1ec/go-pkg/cachex/driver/file"
+	_ "github.com/yu1ec/go-pkg/cachex/driver/memory"
+	"github.com/yu1ec/go-pkg/cachex/driver/memory/gocache"
+	"github.com/yu1ec/go-pkg/cachex/driver/redis"
+	"github.com/yu1ec/go-pkg/cachex/driver/tiered"
+)
+
+func newMemoryCache(t *testing.T, opts ...cachex.Option) cachex.Cache {
+	c, err := cachex.New("memory", map[string]any{}, opts...)
+	if err != nil {
+		t.Fatalf("failed to create memory cache: %v", err)
+	}
+	return c
+}
+
+func TestRememberSingleflight(t *testing.T) {
+	c := newMemoryCache(t)
+
+	var calls int32
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := c.Remember("hot", 60, func() (any, error) {
+				atomic.AddInt32(&calls, 1)
+				time.Sleep(50 * time.Millisecond)
+				return "value", nil
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, "value", v)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestRememberDistributedLock(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	defer mr.Close()
+
+	// 模拟两个实例共享同一个 Redis
+	var caches []cachex.Cache
+	for i := 0; i < 2; i++ {
+		c, err := cachex.New("redis", &redis.RedisConfig{Addr: mr.Addr()},
+			cachex.WithDistributedLock(time.Second, 2*time.Second))
+		if err != nil {
+			t.Fatalf("failed to create redis cache: %v", err)
+		}
+		caches = append(caches, c)
+	}
+
+	var calls int32
+	var wg sync.WaitGroup
+	for _, c := range caches {
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func(c cachex.Cache) {
+				defer wg.Done()
+				v, err := c.Remember("hot", 60, func() (any, error) {
+					atomic.AddInt32(&calls, 1)
+					time.Sleep(200 * time.Millisecond)
+					return "value", nil
+				})
+				assert.NoError(t, err)
+				assert.Equal(t, "value", v)
+			}(c)
+		}
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	assert.False(t, mr.Exists("cachex:lock:hot"))
+}
+
+func TestRememberDoesNotCacheErrors(t *testing.T) {
+	c := newMemoryCache(t)
+
+	loadErr := errors.New("db down")
+	_, err := c.Remember("user:1", 60, func() (any, error) {
+		return nil, loadErr
+	})
+	assert.ErrorIs(t, err, loadErr)
+	assert.False(t, c.Exists("user:1"))
+
+	v, err := c.Remember("user:1", 60, func() (any, error) {
+		return "alice", nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", v)
+}
+
+func TestRememberNegativeCache(t *testing.T) {
+	errNotFound := errors.New("not found")
+	c := newMemoryCache(t, cachex.WithNegativeCache(5, func(err error) bool {
+		return errors.Is(err, errNotFound)
+	}))
+
+	// 不匹配的错误不会被缓存
+	_, err := c.Remember("user:1", 60, func() (any, error) {
+		return nil, errors.New("timeout")
+	})
+	assert.Error(t, err)
+	assert.False(t, c.Exists("user:1"))
+
+	_, err = c.Remember("user:1", 60, func() (any, error) {
+		return nil, errNotFound
+	})
+	assert.ErrorIs(t, err, errNotFound)
+
+	v, exists := c.Get("user:1")
+	assert.True(t, exists)
+	assert.True(t, cachex.IsMissing(v))
+
+	var calls int
+	_, err = c.Remember("user:1", 60, func() (any, error) {
+		calls++
+		return "alice", nil
+	})
+	assert.ErrorIs(t, err, cachex.ErrMissing)
+	assert.Equal(t, 0, calls)
+}
+
+func TestRememberCtxCanceledWaiter(t *testing.T) {
+	c := newMemoryCache(t)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	go func() {
+		_, _ = c.RememberCtx(context.Background(), "slow", 60, func(ctx context.Context) (any, error) {
+			close(started)
+			<-release
+			return "value", nil
+		})
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := c.RememberCtx(ctx, "slow", 60, func(ctx context.Context) (any, error) {
+		t.Error("create should not be called while another caller is loading")
+		return nil, nil
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	close(release)
+}
+
+func TestRememberCtxCanceledLeader(t *testing.T) {
+	c := newMemoryCache(t)
+
+	leaderCtx, cancelLeader := context.WithCancel(context.Background())
+	started := make(chan struct{})
+	go func() {
+		_, err := c.RememberCtx(leaderCtx, "slow", 60, func(ctx context.Context) (any, error) {
+			close(started)
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+		assert.ErrorIs(t, err, context.Canceled)
+	}()
+	<-started
+
+	// 执行者的请求被取消时，等待者不应收到其他请求的取消错误，而是自己重新加载
+	result := make(chan any)
+	go func() {
+		v, err := c.RememberCtx(context.Background(), "slow", 60, func(ctx context.Context) (any, error) {
+			return "value", nil
+		})
+		assert.NoError(t, err)
+		result <- v
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancelLeader()
+	assert.Equal(t, "value", <-result)
+}
+
+func TestRememberPanic(t *testing.T) {
+	c := newMemoryCache(t)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	leaderPanic := make(chan any)
+	go func() {
+		defer func() { leaderPanic <- recover() }()
+		_, _ = c.Remember("boom", 60, func() (any, error) {
+			close(started)
+			<-release
+			panic("loader failed")
+		})
+	}()
+	<-started
+
+	waiterPanic := make(chan any)
+	go func() {
+		defer func() { waiterPanic <- recover() }()
+		_, _ = c.Remember("boom", 60, func() (any, error) {
+			t.Error("create should not be called while another caller is loading")
+			return nil, nil
+		})
+	}()
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+
+	assert.Equal(t, "loader failed", <-leaderPanic)
+	r := <-waiterPanic
+	assert.NotNil(t, r)
+	assert.Contains(t, fmt.Sprint(r), "loader failed")
+
+	// panic 之后该键仍可以正常加载
+	v, err := c.Remember("boom", 60, func() (any, error) { return "ok", nil })
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", v)
+}
+
+func TestContextCanceled(t *testing.T) {
+	c := newMemoryCache(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := c.PutCtx(ctx, "key", "value", 60)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.False(t, c.Exists("key"))
+
+	c.Put("key", "value", 60)
+	_, err = c.GetCtx(ctx, "key")
+	assert.ErrorIs(t, err, context.Canceled)
+
+	_, err = c.RememberCtx(ctx, "other", 60, func(ctx context.Context) (any, error) {
+		return "value", nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestGetCtxErrors(t *testing.T) {
+	c := newMemoryCache(t)
+	ctx := context.Background()
+
+	_, err := c.GetCtx(ctx, "missing")
+	assert.ErrorIs(t, err, cachex.ErrCacheMiss)
+
+	exists, err := c.ExistsCtx(ctx, "missing")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	assert.NoError(t, c.PutCtx(ctx, "key", "value", 60))
+	v, err := c.GetCtx(ctx, "key")
+	assert.NoError(t, err)
+	assert.Equal(t, "value", v)
+}
+
+func TestRedisUnavailable(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	c, err := cachex.New("redis", &redis.RedisConfig{Addr: mr.Addr()})
+	if err != nil {
+		t.Fatalf("failed to create redis cache: %v", err)
+	}
+	mr.Close()
+
+	ctx := context.Background()
+	_, err = c.GetCtx(ctx, "key")
+	assert.ErrorIs(t, err, cachex.ErrUnavailable)
+	assert.ErrorIs(t, c.PutCtx(ctx, "key", "value", 60), cachex.ErrUnavailable)
+
+	// 缓存不可用时 Remember 直接调用 create
+	v, err := c.RememberCtx(ctx, "key", 60, func(ctx context.Context) (any, error) {
+		return "value", nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "value", v)
+}
+
+func TestWithPrefix(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	defer mr.Close()
+
+	newCache := func(prefix string) cachex.Cache {
+		c, err := cachex.New("redis", &redis.RedisConfig{Addr: mr.Addr()}, cachex.WithPrefix(prefix))
+		if err != nil {
+			t.Fatalf("failed to create redis cache: %v", err)
+		}
+		return c
+	}
+	a := newCache("a:")
+	b := newCache("b:")
+
+	a.Put("key", "from a", 60)
+	b.Put("key", "from b", 60)
+	assert.True(t, mr.Exists("a:key"))
+
+	a.Flush()
+	assert.False(t, a.Exists("key"))
+	v, exists := b.Get("key")
+	assert.True(t, exists)
+	assert.Equal(t, "from b", v)
+}
+
+func TestRememberStale(t *testing.T) {
+	c := newMemoryCache(t)
+
+	var calls int32
+	create := func() (any, error) {
+		return atomic.AddInt32(&calls, 1), nil
+	}
+
+	v, err := c.RememberStale("report", 60, 120, create)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), v)
+
+	// 仍在软过期时间内，直接返回缓存
+	v, err = c.RememberStale("report", 60, 120, create)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), v)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	// 剩余 30 秒，已超过软过期时间：立即返回陈旧值并在后台刷新一次
+	c.Put("report", "stale", 30)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := c.RememberStale("report", 60, 120, func() (any, error) {
+				time.Sleep(50 * time.Millisecond)
+				return create()
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, "stale", v)
+		}()
+	}
+	wg.Wait()
+
+	assert.Eventually(t, func() bool {
+		v, _ := c.RememberStale("report", 60, 120, create)
+		return v == int32(2)
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestRememberStaleRefreshPanic(t *testing.T) {
+	c := newMemoryCache(t)
+	c.Put("report", "stale", 30)
+
+	var calls int32
+	v, err := c.RememberStale("report", 60, 120, func() (any, error) {
+		atomic.AddInt32(&calls, 1)
+		panic("boom")
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "stale", v)
+
+	// 后台刷新的 panic 不会使进程崩溃，放弃刷新后陈旧值保留，下一次读取会重新刷新
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&calls) == 1
+	}, time.Second, 10*time.Millisecond)
+	assert.Eventually(t, func() bool {
+		v, err := c.RememberStale("report", 60, 120, func() (any, error) {
+			return "fresh", nil
+		})
+		return err == nil && v == "fresh"
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestGetDoesNotUnwrapUserValues(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rc, err := cachex.New("redis", &redis.RedisConfig{Addr: mr.Addr()})
+	assert.NoError(t, err)
+	jc, err := cachex.New("redis", &redis.RedisConfig{Addr: mr.Addr(), Prefix: "json:", Codec: "json"})
+	assert.NoError(t, err)
+
+	// 与 RememberStale 写入的编码形式相同的用户值按原样返回
+	s := "\x00cachex:stale\x001:0:value"
+	rc.Put("s", s, 60)
+	v, _ := rc.Get("s")
+	assert.Equal(t, s, v)
+
+	m := map[string]any{"cachex_stale_soft": float64(1), "cachex_stale_value": "value"}
+	jc.Put("m", m, 60)
+	v, _ = jc.Get("m")
+	assert.Equal(t, m, v)
+	found, _ := jc.GetMany([]string{"m"})
+	assert.Equal(t, m, found["m"])
+}
+
+func TestRememberStaleRefreshError(t *testing.T) {
+	c := newMemoryCache(t)
+	c.Put("report", "stale", 30)
+
+	var calls int32
+	v, err := c.RememberStale("report", 60, 120, func() (any, error) {
+		atomic.AddInt32(&calls, 1)
+		return nil, errors.New("boom")
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "stale", v)
+
+	// 刷新失败时保留陈旧值
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&calls) == 1
+	}, time.Second, 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	v, found := c.Get("report")
+	assert.True(t, found)
+	assert.Equal(t, "stale", v)
+}
+
+func TestRememberRedisCodecs(t *testing.T) {
+	mr := miniredis.RunT(t)
+	for _, codec := range []string{"json", "gob", "msgpack"} {
+		t.Run(codec, func(t *testing.T) {
+			c, err := cachex.New("redis", &redis.RedisConfig{Addr: mr.Addr(), Prefix: codec + ":", Codec: codec})
+			assert.NoError(t, err)
+
+			var calls int32
+			for i := 0; i < 3; i++ {
+				v, err := c.Remember("report", 60, func() (any, error) {
+					atomic.AddInt32(&calls, 1)
+					return "data", nil
+				})
+				assert.NoError(t, err)
+				assert.Equal(t, "data", v)
+			}
+			assert.Equal(t, int32(1), calls)
+
+			v, err := c.GetCtx(context.Background(), "report")
+			assert.NoError(t, err)
+			assert.Equal(t, "data", v)
+		})
+	}
+}
+
+func TestRememberStaleDrivers(t *testing.T) {
+	mr := miniredis.RunT(t)
+	newCache := func(driverName string, config any) cachex.Cache {
+		c, err := cachex.New(driverName, config)
+		if err != nil {
+			t.Fatalf("failed to create %s cache: %v", driverName, err)
+		}
+		return c
+	}
+	caches := map[string]cachex.Cache{
+		"gocache":       newMemoryCache(t),
+		"file":          newCache("file", &file.FileConfig{Dir: t.TempDir()}),
+		"file json":     newCache("file", &file.FileConfig{Dir: t.TempDir(), Codec: "json"}),
+		"redis":         newCache("redis", &redis.RedisConfig{Addr: mr.Addr(), Prefix: "plain:"}),
+		"redis json":    newCache("redis", &redis.RedisConfig{Addr: mr.Addr(), Prefix: "json:", Codec: "json"}),
+		"redis gob":     newCache("redis", &redis.RedisConfig{Addr: mr.Addr(), Prefix: "gob:", Codec: "gob"}),
+		"redis msgpack": newCache("redis", &redis.RedisConfig{Addr: mr.Addr(), Prefix: "msgpack:", Codec: "msgpack"}),
+	}
+
+	calls := make(map[string]*int32)
+	creator := func(name string) func() (any, error) {
+		return func() (any, error) {
+			return fmt.Sprintf("v%d", atomic.AddInt32(calls[name], 1)), nil
+		}
+	}
+	for name, c := range caches {
+		calls[name] = new(int32)
+		for i := 0; i < 2; i++ {
+			v, err := c.RememberStale("report", 1, 600, creator(name))
+			assert.NoError(t, err, name)
+			assert.Equal(t, "v1", v, name)
+		}
+	}
+
+	// 软过期时间保存在值中，不受驱动返回的过期时间影响
+	time.Sleep(1100 * time.Millisecond)
+	for name, c := range caches {
+		assert.Equal(t, int32(1), atomic.LoadInt32(calls[name]), name)
+		v, err := c.RememberStale("report", 1, 600, creator(name))
+		assert.NoError(t, err, name)
+		assert.Equal(t, "v1", v, name)
+		assert.Eventually(t, func() bool {
+			v, _ := c.RememberStale("report", 1, 600, creator(name))
+			return v == "v2"
+		}, time.Second, 10*time.Millisecond, name)
+	}
+}
+
+func TestRememberStaleTiered(t *testing.T) {
+	mr := miniredis.RunT(t)
+	c, err := cachex.New("tiered", &tiered.TieredConfig{L2: &redis.RedisConfig{Addr: mr.Addr()}})
+	if err != nil {
+		t.Fatalf("failed to create tiered cache: %v", err)
+	}
+
+	var calls int32
+	create := func() (any, error) {
+		return fmt.Sprint(atomic.AddInt32(&calls, 1)), nil
+	}
+
+	// 第二次读取命中一级缓存，软过期时间仍需按二级缓存的过期时间计算，不应触发刷新
+	for i := 0; i < 3; i++ {
+		v, err := c.RememberStale("report", 60, 600, create)
+		assert.NoError(t, err)
+		assert.Equal(t, "1", v)
+	}
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestRememberStaleEarlyRefresh(t *testing.T) {
+	c := newMemoryCache(t, cachex.WithEarlyRefresh(1e5))
+
+	var calls int32
+	create := func() (any, error) {
+		time.Sleep(10 * time.Millisecond)
+		return atomic.AddInt32(&calls, 1), nil
+	}
+
+	_, err := c.RememberStale("report", 60, 120, create)
+	assert.NoError(t, err)
+
+	// create 耗时约 10ms，beta 很大时在软过期之前就会被提前刷新
+	assert.Eventually(t, func() bool {
+		_, err := c.RememberStale("report", 60, 120, create)
+		return err == nil && atomic.LoadInt32(&calls) >= 2
+	}, 2*time.Second, 20*time.Millisecond)
+}
+
+func TestOnRemoval(t *testing.T) {
+	c, err := cachex.New("memory_gocache", &gocache.GoCacheConfig{CleanupInterval: 10 * time.Millisecond}, cachex.WithPrefix("app:"))
+	if err != nil {
+		t.Fatalf("failed to create memory cache: %v", err)
+	}
+
+	var mu sync.Mutex
+	var events []cachex.RemovalEvent
+	cancel, err := c.OnRemoval(func(e cachex.RemovalEvent) {
+		mu.Lock()
+		events = append(events, e)
+		mu.Unlock()
+	})
+	assert.NoError(t, err)
+	defer cancel()
+
+	c.Put("user", "alice", 60)
+	c.Forget("user")
+	c.Forget("missing")
+	c.Put("session", "s", 1)
+
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(events) == 2
+	}, 3*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []cachex.RemovalEvent{
+		{Key: "user", Value: "alice", HasValue: true, Reason: cachex.RemovalDeleted},
+		{Key: "session", Value: "s", HasValue: true, Reason: cachex.RemovalExpired},
+	}, events)
+
+	redisCache, err := cachex.New("redis", &redis.RedisConfig{Addr: miniredis.RunT(t).Addr()})
+	assert.NoError(t, err)
+	_, err = redisCache.OnRemoval(func(cachex.RemovalEvent) {})
+	assert.NoError(t, err)
+}
+
+func TestOpen(t *testing.T) {
+	mr := miniredis.RunT(t)
+
+	c, err := cachex.Open("redis://"+mr.Addr()+"/0?prefix=app:&codec=json", cachex.WithPrefix("users:"))
+	assert.NoError(t, err)
+	c.Put("1", map[string]any{"name": "alice"}, 60)
+	assert.True(t, mr.Exists("app:users:1"))
+	v, found := c.Get("1")
+	assert.True(t, found)
+	assert.Equal(t, map[string]any{"name": "alice"}, v)
+
+	c, err = cachex.Open("memory://?implementation=lru&max_entries=10000")
+	assert.NoError(t, err)
+	c.Put("k", "v", 60)
+	assert.True(t, c.Exists("k"))
+
+	_, err = cachex.Open("redis://" + mr.Addr() + "?prefx=app")
+	assert.ErrorIs(t, err, cachex.ErrInvalidConfig)
+
+	_, err = cachex.Open("mysql://localhost")
+	assert.Error(t, err)
+}
@@ -0,0 +1,142 @@
+package driver
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Codec 是缓存值的序列化接口，用于需要以字节形式存储值的驱动（如 Redis）
+type Codec interface {
+	// Name 返回编解码器名称，用于在配置中选择编解码器
+	Name() string
+	// Marshal 将 v 编码为字节
+	Marshal(v any) ([]byte, error)
+	// Unmarshal 将 data 解码到 v 中，v 必须是指针
+	Unmarshal(data []byte, v any) error
+}
+
+var (
+	// JSONCodec 使用 encoding/json 编解码，可读性好，解码到 any 时数字为 float64
+	JSONCodec Codec = jsonCodec{}
+
+	// GobCodec 使用 encoding/gob 编解码，保留 Go 类型信息
+	// 驱动以 any 的形式编码值，通过 gob.Register 注册的类型可以直接由 Get 读取，未注册的类型只能通过 GetInto 解码到具体类型
+	GobCodec Codec = gobCodec{}
+
+	// MsgpackCodec 使用 MessagePack 二进制格式编解码，通过反射直接编码，体积比 JSON 更小
+	// 结构体字段与 JSON 一样遵循 json 标签，并且保留 []byte、NaN 与无穷大等 JSON 无法表示的值
+	MsgpackCodec Codec = msgpackCodec{}
+)
+
+var (
+	codecMu sync.RWMutex
+	codecs  = map[string]Codec{
+		JSONCodec.Name():    JSONCodec,
+		GobCodec.Name():     GobCodec,
+		MsgpackCodec.Name(): MsgpackCodec,
+	}
+)
+
+// RegisterCodec 注册一个自定义编解码器，注册后可以在驱动配置中通过名称选择
+func RegisterCodec(c Codec) {
+	codecMu.Lock()
+	defer codecMu.Unlock()
+	if c == nil {
+		panic("缓存: 注册的编解码器是 nil")
+	}
+	if _, dup := codecs[c.Name()]; dup {
+		panic("缓存：注册重复的编解码器名称 " + c.Name())
+	}
+	codecs[c.Name()] = c
+}
+
+// LookupCodec 根据名称查找编解码器
+func LookupCodec(name string) (Codec, error) {
+	codecMu.RLock()
+	c, ok := codecs[name]
+	codecMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("缓存：未注册的编解码器名称 %s", name)
+	}
+	return c, nil
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string { return "json" }
+
+func (jsonCodec) Marshal(v any) ([]byte, error) { return json.Marshal(v) }
+
+func (jsonCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+
+type gobCodec struct{}
+
+func (gobCodec) Name() string { return "gob" }
+
+func (gobCodec) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobCodec) Unmarshal(data []byte, v any) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// compressedMagic 压缩数据的前缀
+// 各内置编解码器的输出都不会以 0x00 开头且长度大于 1，因此可以与未压缩数据区分
+var compressedMagic = []byte("\x00cz")
+
+// Serializer 组合编解码器与可选的压缩，编码后的数据超过 CompressThreshold 字节时使用 flate 压缩
+// 未压缩的数据与 Codec 的输出完全一致，因此 JSON 编码的整数仍然可以被 Redis INCRBY 等命令处理
+type Serializer struct {
+	Codec Codec
+	// CompressThreshold 压缩阈值，单位/字节，0 表示不压缩
+	CompressThreshold int
+}
+
+// Marshal 编码 v，必要时进行压缩
+func (s *Serializer) Marshal(v any) ([]byte, error) {
+	data, err := s.Codec.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if s.CompressThreshold <= 0 || len(data) <= s.CompressThreshold {
+		return data, nil
+	}
+
+	var buf bytes.Buffer
+	buf.Write(compressedMagic)
+	w, err := flate.NewWriter(&buf, flate.DefaultCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal 解码 data 到 v 中，自动识别压缩数据
+func (s *Serializer) Unmarshal(data []byte, v any) error {
+	if bytes.HasPrefix(data, compressedMagic) {
+		r := flate.NewReader(bytes.NewReader(data[len(compressedMagic):]))
+		defer r.Close()
+		raw, err := io.ReadAll(r)
+		if err != nil {
+			return fmt.Errorf("decompress: %w", err)
+		}
+		data = raw
+	}
+	return s.Codec.Unmarshal(data, v)
+}
@@ -0,0 +1,151 @@
+package driver_test
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yu1ec/go-pkg/cachex/driver"
+)
+
+type profile struct {
+	ID      int64             `json:"id"`
+	Name    string            `json:"name"`
+	Score   float64           `json:"score"`
+	Active  bool              `json:"active"`
+	Tags    []string          `json:"tags"`
+	Attrs   map[string]string `json:"attrs"`
+	Comment *string           `json:"comment"`
+}
+
+func TestCodecs(t *testing.T) {
+	want := profile{
+		ID:     -1 << 40,
+		Name:   strings.Repeat("名", 100),
+		Score:  3.14,
+		Active: true,
+		Tags:   []string{"a", "b"},
+		Attrs:  map[string]string{"k": "v"},
+	}
+
+	for _, name := range []string{"json", "gob", "msgpack"} {
+		t.Run(name, func(t *testing.T) {
+			codec, err := driver.LookupCodec(name)
+			assert.NoError(t, err)
+
+			data, err := codec.Marshal(want)
+			assert.NoError(t, err)
+
+			var got profile
+			assert.NoError(t, codec.Unmarshal(data, &got))
+			assert.Equal(t, want, got)
+		})
+	}
+
+	_, err := driver.LookupCodec("unknown")
+	assert.Error(t, err)
+}
+
+func TestMsgpackGeneric(t *testing.T) {
+	data, err := driver.MsgpackCodec.Marshal(map[string]any{"n": 1, "f": 1.5, "s": "x", "l": []int{1, 2}})
+	assert.NoError(t, err)
+
+	var got any
+	assert.NoError(t, driver.MsgpackCodec.Unmarshal(data, &got))
+	assert.Equal(t, map[string]any{"n": int64(1), "f": 1.5, "s": "x", "l": []any{int64(1), int64(2)}}, got)
+}
+
+type level int
+
+type base struct {
+	ID      int64     `json:"id"`
+	Created time.Time `json:"created"`
+}
+
+type Extra struct {
+	Note string `json:"note"`
+}
+
+type record struct {
+	base
+	*Extra
+	Level   level          `json:"level"`
+	Raw     []byte         `json:"raw"`
+	Ratio   float64        `json:"ratio"`
+	Small   float32        `json:"small"`
+	Big     uint64         `json:"big"`
+	Skip    string         `json:"-"`
+	Empty   string         `json:"empty,omitempty"`
+	ByID    map[int]string `json:"by_id"`
+	Fixed   [2]int8        `json:"fixed"`
+	Any     any            `json:"any"`
+	private int
+}
+
+func TestMsgpackReflect(t *testing.T) {
+	want := record{
+		base:  base{ID: 7, Created: time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC)},
+		Extra: &Extra{Note: "n"},
+		Level: 3,
+		Raw:   []byte{0, 1, 0xff},
+		Ratio: math.Inf(-1),
+		Small: 1.5,
+		Big:   math.MaxUint64,
+		Skip:  "skip",
+		ByID:  map[int]string{-1: "a", 2: "b"},
+		Fixed: [2]int8{-128, 127},
+		Any:   []any{"x", int64(1)},
+	}
+	data, err := driver.MsgpackCodec.Marshal(want)
+	assert.NoError(t, err)
+
+	var got record
+	assert.NoError(t, driver.MsgpackCodec.Unmarshal(data, &got))
+	want.Skip = ""
+	assert.Equal(t, want, got)
+
+	// 嵌入结构体的字段提升到外层，omitempty 与 "-" 的字段不编码，time.Time 使用其 JSON 编码
+	var generic map[string]any
+	assert.NoError(t, driver.MsgpackCodec.Unmarshal(data, &generic))
+	assert.Equal(t, "n", generic["note"])
+	assert.Equal(t, "2024-01-02T03:04:05.000000006Z", generic["created"])
+	assert.Equal(t, []byte{0, 1, 0xff}, generic["raw"])
+	assert.Equal(t, map[string]any{"-1": "a", "2": "b"}, generic["by_id"])
+	assert.NotContains(t, generic, "empty")
+	assert.NotContains(t, generic, "Skip")
+
+	// JSON 无法表示的 NaN 可以正常编码
+	data, err = driver.MsgpackCodec.Marshal(math.NaN())
+	assert.NoError(t, err)
+	var f float64
+	assert.NoError(t, driver.MsgpackCodec.Unmarshal(data, &f))
+	assert.True(t, math.IsNaN(f))
+
+	// 类型不匹配或超出范围时返回错误
+	data, _ = driver.MsgpackCodec.Marshal(300)
+	var small int8
+	assert.Error(t, driver.MsgpackCodec.Unmarshal(data, &small))
+	var s string
+	assert.Error(t, driver.MsgpackCodec.Unmarshal(data, &s))
+	assert.Error(t, driver.MsgpackCodec.Unmarshal(data, s))
+	assert.Error(t, driver.MsgpackCodec.Unmarshal(append(data, 0), new(int)))
+}
+
+func TestSerializerCompression(t *testing.T) {
+	s := &driver.Serializer{Codec: driver.JSONCodec, CompressThreshold: 64}
+
+	small, err := s.Marshal("short")
+	assert.NoError(t, err)
+	assert.Equal(t, `"short"`, string(small))
+
+	long := strings.Repeat("abc", 1000)
+	data, err := s.Marshal(long)
+	assert.NoError(t, err)
+	assert.Less(t, len(data), len(long))
+
+	var got string
+	assert.NoError(t, s.Unmarshal(data, &got))
+	assert.Equal(t, long, got)
+}
@@ -1,6 +1,9 @@
 package driver
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"sync"
 	"time"
@@ -35,9 +38,37 @@ type BaseDriver interface {
 
 	// SetDefault 添加/替换现有的缓存设置,使用默认过期时间
 	SetDefault(k string, x any)
+
+	// GetMany 批量获取缓存，返回找到的键值以及未找到的键
+	GetMany(keys []string) (map[string]any, []string)
+
+	// SetMany 批量添加/替换缓存，所有项目使用相同的过期时间，过期时间语义与 Set 相同
+	SetMany(items map[string]any, d time.Duration)
+
+	// DeleteMany 批量删除缓存
+	DeleteMany(keys []string)
+}
+
+// ContextDriver 是 BaseDriver 的 context 版本，用于向驱动传递取消信号、超时和链路追踪信息
+// 所有读写操作都返回错误：键不存在时返回 ErrCacheMiss，Add 时键已存在返回 ErrKeyExists，
+// 后端不可用时返回包装了 ErrUnavailable 的错误，ctx 取消或超时时返回 ctx.Err()
+type ContextDriver interface {
+	AddCtx(ctx context.Context, k string, v any, d time.Duration) error
+	DeleteCtx(ctx context.Context, k string) error
+	FlushCtx(ctx context.Context) error
+	GetCtx(ctx context.Context, k string) (any, error)
+	GetWithExpirationCtx(ctx context.Context, k string) (any, time.Time, error)
+	ReplaceCtx(ctx context.Context, k string, x any, d time.Duration) error
+	SetCtx(ctx context.Context, k string, x any, d time.Duration) error
+	SetDefaultCtx(ctx context.Context, k string, x any) error
+	GetManyCtx(ctx context.Context, keys []string) (map[string]any, []string, error)
+	SetManyCtx(ctx context.Context, items map[string]any, d time.Duration) error
+	DeleteManyCtx(ctx context.Context, keys []string) error
 }
 
 // NumericOperations 是所有数值类型驱动程序的基本接口
+// 键不存在时返回 ErrCacheMiss，值不是对应的数值类型时返回 ErrNotNumeric，
+// 结果超出类型的取值范围（包括无符号类型减到负数）时返回 ErrOverflow，出错时值保持不变
 type NumericOperations interface {
 	IncrementInt(k string, n int) (int, error)
 	DecrementInt(k string, n int) (int, error)
@@ -52,11 +83,126 @@ type NumericOperations interface {
 	DecrementUint64(k string, n uint64) (uint64, error)
 }
 
+// FloatOperations 是支持浮点数增减的驱动程序可以实现的可选接口
+type FloatOperations interface {
+	// IncrementFloat64 将键的 float64 值加上 n，n 为负数时减少，键不存在时返回 ErrCacheMiss
+	IncrementFloat64(k string, n float64) (float64, error)
+}
+
+// Counter 是支持自动创建计数器的驱动程序可以实现的可选接口，适用于限流、访问计数等场景
+type Counter interface {
+	// IncrementInt64WithTTL 将键的 int64 值加上 n，键不存在时以 n 为值创建并设置过期时间 d（语义与 Set 相同）
+	// 已存在的键保留剩余的过期时间
+	IncrementInt64WithTTL(ctx context.Context, k string, n int64, d time.Duration) (int64, error)
+}
+
+// Locker 是支持分布式锁的驱动程序可以实现的可选接口
+type Locker interface {
+	// TryLock 尝试获取给定键的锁,锁在 ttl 后自动失效。获取成功时返回释放锁的函数
+	TryLock(ctx context.Context, k string, ttl time.Duration) (unlock func(), ok bool, err error)
+}
+
+// TokenLocker 是支持带持有者令牌的锁的驱动程序可以实现的可选接口，cachex/lock 包基于该接口实现续期与释放
+type TokenLocker interface {
+	// AcquireLock 当 k 未被持有时以 token 持有 ttl 时长，返回是否获取成功
+	AcquireLock(ctx context.Context, k, token string, ttl time.Duration) (bool, error)
+	// ExtendLock 仅当 k 由 token 持有时将剩余的持有时间重置为 ttl，返回是否仍由 token 持有
+	ExtendLock(ctx context.Context, k, token string, ttl time.Duration) (bool, error)
+	// ReleaseLock 仅当 k 由 token 持有时释放，返回是否仍由 token 持有
+	ReleaseLock(ctx context.Context, k, token string) (bool, error)
+}
+
+// NewLockToken 生成随机的锁持有者令牌
+func NewLockToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// ValueDecoder 是以字节形式存储值的驱动可以实现的可选接口，用于将缓存值解码到调用方提供的类型中
+type ValueDecoder interface {
+	// GetInto 读取键 k 的值并解码到 v 中，v 必须是指针，键不存在时返回 ErrCacheMiss
+	GetInto(ctx context.Context, k string, v any) error
+}
+
+// Tagger 是支持缓存标签的驱动程序可以实现的可选接口，用于记录标签与键的从属关系
+type Tagger interface {
+	// TagKeys 将 keys 加入标签 tag，d 为 keys 的过期时间（语义同 SetCtx），驱动可以据此让标签记录随键过期
+	TagKeys(ctx context.Context, tag string, d time.Duration, keys ...string) error
+	// TaggedKeys 返回标签 tag 下记录的所有键，其中可能包含已过期或已删除的键
+	TaggedKeys(ctx context.Context, tag string) ([]string, error)
+	// DeleteTag 删除标签 tag 的从属关系记录，不会删除键本身
+	DeleteTag(ctx context.Context, tag string) error
+}
+
+// PrefixFlusher 是支持按前缀删除键的驱动程序可以实现的可选接口，用于只清空某个命名空间下的缓存
+type PrefixFlusher interface {
+	// FlushPrefix 删除所有以 prefix 开头的键
+	FlushPrefix(ctx context.Context, prefix string) error
+}
+
+// Scanner 是支持遍历键的驱动程序可以实现的可选接口，用于管理工具与按模式批量删除
+// pattern 的语法见 MatchPattern
+type Scanner interface {
+	// Scan 遍历与 pattern 匹配的未过期键，fn 返回 false 时停止；遍历期间写入或删除的键可能被遗漏，分布式的驱动可能重复返回同一个键
+	Scan(pattern string, fn func(k string) bool)
+	// Len 返回缓存中键的数量，可能包含已过期但尚未清理的键
+	Len() int
+	// ScanCtx Scan 的 context 版本
+	ScanCtx(ctx context.Context, pattern string, fn func(k string) bool) error
+	// LenCtx Len 的 context 版本
+	LenCtx(ctx context.Context) (int, error)
+}
+
+// Updater 是支持按键原子更新的驱动程序可以实现的可选接口
+type Updater interface {
+	// CompareAndSwap 当键存在且当前值等于 old 时写入 new 并返回 true，过期时间语义与 Set 相同
+	CompareAndSwap(ctx context.Context, k string, old, new any, d time.Duration) (bool, error)
+	// Update 原子地读取键的当前值并写入 fn 返回的新值，fn 返回错误时不写入并原样返回该错误
+	// 已存在的键保留剩余的过期时间，新键使用默认过期时间；发生并发冲突时 fn 可能被多次调用
+	Update(ctx context.Context, k string, fn func(old any, exists bool) (any, error)) (any, error)
+}
+
+// Pinger 是连接远程服务的驱动程序可以实现的可选接口，用于健康检查
+type Pinger interface {
+	// Ping 检查缓存服务是否可用，不可用时返回包装了 ErrUnavailable 的错误
+	Ping(ctx context.Context) error
+}
+
+// Notifier 是支持删除通知的驱动程序可以实现的可选接口，用于订阅项目因淘汰、过期或显式删除而离开缓存的事件
+type Notifier interface {
+	// OnRemoval 注册回调，返回取消注册的函数。回调在驱动内部的协程中同步调用，不应长时间阻塞
+	OnRemoval(fn func(RemovalEvent)) (cancel func(), err error)
+}
+
 type Driver interface {
 	BaseDriver
+	ContextDriver
 	NumericOperations
 }
 
+// Capabilities 是包装其他驱动的装饰器可以实现的可选接口，用于逐个暴露被包装驱动的可选接口，
+// 使被包装驱动实现的任意可选接口组合都不会因为装饰器的方法集合而丢失
+type Capabilities interface {
+	// Capability target 为指向可选接口类型的指针，被包装的驱动支持该接口时写入装饰器的实现并返回 true
+	Capability(target any) bool
+}
+
+// As 返回 d 实现的可选接口 T，d 本身没有实现 T 时通过 Capabilities 向装饰器查找
+// 检查可选接口时应使用 As 而不是类型断言，被装饰器包装的驱动与未被包装时结果一致
+func As[T any](d Driver) (T, bool) {
+	if v, ok := d.(T); ok {
+		return v, true
+	}
+	var v T
+	if c, ok := d.(Capabilities); ok && c.Capability(&v) {
+		return v, true
+	}
+	return v, false
+}
+
 type DriverFactory func(config any) (Driver, error)
 
 var (
@@ -0,0 +1,376 @@
+// Package drivertest 提供缓存驱动的一致性测试，第三方驱动可以在自己的测试中调用 Run 验证语义与内置驱动一致
+package drivertest
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/yu1ec/go-pkg/cachex/driver"
+)
+
+// TTL 测试中使用的过期时间，驱动的过期精度需要高于该值
+const TTL = 100 * time.Millisecond
+
+// Harness 一致性测试需要的驱动构造方法
+type Harness struct {
+	// New 创建一个空的驱动，defaultExpiration 为过期时间参数为 0 时使用的默认过期时间，0 表示永不过期
+	New func(t *testing.T, defaultExpiration time.Duration) driver.Driver
+	// Advance 使驱动中的时间前进 d，为 nil 时使用 time.Sleep；服务端时间可控时（如 miniredis）可以加快测试
+	Advance func(d time.Duration)
+}
+
+func (h Harness) advance(d time.Duration) {
+	if h.Advance != nil {
+		h.Advance(d)
+		return
+	}
+	time.Sleep(d)
+}
+
+// Run 运行所有一致性测试，过期时间参数的语义与 driver.BaseDriver.Set 一致：
+// 大于 0 为过期时间，0 使用默认过期时间，负数表示永不过期
+func Run(t *testing.T, h Harness) {
+	t.Run("TTL", func(t *testing.T) { testTTL(t, h) })
+	t.Run("DefaultExpiration", func(t *testing.T) { testDefaultExpiration(t, h) })
+	t.Run("AddReplace", func(t *testing.T) { testAddReplace(t, h) })
+	t.Run("Numeric", func(t *testing.T) { testNumeric(t, h) })
+	t.Run("NumericErrors", func(t *testing.T) { testNumericErrors(t, h) })
+	t.Run("Float", func(t *testing.T) { testFloat(t, h) })
+	t.Run("Counter", func(t *testing.T) { testCounter(t, h) })
+	t.Run("Scan", func(t *testing.T) { testScan(t, h) })
+	t.Run("Flush", func(t *testing.T) { testFlush(t, h) })
+}
+
+func testTTL(t *testing.T, h Harness) {
+	ctx := context.Background()
+	d := h.New(t, time.Hour)
+
+	d.Set("ttl", "v", TTL)
+	d.Set("never", "v", -1)
+	d.Set("never-seconds", "v", -time.Second)
+	d.Set("default", "v", 0)
+	d.SetDefault("set-default", "v")
+
+	_, expiration, found := d.GetWithExpiration("ttl")
+	if !found {
+		t.Fatal("ttl: not found")
+	}
+	if remaining := time.Until(expiration); remaining <= 0 || remaining > TTL+time.Second {
+		t.Errorf("ttl: expiration %s out of range", expiration)
+	}
+	for _, k := range []string{"never", "never-seconds"} {
+		_, expiration, found := d.GetWithExpiration(k)
+		if !found || !expiration.IsZero() {
+			t.Errorf("%s: got found=%v expiration=%s, want no expiration", k, found, expiration)
+		}
+	}
+	for _, k := range []string{"default", "set-default"} {
+		_, expiration, found := d.GetWithExpiration(k)
+		if !found || expiration.IsZero() || time.Until(expiration) < 59*time.Minute {
+			t.Errorf("%s: got found=%v expiration=%s, want default expiration", k, found, expiration)
+		}
+	}
+
+	// 覆盖写入时使用新的过期时间，负数不能保留原有的过期时间
+	d.Set("overwrite", "v", TTL)
+	d.Set("overwrite", "v2", -1)
+
+	h.advance(TTL + 50*time.Millisecond)
+	if _, found := d.Get("ttl"); found {
+		t.Error("ttl: found after expiration")
+	}
+	if _, err := d.GetCtx(ctx, "ttl"); !errors.Is(err, driver.ErrCacheMiss) {
+		t.Errorf("ttl: GetCtx returned %v, want ErrCacheMiss", err)
+	}
+	for _, k := range []string{"never", "never-seconds", "default", "set-default"} {
+		if _, found := d.Get(k); !found {
+			t.Errorf("%s: expired", k)
+		}
+	}
+	if v, found := d.Get("overwrite"); !found || v != "v2" {
+		t.Errorf("overwrite: got %v, %v, want v2 without expiration", v, found)
+	}
+}
+
+func testDefaultExpiration(t *testing.T, h Harness) {
+	d := h.New(t, TTL)
+	d.Set("default", "v", 0)
+	d.SetDefault("set-default", "v")
+	d.SetMany(map[string]any{"many": "v"}, 0)
+	d.Set("never", "v", -1)
+
+	h.advance(TTL + 50*time.Millisecond)
+	for _, k := range []string{"default", "set-default", "many"} {
+		if _, found := d.Get(k); found {
+			t.Errorf("%s: found after default expiration", k)
+		}
+	}
+	if _, found := d.Get("never"); !found {
+		t.Error("never: expired")
+	}
+
+	// 默认过期时间为 0 时永不过期
+	d = h.New(t, 0)
+	d.Set("default", "v", 0)
+	_, expiration, found := d.GetWithExpiration("default")
+	if !found || !expiration.IsZero() {
+		t.Errorf("default: got found=%v expiration=%s, want no expiration", found, expiration)
+	}
+}
+
+func testAddReplace(t *testing.T, h Harness) {
+	ctx := context.Background()
+	d := h.New(t, 0)
+
+	if err := d.AddCtx(ctx, "k", "a", -1); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if err := d.AddCtx(ctx, "k", "b", -1); !errors.Is(err, driver.ErrKeyExists) {
+		t.Errorf("Add existing: got %v, want ErrKeyExists", err)
+	}
+	if err := d.Add("k", "b", -1); !errors.Is(err, driver.ErrKeyExists) {
+		t.Errorf("Add existing: got %v, want ErrKeyExists", err)
+	}
+	if v, _ := d.Get("k"); v != "a" {
+		t.Errorf("Add existing overwrote value: got %v", v)
+	}
+
+	if err := d.ReplaceCtx(ctx, "missing", "v", -1); !errors.Is(err, driver.ErrCacheMiss) {
+		t.Errorf("Replace missing: got %v, want ErrCacheMiss", err)
+	}
+	if err := d.Replace("missing", "v", -1); err == nil {
+		t.Error("Replace missing: got nil error")
+	}
+	if _, found := d.Get("missing"); found {
+		t.Error("Replace missing created the key")
+	}
+	if err := d.ReplaceCtx(ctx, "k", "c", -1); err != nil {
+		t.Errorf("Replace existing: %v", err)
+	}
+	if v, _ := d.Get("k"); v != "c" {
+		t.Errorf("Replace existing: got %v, want c", v)
+	}
+
+	// 已过期的键视为不存在
+	d.Set("expiring", "v", TTL)
+	h.advance(TTL + 50*time.Millisecond)
+	if err := d.ReplaceCtx(ctx, "expiring", "v", -1); !errors.Is(err, driver.ErrCacheMiss) {
+		t.Errorf("Replace expired: got %v, want ErrCacheMiss", err)
+	}
+	if err := d.AddCtx(ctx, "expiring", "v2", -1); err != nil {
+		t.Errorf("Add expired: %v", err)
+	}
+}
+
+func testNumeric(t *testing.T, h Harness) {
+	d := h.New(t, 0)
+
+	d.Set("n", int64(10), -1)
+	if v, err := d.IncrementInt64("n", 5); err != nil || v != 15 {
+		t.Errorf("IncrementInt64: got %d, %v, want 15", v, err)
+	}
+	if v, err := d.DecrementInt64("n", 20); err != nil || v != -5 {
+		t.Errorf("DecrementInt64: got %d, %v, want -5", v, err)
+	}
+
+	d.Set("max", int64(math.MaxInt64), -1)
+	if _, err := d.IncrementInt64("max", 1); !errors.Is(err, driver.ErrOverflow) {
+		t.Errorf("IncrementInt64 overflow: got %v, want ErrOverflow", err)
+	}
+	if v, err := d.DecrementInt64("max", 1); err != nil || v != math.MaxInt64-1 {
+		t.Errorf("value changed after overflow: got %d, %v", v, err)
+	}
+
+	d.Set("min", int64(math.MinInt64), -1)
+	if _, err := d.DecrementInt64("min", 1); !errors.Is(err, driver.ErrOverflow) {
+		t.Errorf("DecrementInt64 overflow: got %v, want ErrOverflow", err)
+	}
+	if _, err := d.IncrementInt64("min", -1); !errors.Is(err, driver.ErrOverflow) {
+		t.Errorf("IncrementInt64 negative overflow: got %v, want ErrOverflow", err)
+	}
+	if v, err := d.IncrementInt64("min", 1); err != nil || v != math.MinInt64+1 {
+		t.Errorf("value changed after overflow: got %d, %v", v, err)
+	}
+
+	// 数值操作保留原有的过期时间
+	d.Set("ttl", int64(1), TTL)
+	if _, err := d.IncrementInt64("ttl", 1); err != nil {
+		t.Errorf("IncrementInt64: %v", err)
+	}
+	_, expiration, found := d.GetWithExpiration("ttl")
+	if !found || expiration.IsZero() {
+		t.Errorf("IncrementInt64 dropped expiration: found=%v expiration=%s", found, expiration)
+	}
+}
+
+func testNumericErrors(t *testing.T, h Harness) {
+	d := h.New(t, 0)
+
+	// 键不存在时返回 ErrCacheMiss 且不会创建键
+	checks := map[string]func() error{
+		"IncrementInt":    func() error { _, err := d.IncrementInt("missing", 1); return err },
+		"DecrementInt":    func() error { _, err := d.DecrementInt("missing", 1); return err },
+		"IncrementInt64":  func() error { _, err := d.IncrementInt64("missing", 1); return err },
+		"DecrementInt64":  func() error { _, err := d.DecrementInt64("missing", 1); return err },
+		"IncrementUint":   func() error { _, err := d.IncrementUint("missing", 1); return err },
+		"DecrementUint":   func() error { _, err := d.DecrementUint("missing", 1); return err },
+		"IncrementUint64": func() error { _, err := d.IncrementUint64("missing", 1); return err },
+		"DecrementUint64": func() error { _, err := d.DecrementUint64("missing", 1); return err },
+	}
+	for name, check := range checks {
+		if err := check(); !errors.Is(err, driver.ErrCacheMiss) {
+			t.Errorf("%s on missing key: got %v, want ErrCacheMiss", name, err)
+		}
+	}
+	if _, found := d.Get("missing"); found {
+		t.Error("numeric operation created a missing key")
+	}
+
+	// 无符号类型不能减到负数
+	d.Set("u", uint64(5), -1)
+	if _, err := d.DecrementUint64("u", 6); !errors.Is(err, driver.ErrOverflow) {
+		t.Errorf("DecrementUint64 underflow: got %v, want ErrOverflow", err)
+	}
+	if v, err := d.DecrementUint64("u", 5); err != nil || v != 0 {
+		t.Errorf("DecrementUint64: got %d, %v, want 0", v, err)
+	}
+	d.Set("ui", uint(1), -1)
+	if _, err := d.DecrementUint("ui", 2); !errors.Is(err, driver.ErrOverflow) {
+		t.Errorf("DecrementUint underflow: got %v, want ErrOverflow", err)
+	}
+	if v, err := d.IncrementUint("ui", 2); err != nil || v != 3 {
+		t.Errorf("IncrementUint: got %d, %v, want 3", v, err)
+	}
+
+	// 值不是数值
+	d.Set("s", "abc", -1)
+	if _, err := d.IncrementInt64("s", 1); !errors.Is(err, driver.ErrNotNumeric) {
+		t.Errorf("IncrementInt64 on string: got %v, want ErrNotNumeric", err)
+	}
+}
+
+func testFloat(t *testing.T, h Harness) {
+	d := h.New(t, 0)
+	floats, ok := driver.As[driver.FloatOperations](d)
+	if !ok {
+		t.Skip("driver does not implement driver.FloatOperations")
+	}
+
+	if _, err := floats.IncrementFloat64("missing", 1); !errors.Is(err, driver.ErrCacheMiss) {
+		t.Errorf("IncrementFloat64 on missing key: got %v, want ErrCacheMiss", err)
+	}
+	d.Set("f", 1.5, TTL)
+	if v, err := floats.IncrementFloat64("f", 1); err != nil || v != 2.5 {
+		t.Errorf("IncrementFloat64: got %v, %v, want 2.5", v, err)
+	}
+	if v, err := floats.IncrementFloat64("f", -3); err != nil || v != -0.5 {
+		t.Errorf("IncrementFloat64: got %v, %v, want -0.5", v, err)
+	}
+	if _, expiration, _ := d.GetWithExpiration("f"); expiration.IsZero() {
+		t.Error("IncrementFloat64 dropped expiration")
+	}
+
+	d.Set("max", math.MaxFloat64, -1)
+	if _, err := floats.IncrementFloat64("max", math.MaxFloat64); !errors.Is(err, driver.ErrOverflow) {
+		t.Errorf("IncrementFloat64 overflow: got %v, want ErrOverflow", err)
+	}
+	if v, err := floats.IncrementFloat64("max", -math.MaxFloat64); err != nil || v != 0 {
+		t.Errorf("value changed after overflow: got %v, %v", v, err)
+	}
+}
+
+func testCounter(t *testing.T, h Harness) {
+	ctx := context.Background()
+	d := h.New(t, 0)
+	counter, ok := driver.As[driver.Counter](d)
+	if !ok {
+		t.Skip("driver does not implement driver.Counter")
+	}
+
+	if v, err := counter.IncrementInt64WithTTL(ctx, "c", 2, TTL); err != nil || v != 2 {
+		t.Errorf("IncrementInt64WithTTL on missing key: got %d, %v, want 2", v, err)
+	}
+	// 已存在的键保留原有的过期时间
+	if v, err := counter.IncrementInt64WithTTL(ctx, "c", 3, time.Hour); err != nil || v != 5 {
+		t.Errorf("IncrementInt64WithTTL: got %d, %v, want 5", v, err)
+	}
+	if v, err := d.IncrementInt64("c", 1); err != nil || v != 6 {
+		t.Errorf("IncrementInt64 on counter: got %d, %v, want 6", v, err)
+	}
+
+	h.advance(TTL + 50*time.Millisecond)
+	if _, found := d.Get("c"); found {
+		t.Error("counter found after expiration")
+	}
+	if v, err := counter.IncrementInt64WithTTL(ctx, "c", 1, -1); err != nil || v != 1 {
+		t.Errorf("IncrementInt64WithTTL after expiration: got %d, %v, want 1", v, err)
+	}
+	if _, expiration, _ := d.GetWithExpiration("c"); !expiration.IsZero() {
+		t.Errorf("IncrementInt64WithTTL with negative ttl: got expiration %s", expiration)
+	}
+}
+
+func testScan(t *testing.T, h Harness) {
+	ctx := context.Background()
+	d := h.New(t, 0)
+	scanner, ok := driver.As[driver.Scanner](d)
+	if !ok {
+		t.Skip("driver does not implement driver.Scanner")
+	}
+
+	d.SetMany(map[string]any{"user:1": "a", "user:2": "b", "post:1": "c"}, -1)
+	if n, err := scanner.LenCtx(ctx); err != nil || n != 3 {
+		t.Errorf("LenCtx: got %d, %v, want 3", n, err)
+	}
+
+	d.Set("user:tmp", "d", TTL)
+	h.advance(2 * TTL)
+	found := make(map[string]bool)
+	if err := scanner.ScanCtx(ctx, "user:*", func(k string) bool {
+		found[k] = true
+		return true
+	}); err != nil {
+		t.Fatalf("ScanCtx: %v", err)
+	}
+	if len(found) != 2 || !found["user:1"] || !found["user:2"] {
+		t.Errorf("ScanCtx user:*: got %v, want user:1 and user:2", found)
+	}
+
+	var calls int
+	scanner.Scan("*", func(string) bool {
+		calls++
+		return false
+	})
+	if calls != 1 {
+		t.Errorf("Scan did not stop when fn returned false: %d calls", calls)
+	}
+}
+
+func testFlush(t *testing.T, h Harness) {
+	ctx := context.Background()
+	d := h.New(t, 0)
+
+	d.SetMany(map[string]any{"a": "1", "b": "2"}, -1)
+	d.Set("c", "3", TTL)
+	d.Flush()
+	for _, k := range []string{"a", "b", "c"} {
+		if _, found := d.Get(k); found {
+			t.Errorf("%s: found after Flush", k)
+		}
+	}
+
+	// Flush 后驱动仍然可用
+	d.Set("a", "1", -1)
+	if err := d.FlushCtx(ctx); err != nil {
+		t.Fatalf("FlushCtx: %v", err)
+	}
+	if _, err := d.GetCtx(ctx, "a"); !errors.Is(err, driver.ErrCacheMiss) {
+		t.Errorf("a: got %v after FlushCtx, want ErrCacheMiss", err)
+	}
+	if err := d.AddCtx(ctx, "a", "2", -1); err != nil {
+		t.Errorf("Add after FlushCtx: %v", err)
+	}
+}
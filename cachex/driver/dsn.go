@@ -0,0 +1,140 @@
+package driver
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// URLOpener 根据解析后的 DSN 创建驱动
+type URLOpener func(u *url.URL) (Driver, error)
+
+var openers = make(map[string]URLOpener)
+
+// RegisterURL 注册 DSN 的协议名，Open 根据协议名选择驱动，一个驱动可以注册多个协议名（如 redis 与 rediss）
+func RegisterURL(scheme string, opener URLOpener) {
+	driverMu.Lock()
+	defer driverMu.Unlock()
+	if opener == nil {
+		panic("缓存: 注册的 DSN 解析器是 nil")
+	}
+	if _, dup := openers[scheme]; dup {
+		panic("缓存：注册重复的 DSN 协议名 " + scheme)
+	}
+	openers[scheme] = opener
+}
+
+// Open 根据 DSN 创建驱动，如 redis://:pass@host:6379/2?prefix=app&codec=json 或 memory://?implementation=lru
+func Open(dsn string) (Driver, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+	driverMu.RLock()
+	opener, ok := openers[u.Scheme]
+	driverMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("缓存：未注册的 DSN 协议名 %q", u.Scheme)
+	}
+	return opener(u)
+}
+
+// URLQuery 读取 DSN 中的查询参数，记录解析错误与已读取的参数，Err 返回第一个错误或未读取的未知参数
+type URLQuery struct {
+	values url.Values
+	used   map[string]bool
+	err    error
+}
+
+func NewURLQuery(u *url.URL) *URLQuery {
+	return &URLQuery{values: u.Query(), used: make(map[string]bool)}
+}
+
+// Values 返回参数的所有值，用于可以重复的参数
+func (q *URLQuery) Values(name string) []string {
+	q.used[name] = true
+	return q.values[name]
+}
+
+// String 返回参数的值，参数不存在时返回空字符串，参数重复时记录错误
+func (q *URLQuery) String(name string) string {
+	values := q.Values(name)
+	if len(values) > 1 {
+		q.fail(name, fmt.Errorf("parameter repeated"))
+	}
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func (q *URLQuery) Int(name string) int {
+	return int(q.Int64(name))
+}
+
+func (q *URLQuery) Int64(name string) int64 {
+	v := q.String(name)
+	if v == "" {
+		return 0
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		q.fail(name, err)
+	}
+	return n
+}
+
+// Duration 支持 "5m" 形式的时间，以及表示秒数的整数
+func (q *URLQuery) Duration(name string) time.Duration {
+	v := q.String(name)
+	if v == "" {
+		return 0
+	}
+	if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return time.Duration(n) * time.Second
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		q.fail(name, err)
+	}
+	return d
+}
+
+func (q *URLQuery) Bool(name string) bool {
+	v := q.String(name)
+	if v == "" {
+		return false
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		q.fail(name, err)
+	}
+	return b
+}
+
+func (q *URLQuery) fail(name string, err error) {
+	if q.err == nil {
+		q.err = fmt.Errorf("%w: parameter %s: %v", ErrInvalidConfig, name, err)
+	}
+}
+
+// Err 返回第一个解析错误，没有解析错误时检查是否存在未读取的未知参数
+func (q *URLQuery) Err() error {
+	if q.err != nil {
+		return q.err
+	}
+	var unknown []string
+	for name := range q.values {
+		if !q.used[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("%w: unknown parameters %s", ErrInvalidConfig, strings.Join(unknown, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package driver
+
+import "errors"
+
+var (
+	// ErrCacheMiss 键不存在或已过期
+	ErrCacheMiss = errors.New("cache: key not found")
+
+	// ErrKeyExists Add 时键已存在
+	ErrKeyExists = errors.New("cache: key already exists")
+
+	// ErrUnavailable 缓存服务不可用，如连接被拒绝、网络超时、连接池耗尽或客户端已关闭
+	ErrUnavailable = errors.New("cache: backend unavailable")
+
+	// ErrConflict 原子更新因并发修改多次重试后仍未成功
+	ErrConflict = errors.New("cache: too many concurrent modifications")
+
+	// ErrOverflow 数值操作的结果超出值类型的取值范围，此时值保持不变
+	ErrOverflow = errors.New("cache: numeric overflow")
+
+	// ErrNotNumeric 数值操作的键的值不是对应的数值类型
+	ErrNotNumeric = errors.New("cache: value is not a number of the requested type")
+
+	// ErrInvalidConfig 驱动配置的类型错误，或包含未知、无法解析的参数
+	ErrInvalidConfig = errors.New("cache: invalid config")
+
+	// ErrNotSupported 驱动不支持该操作，如装饰器包装的驱动没有实现对应的可选接口
+	ErrNotSupported = errors.New("cache: operation not supported by driver")
+)
@@ -0,0 +1,69 @@
+package file
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// GetMany 批量获取缓存
+func (s *store) GetMany(keys []string) (map[string]any, []string) {
+	found, missing, _ := s.GetManyCtx(context.Background(), keys)
+	return found, missing
+}
+
+// SetMany 批量添加/替换缓存
+func (s *store) SetMany(items map[string]any, d time.Duration) {
+	_ = s.SetManyCtx(context.Background(), items, d)
+}
+
+// DeleteMany 批量删除缓存
+func (s *store) DeleteMany(keys []string) {
+	_ = s.DeleteManyCtx(context.Background(), keys)
+}
+
+// GetManyCtx GetMany 的 context 版本，读取失败的键视为未找到
+func (s *store) GetManyCtx(ctx context.Context, keys []string) (map[string]any, []string, error) {
+	found := make(map[string]any, len(keys))
+	var missing []string
+	for _, k := range keys {
+		v, err := s.GetCtx(ctx, k)
+		if err == nil {
+			found[k] = v
+			continue
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, nil, ctxErr
+		}
+		missing = append(missing, k)
+	}
+	return found, missing, nil
+}
+
+// SetManyCtx SetMany 的 context 版本，返回遇到的所有错误
+func (s *store) SetManyCtx(ctx context.Context, items map[string]any, d time.Duration) error {
+	var errs []error
+	for k, v := range items {
+		if err := s.SetCtx(ctx, k, v, d); err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// DeleteManyCtx DeleteMany 的 context 版本，返回遇到的所有错误
+func (s *store) DeleteManyCtx(ctx context.Context, keys []string) error {
+	var errs []error
+	for _, k := range keys {
+		if err := s.DeleteCtx(ctx, k); err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
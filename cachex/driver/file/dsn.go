@@ -0,0 +1,47 @@
+package file
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/yu1ec/go-pkg/cachex/driver"
+)
+
+// ParseURL 将 DSN 解析为配置，格式为 file:///var/cache/app[?参数]，相对目录写作 file://./cache
+// 支持的参数：codec、compress_threshold、default_expiration、cleanup_interval，时间参数支持 "5m" 形式或秒数
+// 未知参数返回包装了 driver.ErrInvalidConfig 的错误
+func ParseURL(dsn string) (*FileConfig, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("file: %w: %v", driver.ErrInvalidConfig, err)
+	}
+	return parseURL(u)
+}
+
+func parseURL(u *url.URL) (*FileConfig, error) {
+	if u.Scheme != "file" {
+		return nil, fmt.Errorf("file: %w: unsupported scheme %q", driver.ErrInvalidConfig, u.Scheme)
+	}
+	cfg := &FileConfig{Dir: u.Host + u.Path}
+	if cfg.Dir == "" {
+		return nil, fmt.Errorf("file: %w: missing directory", driver.ErrInvalidConfig)
+	}
+
+	q := driver.NewURLQuery(u)
+	cfg.Codec = q.String("codec")
+	cfg.CompressThreshold = q.Int("compress_threshold")
+	cfg.DefaultExpiration = q.Duration("default_expiration")
+	cfg.CleanupInterval = q.Duration("cleanup_interval")
+	if err := q.Err(); err != nil {
+		return nil, fmt.Errorf("file: %w", err)
+	}
+	return cfg, nil
+}
+
+func openURL(u *url.URL) (driver.Driver, error) {
+	cfg, err := parseURL(u)
+	if err != nil {
+		return nil, err
+	}
+	return New(cfg)
+}
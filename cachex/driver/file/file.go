@@ -0,0 +1,542 @@
+package file
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yu1ec/go-pkg/cachex/driver"
+	"github.com/yu1ec/go-pkg/dirx"
+)
+
+func init() {
+	driver.Register("file", New)
+	driver.RegisterURL("file", openURL)
+
+	// 值以 any 的形式编码，gob 需要注册接口中可能出现的复合类型，基础类型已由 gob 预先注册
+	gob.Register(map[string]any{})
+	gob.Register([]any{})
+}
+
+const (
+	// NoExpiration 永不过期
+	NoExpiration time.Duration = -1
+	// DefaultExpiration 使用配置中的默认过期时间
+	DefaultExpiration time.Duration = 0
+)
+
+const (
+	// tempPrefix 原子写入时使用的临时文件名前缀
+	tempPrefix = ".tmp-"
+	// tempPattern 原子写入时使用的临时文件名
+	tempPattern = tempPrefix + "*"
+	// staleTempAge 超过该时间的临时文件视为写入中断后的残留，清理时删除
+	staleTempAge = time.Hour
+)
+
+// FileConfig 文件缓存配置
+type FileConfig struct {
+	// Dir 缓存目录，不存在时自动创建
+	Dir string
+	// DefaultExpiration 默认过期时间，0 表示永不过期
+	DefaultExpiration time.Duration
+	// CleanupInterval 定期清理过期文件的间隔，0 表示只在读取时惰性清理
+	CleanupInterval time.Duration
+	// Codec 值的编解码器名称，为空时使用 gob 以保留数值类型，自定义结构体需要通过 gob.Register 注册
+	// 使用 json 等不保留 Go 类型的编解码器时，数值操作只能作用于解码后的类型（如 float64）
+	Codec string
+	// CompressThreshold 编码后超过该字节数时进行压缩，0 表示不压缩
+	CompressThreshold int
+}
+
+// FileDriver 是基于本地文件的持久化缓存驱动，重启后缓存仍然有效
+// 每个键保存为一个文件，文件名为键的 SHA-256，按前两级十六进制前缀分散到子目录中，写入时先写临时文件再重命名以保证原子性
+// Add、Replace 与数值操作的原子性只在同一个进程内保证
+type FileDriver struct {
+	*store
+}
+
+type store struct {
+	dir               string
+	serializer        *driver.Serializer
+	defaultExpiration time.Duration
+	janitor           chan struct{}
+
+	// locks 按键的哈希分段加锁，保证同一进程内读改写操作的原子性
+	locks [256]sync.Mutex
+	// updateLocks 按键的哈希分段加锁，使同一个键的 Update 依次执行
+	updateLocks [256]sync.Mutex
+}
+
+func New(config any) (driver.Driver, error) {
+	cfg, ok := config.(*FileConfig)
+	if !ok || cfg == nil || cfg.Dir == "" {
+		return nil, fmt.Errorf("%w: invalid config for file cache", driver.ErrInvalidConfig)
+	}
+
+	codecName := cfg.Codec
+	if codecName == "" {
+		codecName = driver.GobCodec.Name()
+	}
+	codec, err := driver.LookupCodec(codecName)
+	if err != nil {
+		return nil, err
+	}
+	if err := dirx.CreateNestedDir(cfg.Dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	defaultExpiration := cfg.DefaultExpiration
+	if defaultExpiration == 0 {
+		defaultExpiration = NoExpiration
+	}
+
+	s := &store{
+		dir:               filepath.Clean(cfg.Dir),
+		serializer:        &driver.Serializer{Codec: codec, CompressThreshold: cfg.CompressThreshold},
+		defaultExpiration: defaultExpiration,
+	}
+	f := &FileDriver{s}
+	if cfg.CleanupInterval > 0 {
+		// 通过包装对象的 finalizer 停止清理协程，避免驱动被回收后协程泄漏
+		s.janitor = make(chan struct{})
+		go s.runJanitor(cfg.CleanupInterval)
+		runtime.SetFinalizer(f, func(f *FileDriver) { close(f.janitor) })
+	}
+	return f, nil
+}
+
+func (s *store) runJanitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.DeleteExpired()
+		case <-s.janitor:
+			return
+		}
+	}
+}
+
+// entry 文件中保存的项目
+// 文件格式：8 字节过期时间（UnixNano，0 表示永不过期）+ 4 字节键长度 + 键 + 编码后的值
+type entry struct {
+	key     string
+	value   any
+	expires int64
+}
+
+func (e *entry) expired(now int64) bool {
+	return e.expires > 0 && now > e.expires
+}
+
+// path 返回键对应的文件路径
+func (s *store) path(k string) (string, *sync.Mutex) {
+	sum := sha256.Sum256([]byte(k))
+	name := hex.EncodeToString(sum[:])
+	return filepath.Join(s.dir, name[:2], name[2:4], name[4:]), &s.locks[sum[0]]
+}
+
+// expiration 将 Set 的过期时间参数转换为过期的时间戳，0 表示永不过期
+func (s *store) expiration(d time.Duration) int64 {
+	if d == DefaultExpiration {
+		d = s.defaultExpiration
+	}
+	if d > 0 {
+		return time.Now().Add(d).UnixNano()
+	}
+	return 0
+}
+
+// errExpired 文件存在但项目已过期
+var errExpired = fmt.Errorf("%w: expired", driver.ErrCacheMiss)
+
+// read 读取未过期的项目，不存在或已过期时返回 driver.ErrCacheMiss，已过期的文件会被删除
+// 调用方需要持有键的锁，否则可能删除其他协程刚写入的文件
+func (s *store) read(path string) (*entry, error) {
+	e, err := s.load(path)
+	if errors.Is(err, errExpired) {
+		_ = s.remove(path)
+		return nil, driver.ErrCacheMiss
+	}
+	return e, err
+}
+
+// get 在不持有键的锁时读取项目，已过期的文件在加锁并重新确认仍已过期后才删除
+func (s *store) get(path string, mu *sync.Mutex) (*entry, error) {
+	e, err := s.load(path)
+	if !errors.Is(err, errExpired) {
+		return e, err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if e, err := s.readHeaderFile(path); err == nil && e.expired(time.Now().UnixNano()) {
+		_ = s.remove(path)
+	}
+	return nil, driver.ErrCacheMiss
+}
+
+// load 读取并解码项目，不存在时返回 driver.ErrCacheMiss，已过期时返回 errExpired
+func (s *store) load(path string) (*entry, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, driver.ErrCacheMiss
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	e, rest, err := decodeHeader(data)
+	if err != nil {
+		return nil, fmt.Errorf("file: read %s: %w", path, err)
+	}
+	if e.expired(time.Now().UnixNano()) {
+		return nil, errExpired
+	}
+	if err := s.serializer.Unmarshal(rest, &e.value); err != nil {
+		return nil, fmt.Errorf("file: decode %s: %w", e.key, err)
+	}
+	return e, nil
+}
+
+func decodeHeader(data []byte) (*entry, []byte, error) {
+	if len(data) < 12 {
+		return nil, nil, io.ErrUnexpectedEOF
+	}
+	expires := int64(binary.BigEndian.Uint64(data))
+	n := int(binary.BigEndian.Uint32(data[8:]))
+	if len(data) < 12+n {
+		return nil, nil, io.ErrUnexpectedEOF
+	}
+	return &entry{key: string(data[12 : 12+n]), expires: expires}, data[12+n:], nil
+}
+
+// write 原子地写入项目：先写入同目录下的临时文件，再重命名为目标文件
+func (s *store) write(path string, e *entry) error {
+	value, err := s.serializer.Marshal(&e.value)
+	if err != nil {
+		return fmt.Errorf("file: encode %s: %w", e.key, err)
+	}
+
+	var buf bytes.Buffer
+	buf.Grow(12 + len(e.key) + len(value))
+	var header [12]byte
+	binary.BigEndian.PutUint64(header[:8], uint64(e.expires))
+	binary.BigEndian.PutUint32(header[8:], uint32(len(e.key)))
+	buf.Write(header[:])
+	buf.WriteString(e.key)
+	buf.Write(value)
+
+	dir := filepath.Dir(path)
+	if err := dirx.CreateNestedDir(dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, tempPattern)
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+func (s *store) remove(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// walk 遍历所有缓存文件，只读取文件头，fn 返回 true 时删除该文件
+// 删除前持有键的锁并重新读取文件头，避免误删在遍历期间被重新写入的文件；同时清理写入中断后残留的临时文件
+func (s *store) walk(ctx context.Context, fn func(e *entry) bool) error {
+	return filepath.WalkDir(s.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if ok, err := s.owned(path, d); !ok || d.IsDir() {
+			return err
+		}
+		if strings.HasPrefix(d.Name(), tempPrefix) {
+			if info, err := d.Info(); err == nil && time.Since(info.ModTime()) > staleTempAge {
+				return s.remove(path)
+			}
+			return nil
+		}
+
+		e, err := s.readHeaderFile(path)
+		if err != nil || !fn(e) {
+			return nil
+		}
+
+		_, mu := s.path(e.key)
+		mu.Lock()
+		defer mu.Unlock()
+		if e, err := s.readHeaderFile(path); err == nil && fn(e) {
+			return s.remove(path)
+		}
+		return nil
+	})
+}
+
+// owned 判断遍历到的路径是否由驱动创建：缓存目录下只有两位十六进制的分段目录属于驱动，
+// 其他目录返回 fs.SkipDir，其他文件返回 false，使 Dir 指向共享目录时不会读取或删除其他文件
+func (s *store) owned(path string, d fs.DirEntry) (bool, error) {
+	if filepath.Dir(path) != s.dir {
+		return true, nil
+	}
+	if !d.IsDir() {
+		return false, nil
+	}
+	if !isShard(d.Name()) {
+		return false, fs.SkipDir
+	}
+	return true, nil
+}
+
+// isShard 判断目录名是否为两位小写十六进制的分段目录
+func isShard(name string) bool {
+	if len(name) != 2 {
+		return false
+	}
+	for _, c := range name {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// errCorrupt 文件头记录的键长度超出文件大小，或键与文件路径不对应
+var errCorrupt = errors.New("file: corrupt cache file")
+
+// readHeaderFile 读取文件头，文件损坏或路径与键不对应时返回 errCorrupt
+func (s *store) readHeaderFile(path string) (*entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	e, err := readHeader(f, info.Size())
+	if err != nil {
+		return nil, err
+	}
+	if p, _ := s.path(e.key); p != path {
+		return nil, errCorrupt
+	}
+	return e, nil
+}
+
+// readHeader 读取文件头，size 为文件大小，键长度超出文件大小时返回 errCorrupt，避免按损坏的长度分配内存
+func readHeader(r io.Reader, size int64) (*entry, error) {
+	var header [12]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	n := int64(binary.BigEndian.Uint32(header[8:]))
+	if n > size-int64(len(header)) {
+		return nil, errCorrupt
+	}
+	key := make([]byte, n)
+	if _, err := io.ReadFull(r, key); err != nil {
+		return nil, err
+	}
+	return &entry{key: string(key), expires: int64(binary.BigEndian.Uint64(header[:8]))}, nil
+}
+
+// Add 仅当给定键的项目尚不存在或现有项目已过期时，才将项目添加到缓存。否则返回错误。
+func (s *store) Add(k string, v any, d time.Duration) error {
+	return s.AddCtx(context.Background(), k, v, d)
+}
+
+// Delete 从缓存中删除一个项目。如果密钥不在缓存中，则不执行任何操作。
+func (s *store) Delete(k string) {
+	_ = s.DeleteCtx(context.Background(), k)
+}
+
+// DeleteExpired 删除过期的缓存
+func (s *store) DeleteExpired() {
+	now := time.Now().UnixNano()
+	_ = s.walk(context.Background(), func(e *entry) bool {
+		return e.expired(now)
+	})
+}
+
+// Flush 清空缓存
+func (s *store) Flush() {
+	_ = s.FlushCtx(context.Background())
+}
+
+// Get 从缓存中获取一个项目。返回该项或 nil，以及一个指示是否找到该键的布尔值。
+func (s *store) Get(k string) (any, bool) {
+	v, err := s.GetCtx(context.Background(), k)
+	return v, err == nil
+}
+
+// GetWithExpiration 从缓存中返回一个项目及其过期时间。它返回该项目或 nil、过期时间（如果已设置）
+// (如果该项目永不过期，则返回时间的零值。Time 返回) 以及指示是否找到该键的 bool。
+func (s *store) GetWithExpiration(k string) (any, time.Time, bool) {
+	v, expiration, err := s.GetWithExpirationCtx(context.Background(), k)
+	return v, expiration, err == nil
+}
+
+// Replace 替换缓存,如果缓存不存在,则返回错误
+func (s *store) Replace(k string, x any, d time.Duration) error {
+	return s.ReplaceCtx(context.Background(), k, x, d)
+}
+
+// Set 添加/替换现有的缓存设置,包括过期时间,如果过期时间是0,则使用默认过期时间,如果为-1则表示永不过期
+func (s *store) Set(k string, x any, d time.Duration) {
+	_ = s.SetCtx(context.Background(), k, x, d)
+}
+
+// SetDefault 添加/替换现有的缓存设置,使用默认过期时间
+func (s *store) SetDefault(k string, x any) {
+	s.Set(k, x, DefaultExpiration)
+}
+
+// AddCtx Add 的 context 版本
+func (s *store) AddCtx(ctx context.Context, k string, v any, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	path, mu := s.path(k)
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, err := s.read(path); err == nil {
+		return fmt.Errorf("%w: %s", driver.ErrKeyExists, k)
+	} else if !errors.Is(err, driver.ErrCacheMiss) {
+		return err
+	}
+	return s.write(path, &entry{key: k, value: v, expires: s.expiration(d)})
+}
+
+// DeleteCtx Delete 的 context 版本
+func (s *store) DeleteCtx(ctx context.Context, k string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	path, mu := s.path(k)
+	mu.Lock()
+	defer mu.Unlock()
+	return s.remove(path)
+}
+
+// FlushCtx Flush 的 context 版本，删除缓存目录下的所有内容，保留缓存目录本身
+func (s *store) FlushCtx(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return err
+	}
+	// 只删除驱动创建的分段目录，临时文件也位于其中；Dir 中的其他文件保持不变
+	for _, e := range entries {
+		if !e.IsDir() || !isShard(e.Name()) {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(s.dir, e.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetCtx Get 的 context 版本，键不存在时返回 driver.ErrCacheMiss
+func (s *store) GetCtx(ctx context.Context, k string) (any, error) {
+	v, _, err := s.GetWithExpirationCtx(ctx, k)
+	return v, err
+}
+
+// GetWithExpirationCtx GetWithExpiration 的 context 版本，键不存在时返回 driver.ErrCacheMiss
+func (s *store) GetWithExpirationCtx(ctx context.Context, k string) (any, time.Time, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, time.Time{}, err
+	}
+	path, mu := s.path(k)
+	e, err := s.get(path, mu)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if e.expires == 0 {
+		return e.value, time.Time{}, nil
+	}
+	return e.value, time.Unix(0, e.expires), nil
+}
+
+// ReplaceCtx Replace 的 context 版本
+func (s *store) ReplaceCtx(ctx context.Context, k string, x any, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	path, mu := s.path(k)
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, err := s.read(path); errors.Is(err, driver.ErrCacheMiss) {
+		return fmt.Errorf("%w: %s", driver.ErrCacheMiss, k)
+	} else if err != nil {
+		return err
+	}
+	return s.write(path, &entry{key: k, value: x, expires: s.expiration(d)})
+}
+
+// SetCtx Set 的 context 版本
+func (s *store) SetCtx(ctx context.Context, k string, x any, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	path, mu := s.path(k)
+	mu.Lock()
+	defer mu.Unlock()
+	return s.write(path, &entry{key: k, value: x, expires: s.expiration(d)})
+}
+
+// SetDefaultCtx SetDefault 的 context 版本
+func (s *store) SetDefaultCtx(ctx context.Context, k string, x any) error {
+	return s.SetCtx(ctx, k, x, DefaultExpiration)
+}
+
+// FlushPrefix 实现 driver.PrefixFlusher 接口，删除以 prefix 开头的键
+func (s *store) FlushPrefix(ctx context.Context, prefix string) error {
+	return s.walk(ctx, func(e *entry) bool {
+		return strings.HasPrefix(e.key, prefix)
+	})
+}
@@ -0,0 +1,347 @@
+package file_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yu1ec/go-pkg/cachex/driver"
+	"github.com/yu1ec/go-pkg/cachex/driver/drivertest"
+	"github.com/yu1ec/go-pkg/cachex/driver/file"
+)
+
+func newFile(t *testing.T, cfg *file.FileConfig) driver.Driver {
+	if cfg.Dir == "" {
+		cfg.Dir = t.TempDir()
+	}
+	d, err := driver.New("file", cfg)
+	if err != nil {
+		t.Fatalf("failed to create file driver: %v", err)
+	}
+	return d
+}
+
+func TestFileDriver(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "nested", "cache")
+	d := newFile(t, &file.FileConfig{Dir: dir})
+
+	d.Set("string", "value", 0)
+	d.Set("map", map[string]any{"id": 1}, time.Minute)
+	d.Set("int", 42, 0)
+
+	v, found := d.Get("string")
+	assert.True(t, found)
+	assert.Equal(t, "value", v)
+
+	v, expiration, found := d.GetWithExpiration("map")
+	assert.True(t, found)
+	assert.Equal(t, map[string]any{"id": 1}, v)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), expiration, time.Second)
+
+	_, expiration, _ = d.GetWithExpiration("int")
+	assert.True(t, expiration.IsZero())
+
+	// 重新打开同一个目录，数据仍然存在
+	reopened := newFile(t, &file.FileConfig{Dir: dir})
+	v, found = reopened.Get("int")
+	assert.True(t, found)
+	assert.Equal(t, 42, v)
+
+	_, err := d.GetCtx(context.Background(), "missing")
+	assert.True(t, errors.Is(err, driver.ErrCacheMiss))
+
+	d.Delete("string")
+	_, found = d.Get("string")
+	assert.False(t, found)
+
+	d.Flush()
+	_, found = d.Get("int")
+	assert.False(t, found)
+	_, err = os.Stat(dir)
+	assert.NoError(t, err)
+}
+
+func TestFileDriverExpiration(t *testing.T) {
+	dir := t.TempDir()
+	d := newFile(t, &file.FileConfig{Dir: dir, DefaultExpiration: 10 * time.Millisecond})
+
+	d.SetDefault("default", 1)
+	d.Set("forever", 1, file.NoExpiration)
+	d.Set("short", 1, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+
+	// 惰性清理
+	_, found := d.Get("short")
+	assert.False(t, found)
+	assert.Equal(t, 2, countFiles(t, dir))
+
+	// 主动清理
+	d.DeleteExpired()
+	assert.Equal(t, 1, countFiles(t, dir))
+	_, found = d.Get("forever")
+	assert.True(t, found)
+
+	// 定期清理
+	d = newFile(t, &file.FileConfig{Dir: dir, CleanupInterval: 5 * time.Millisecond})
+	d.Set("short", 1, time.Millisecond)
+	assert.Eventually(t, func() bool {
+		return countFiles(t, dir) == 1
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestFileDriverAddReplace(t *testing.T) {
+	d := newFile(t, &file.FileConfig{})
+
+	assert.True(t, errors.Is(d.Replace("k", 1, 0), driver.ErrCacheMiss))
+	assert.NoError(t, d.Add("k", 1, 0))
+	assert.True(t, errors.Is(d.Add("k", 2, 0), driver.ErrKeyExists))
+	assert.NoError(t, d.Replace("k", 3, 0))
+
+	v, _ := d.Get("k")
+	assert.Equal(t, 3, v)
+}
+
+func TestFileDriverNumeric(t *testing.T) {
+	d := newFile(t, &file.FileConfig{})
+
+	_, err := d.IncrementInt64("counter", 1)
+	assert.True(t, errors.Is(err, driver.ErrCacheMiss))
+
+	d.Set("counter", int64(0), time.Minute)
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := d.IncrementInt64("counter", 1)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	v, expiration, _ := d.GetWithExpiration("counter")
+	assert.Equal(t, int64(20), v)
+	assert.False(t, expiration.IsZero())
+
+	n, err := d.DecrementInt64("counter", 5)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(15), n)
+
+	_, err = d.IncrementUint("counter", 1)
+	assert.Error(t, err)
+}
+
+func TestFileDriverBatchAndPrefix(t *testing.T) {
+	d := newFile(t, &file.FileConfig{Codec: "json", CompressThreshold: 16})
+
+	d.SetMany(map[string]any{"user:1": "alice", "user:2": "bob", "order:1": "big order payload"}, 0)
+	found, missing := d.GetMany([]string{"user:1", "user:2", "user:3"})
+	assert.Equal(t, map[string]any{"user:1": "alice", "user:2": "bob"}, found)
+	assert.Equal(t, []string{"user:3"}, missing)
+
+	assert.NoError(t, d.(driver.PrefixFlusher).FlushPrefix(context.Background(), "user:"))
+	_, found1 := d.Get("user:1")
+	assert.False(t, found1)
+	v, _ := d.Get("order:1")
+	assert.Equal(t, "big order payload", v)
+
+	d.DeleteMany([]string{"order:1"})
+	_, found1 = d.Get("order:1")
+	assert.False(t, found1)
+}
+
+func TestFileDriverSharedDir(t *testing.T) {
+	dir := t.TempDir()
+	d := newFile(t, &file.FileConfig{Dir: dir})
+	ctx := context.Background()
+
+	// Dir 中不由驱动创建的文件与目录不会被读取或删除
+	foreign := []string{"notes.txt", ".gitkeep", filepath.Join("data", "a.txt"), filepath.Join("data", "b", "c.txt")}
+	for _, name := range foreign {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		assert.NoError(t, os.WriteFile(path, []byte("keep"), 0o644))
+	}
+
+	d.Set("a", 1, 0)
+	d.Set("b", 2, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	assert.Equal(t, 2, d.(driver.Scanner).Len())
+	d.DeleteExpired()
+	assert.Equal(t, 1, d.(driver.Scanner).Len())
+	assert.NoError(t, d.(driver.PrefixFlusher).FlushPrefix(ctx, ""))
+	d.Set("c", 3, 0)
+	d.Flush()
+	assert.Equal(t, 0, d.(driver.Scanner).Len())
+
+	for _, name := range foreign {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		assert.NoError(t, err, name)
+		assert.Equal(t, "keep", string(data))
+	}
+}
+
+func TestFileDriverCorruptHeader(t *testing.T) {
+	dir := t.TempDir()
+	d := newFile(t, &file.FileConfig{Dir: dir})
+
+	d.Set("a", 1, 0)
+	// 键长度远超文件大小的损坏文件被跳过，不会按该长度分配内存
+	corrupt := filepath.Join(dir, "00", "00", "corrupt")
+	assert.NoError(t, os.MkdirAll(filepath.Dir(corrupt), 0o755))
+	header := make([]byte, 12)
+	binary.BigEndian.PutUint32(header[8:], math.MaxUint32)
+	assert.NoError(t, os.WriteFile(corrupt, header, 0o644))
+
+	var keys []string
+	d.(driver.Scanner).Scan("*", func(k string) bool {
+		keys = append(keys, k)
+		return true
+	})
+	assert.Equal(t, []string{"a"}, keys)
+	d.DeleteExpired()
+	_, err := os.Stat(corrupt)
+	assert.NoError(t, err)
+}
+
+func TestFileDriverInvalidConfig(t *testing.T) {
+	_, err := driver.New("file", &file.FileConfig{})
+	assert.Error(t, err)
+
+	_, err = driver.New("file", &file.FileConfig{Dir: t.TempDir(), Codec: "unknown"})
+	assert.Error(t, err)
+}
+
+func countFiles(t *testing.T, dir string) int {
+	n := 0
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			n++
+		}
+		return err
+	})
+	assert.NoError(t, err)
+	return n
+}
+
+func TestFileDriverUpdateReentrant(t *testing.T) {
+	d := newFile(t, &file.FileConfig{Dir: t.TempDir()})
+	ctx := context.Background()
+
+	// 找到与 counter 位于同一个锁分段的键
+	sum := sha256.Sum256([]byte("counter"))
+	var other string
+	for i := 0; ; i++ {
+		other = fmt.Sprintf("other:%d", i)
+		if s := sha256.Sum256([]byte(other)); s[0] == sum[0] {
+			break
+		}
+	}
+
+	// fn 在锁外调用，可以读写同一个键与同一分段的其他键
+	d.Set("counter", 1, 0)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		v, err := d.(driver.Updater).Update(ctx, "counter", func(old any, exists bool) (any, error) {
+			cur, _ := d.Get("counter")
+			d.Set(other, cur, 0)
+			return old.(int) + 1, nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, v)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Update deadlocked")
+	}
+	v, _ := d.Get(other)
+	assert.Equal(t, 1, v)
+
+	// fn 每次都修改同一个键时不会无限重试
+	_, err := d.(driver.Updater).Update(ctx, "counter", func(old any, exists bool) (any, error) {
+		d.Set("counter", 0, 0)
+		return 1, nil
+	})
+	assert.ErrorIs(t, err, driver.ErrConflict)
+}
+
+func TestConformance(t *testing.T) {
+	drivertest.Run(t, drivertest.Harness{
+		New: func(t *testing.T, defaultExpiration time.Duration) driver.Driver {
+			return newFile(t, &file.FileConfig{DefaultExpiration: defaultExpiration})
+		},
+	})
+}
+
+func TestParseURL(t *testing.T) {
+	cfg, err := file.ParseURL("file:///var/cache/app?codec=json&default_expiration=1h&compress_threshold=1024")
+	assert.NoError(t, err)
+	assert.Equal(t, &file.FileConfig{
+		Dir:               "/var/cache/app",
+		Codec:             "json",
+		DefaultExpiration: time.Hour,
+		CompressThreshold: 1024,
+	}, cfg)
+
+	cfg, err = file.ParseURL("file://./cache")
+	assert.NoError(t, err)
+	assert.Equal(t, "./cache", cfg.Dir)
+
+	for _, dsn := range []string{"file://", "file:///tmp/cache?ttl=1h", "file:///tmp/cache?cleanup_interval=soon"} {
+		_, err := file.ParseURL(dsn)
+		assert.ErrorIs(t, err, driver.ErrInvalidConfig, dsn)
+	}
+
+	d, err := driver.Open("file://" + t.TempDir())
+	assert.NoError(t, err)
+	d.Set("k", "v", 0)
+	v, found := d.Get("k")
+	assert.True(t, found)
+	assert.Equal(t, "v", v)
+}
+
+func TestLazyExpiryKeepsConcurrentWrite(t *testing.T) {
+	d := newFile(t, &file.FileConfig{})
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+					d.Get("k")
+				}
+			}
+		}()
+	}
+
+	// 读取方看到已过期的文件时，不能删除随后写入的新文件
+	lost := 0
+	for i := 0; i < 100; i++ {
+		d.Set("k", "old", time.Nanosecond)
+		time.Sleep(time.Microsecond)
+		d.Set("k", "new", file.NoExpiration)
+		if _, found := d.Get("k"); !found {
+			lost++
+		}
+	}
+	close(done)
+	wg.Wait()
+	assert.Zero(t, lost)
+}
@@ -0,0 +1,104 @@
+package file
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/yu1ec/go-pkg/cachex/driver"
+)
+
+// apply 将键的值替换为 fn 的结果，值的类型必须为 T，键不存在时返回 driver.ErrCacheMiss，保留原有的过期时间
+// fn 返回错误时值保持不变，调用方需要持有键的锁
+func apply[T driver.Integer | float64](s *store, k, path string, fn func(v T) (T, error)) (T, error) {
+	e, err := s.read(path)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", err, k)
+	}
+	v, ok := e.value.(T)
+	if !ok {
+		return 0, fmt.Errorf("%w: the value for %s is %T, not %T", driver.ErrNotNumeric, k, e.value, v)
+	}
+	v, err = fn(v)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", err, k)
+	}
+	e.value = v
+	if err := s.write(path, e); err != nil {
+		return 0, err
+	}
+	return v, nil
+}
+
+// add 将键的值加上 n，negative 为 true 时减去 n，结果溢出时返回 driver.ErrOverflow
+func add[T driver.Integer](s *store, k string, n T, negative bool) (T, error) {
+	path, mu := s.path(k)
+	mu.Lock()
+	defer mu.Unlock()
+	return apply(s, k, path, func(v T) (T, error) {
+		return driver.AddInteger(v, n, negative)
+	})
+}
+
+// IncrementFloat64 实现 driver.FloatOperations 接口
+func (s *store) IncrementFloat64(k string, n float64) (float64, error) {
+	path, mu := s.path(k)
+	mu.Lock()
+	defer mu.Unlock()
+	return apply(s, k, path, func(v float64) (float64, error) {
+		return driver.AddFloat64(v, n)
+	})
+}
+
+// IncrementInt64WithTTL 实现 driver.Counter 接口
+func (s *store) IncrementInt64WithTTL(ctx context.Context, k string, n int64, d time.Duration) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	path, mu := s.path(k)
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, err := s.read(path); errors.Is(err, driver.ErrCacheMiss) {
+		if err := s.write(path, &entry{key: k, value: n, expires: s.expiration(d)}); err != nil {
+			return 0, err
+		}
+		return n, nil
+	}
+	return apply(s, k, path, func(v int64) (int64, error) {
+		return driver.AddInteger(v, n, false)
+	})
+}
+
+func (s *store) IncrementInt(k string, n int) (int, error) {
+	return add(s, k, n, false)
+}
+
+func (s *store) DecrementInt(k string, n int) (int, error) {
+	return add(s, k, n, true)
+}
+
+func (s *store) IncrementInt64(k string, n int64) (int64, error) {
+	return add(s, k, n, false)
+}
+
+func (s *store) DecrementInt64(k string, n int64) (int64, error) {
+	return add(s, k, n, true)
+}
+
+func (s *store) IncrementUint(k string, n uint) (uint, error) {
+	return add(s, k, n, false)
+}
+
+func (s *store) DecrementUint(k string, n uint) (uint, error) {
+	return add(s, k, n, true)
+}
+
+func (s *store) IncrementUint64(k string, n uint64) (uint64, error) {
+	return add(s, k, n, false)
+}
+
+func (s *store) DecrementUint64(k string, n uint64) (uint64, error) {
+	return add(s, k, n, true)
+}
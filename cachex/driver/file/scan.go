@@ -0,0 +1,78 @@
+package file
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/yu1ec/go-pkg/cachex/driver"
+)
+
+// errStopScan 用于提前结束目录遍历
+var errStopScan = errors.New("file: stop scan")
+
+// headers 只读地遍历所有缓存文件的文件头，跳过临时文件，fn 返回 false 时停止
+func (s *store) headers(ctx context.Context, fn func(e *entry) bool) error {
+	err := filepath.WalkDir(s.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if ok, err := s.owned(path, d); !ok || d.IsDir() {
+			return err
+		}
+		if strings.HasPrefix(d.Name(), tempPrefix) {
+			return nil
+		}
+		e, err := s.readHeaderFile(path)
+		if err != nil {
+			return nil
+		}
+		if !fn(e) {
+			return errStopScan
+		}
+		return nil
+	})
+	if errors.Is(err, errStopScan) {
+		return nil
+	}
+	return err
+}
+
+// Scan 实现 driver.Scanner 接口，遍历与 pattern 匹配的未过期键
+func (s *store) Scan(pattern string, fn func(k string) bool) {
+	_ = s.ScanCtx(context.Background(), pattern, fn)
+}
+
+// Len 返回缓存文件的数量，包括已过期但尚未清理的项目
+func (s *store) Len() int {
+	n, _ := s.LenCtx(context.Background())
+	return n
+}
+
+func (s *store) ScanCtx(ctx context.Context, pattern string, fn func(k string) bool) error {
+	now := time.Now().UnixNano()
+	return s.headers(ctx, func(e *entry) bool {
+		if e.expired(now) || !driver.MatchPattern(pattern, e.key) {
+			return true
+		}
+		return fn(e.key)
+	})
+}
+
+func (s *store) LenCtx(ctx context.Context) (int, error) {
+	var n int
+	err := s.headers(ctx, func(*entry) bool {
+		n++
+		return true
+	})
+	return n, err
+}
@@ -0,0 +1,100 @@
+package file
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/yu1ec/go-pkg/cachex/driver"
+)
+
+// CompareAndSwap 实现 driver.Updater 接口，使用 reflect.DeepEqual 比较解码后的当前值与 old
+// 仅保证同一进程内的原子性
+func (s *store) CompareAndSwap(ctx context.Context, k string, old, new any, d time.Duration) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	path, mu := s.path(k)
+	mu.Lock()
+	defer mu.Unlock()
+
+	e, err := s.read(path)
+	if errors.Is(err, driver.ErrCacheMiss) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if !reflect.DeepEqual(e.value, old) {
+		return false, nil
+	}
+	return true, s.write(path, &entry{key: k, value: new, expires: s.expiration(d)})
+}
+
+// maxUpdateRetries Update 在调用 fn 期间文件被修改时的最大重试次数
+const maxUpdateRetries = 10
+
+// Update 实现 driver.Updater 接口，仅保证同一进程内的原子性
+// 同一个键的 Update 依次执行；fn 在锁外调用，因此可以读写缓存，写入前确认文件在期间没有被替换或删除，否则重新调用 fn，
+// 重试 maxUpdateRetries 次仍被修改时返回 driver.ErrConflict
+func (s *store) Update(ctx context.Context, k string, fn func(old any, exists bool) (any, error)) (any, error) {
+	um := s.updateLock(k)
+	um.Lock()
+	defer um.Unlock()
+
+	path, mu := s.path(k)
+	for i := 0; i < maxUpdateRetries; i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		mu.Lock()
+		e, err := s.read(path)
+		info, statErr := os.Stat(path)
+		mu.Unlock()
+		exists := err == nil
+		if err != nil && !errors.Is(err, driver.ErrCacheMiss) {
+			return nil, err
+		}
+		if !exists {
+			e = &entry{key: k, expires: s.expiration(DefaultExpiration)}
+		}
+
+		v, err := fn(e.value, exists)
+		if err != nil {
+			return nil, err
+		}
+
+		mu.Lock()
+		cur, curErr := os.Stat(path)
+		if unchanged(info, statErr, cur, curErr) && !e.expired(time.Now().UnixNano()) {
+			err := s.write(path, &entry{key: k, value: v, expires: e.expires})
+			mu.Unlock()
+			if err != nil {
+				return nil, err
+			}
+			return v, nil
+		}
+		mu.Unlock()
+	}
+	return nil, fmt.Errorf("%w: %s", driver.ErrConflict, k)
+}
+
+// updateLock 返回键的 Update 锁，与 locks 分开，使 fn 中可以访问同一分段的键
+func (s *store) updateLock(k string) *sync.Mutex {
+	sum := sha256.Sum256([]byte(k))
+	return &s.updateLocks[sum[0]]
+}
+
+// unchanged 判断两次 Stat 之间文件是否没有被替换或删除，每次写入都会重命名新的临时文件，因此文件会变化
+func unchanged(a fs.FileInfo, aErr error, b fs.FileInfo, bErr error) bool {
+	if aErr != nil || bErr != nil {
+		return errors.Is(aErr, fs.ErrNotExist) && errors.Is(bErr, fs.ErrNotExist)
+	}
+	return os.SameFile(a, b) && a.ModTime().Equal(b.ModTime()) && a.Size() == b.Size()
+}
@@ -0,0 +1,84 @@
+package bounded
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/yu1ec/go-pkg/cachex/driver"
+)
+
+// GetMany 批量获取缓存，在一次加锁内完成
+func (s *store) GetMany(keys []string) (map[string]any, []string) {
+	s.lock()
+	defer s.unlock()
+
+	found := make(map[string]any, len(keys))
+	var missing []string
+	for _, k := range keys {
+		if e := s.get(k); e != nil {
+			s.policy.access(e)
+			found[k] = e.value
+		} else {
+			missing = append(missing, k)
+		}
+	}
+	return found, missing
+}
+
+// SetMany 批量添加/替换缓存，在一次加锁内完成，超过 MaxBytes 的项目被跳过
+func (s *store) SetMany(items map[string]any, d time.Duration) {
+	_ = s.setMany(items, d)
+}
+
+// setMany 写入所有能够保存的项目，返回超过 MaxBytes 的项目的错误
+func (s *store) setMany(items map[string]any, d time.Duration) error {
+	s.lock()
+	defer s.unlock()
+
+	var errs []error
+	for k, v := range items {
+		if err := s.set(k, v, d); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// DeleteMany 批量删除缓存，在一次加锁内完成
+func (s *store) DeleteMany(keys []string) {
+	s.lock()
+	defer s.unlock()
+
+	for _, k := range keys {
+		if e, ok := s.items[k]; ok {
+			s.remove(e, driver.RemovalDeleted)
+		}
+	}
+}
+
+// GetManyCtx GetMany 的 context 版本
+func (s *store) GetManyCtx(ctx context.Context, keys []string) (map[string]any, []string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	found, missing := s.GetMany(keys)
+	return found, missing, nil
+}
+
+// SetManyCtx SetMany 的 context 版本
+func (s *store) SetManyCtx(ctx context.Context, items map[string]any, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.setMany(items, d)
+}
+
+// DeleteManyCtx DeleteMany 的 context 版本
+func (s *store) DeleteManyCtx(ctx context.Context, keys []string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.DeleteMany(keys)
+	return nil
+}
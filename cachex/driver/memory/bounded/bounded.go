@@ -0,0 +1,461 @@
+package bounded
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yu1ec/go-pkg/cachex/driver"
+)
+
+func init() {
+	driver.Register("memory_bounded", New)
+	driver.RegisterURL("memory-bounded", openURL)
+}
+
+const (
+	// PolicyLRU 淘汰最久未被访问的项目
+	PolicyLRU = "lru"
+	// PolicyLFU 淘汰访问次数最少的项目，次数相同时淘汰最久未被访问的项目
+	PolicyLFU = "lfu"
+)
+
+// ErrTooLarge 单个项目的大小超过 MaxBytes，缓存中原有的值保持不变
+var ErrTooLarge = errors.New("bounded: item exceeds max bytes")
+
+const (
+	// NoExpiration 永不过期
+	NoExpiration time.Duration = -1
+	// DefaultExpiration 使用配置中的默认过期时间
+	DefaultExpiration time.Duration = 0
+)
+
+// BoundedConfig 有容量上限的内存缓存配置，MaxEntries 与 MaxBytes 至少设置一个才会淘汰
+type BoundedConfig struct {
+	// Policy 淘汰策略，可选 lru、lfu，为空时使用 lru
+	Policy string
+	// MaxEntries 最大项目数，0 表示不限制
+	MaxEntries int
+	// MaxBytes 所有项目的估算大小之和的上限，0 表示不限制
+	MaxBytes int64
+	// Sizer 计算项目大小，单位/字节，为 nil 时根据值的类型估算
+	Sizer func(k string, v any) int64
+	// DefaultExpiration 默认过期时间，0 表示永不过期
+	DefaultExpiration time.Duration
+	// CleanupInterval 定期清理过期项目的间隔，0 表示只在访问时惰性清理
+	CleanupInterval time.Duration
+}
+
+// BoundedDriver 是有容量上限的内存缓存驱动，超出上限时按 LRU 或 LFU 策略淘汰项目
+type BoundedDriver struct {
+	*store
+}
+
+type store struct {
+	mu      sync.Mutex
+	items   map[string]*entry
+	policy  policy
+	bytes   int64
+	janitor chan struct{}
+
+	listeners driver.RemovalListeners
+	// pending 持有锁期间产生的删除事件，释放锁后发送
+	pending []driver.RemovalEvent
+
+	maxEntries        int
+	maxBytes          int64
+	sizer             func(k string, v any) int64
+	defaultExpiration time.Duration
+
+	// tags 标签记录，估算大小计入 MaxBytes
+	tags driver.TagIndex
+
+	// keyLocks 按键的哈希分段加锁，使同一个键的 Update 依次执行
+	keyLocks [256]sync.Mutex
+}
+
+type entry struct {
+	key     string
+	value   any
+	size    int64
+	expires int64
+	// version 每次写入时递增，Update 据此判断读取后是否有其他写入
+	version uint64
+
+	// 淘汰策略使用的字段
+	index int
+	freq  uint64
+	seq   uint64
+}
+
+func (e *entry) expired(now int64) bool {
+	return e.expires > 0 && now > e.expires
+}
+
+func New(config any) (driver.Driver, error) {
+	cfg, ok := config.(*BoundedConfig)
+	if !ok && config != nil {
+		return nil, fmt.Errorf("%w: unsupported config type %T for bounded cache", driver.ErrInvalidConfig, config)
+	}
+	if cfg == nil {
+		cfg = &BoundedConfig{}
+	}
+
+	var p policy
+	switch cfg.Policy {
+	case "", PolicyLRU:
+		p = newLRU()
+	case PolicyLFU:
+		p = newLFU()
+	default:
+		return nil, fmt.Errorf("bounded: unsupported policy %s", cfg.Policy)
+	}
+	if cfg.MaxEntries < 0 || cfg.MaxBytes < 0 {
+		return nil, fmt.Errorf("bounded: max entries and max bytes must not be negative")
+	}
+
+	defaultExpiration := cfg.DefaultExpiration
+	if defaultExpiration == 0 {
+		defaultExpiration = NoExpiration
+	}
+	sizer := cfg.Sizer
+	if sizer == nil {
+		sizer = estimateSize
+	}
+
+	s := &store{
+		items:             make(map[string]*entry),
+		policy:            p,
+		maxEntries:        cfg.MaxEntries,
+		maxBytes:          cfg.MaxBytes,
+		sizer:             sizer,
+		defaultExpiration: defaultExpiration,
+	}
+	b := &BoundedDriver{s}
+	if cfg.CleanupInterval > 0 {
+		// 与 go-cache 相同，通过包装对象的 finalizer 停止清理协程，避免驱动被回收后协程泄漏
+		s.janitor = make(chan struct{})
+		go s.runJanitor(cfg.CleanupInterval)
+		runtime.SetFinalizer(b, func(b *BoundedDriver) { close(b.janitor) })
+	}
+	return b, nil
+}
+
+func (s *store) runJanitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.DeleteExpired()
+		case <-s.janitor:
+			return
+		}
+	}
+}
+
+// Len 返回缓存中的项目数，包括已过期但尚未清理的项目
+func (s *store) Len() int {
+	s.lock()
+	defer s.unlock()
+	return len(s.items)
+}
+
+// Bytes 返回缓存中所有项目与标签记录的估算大小之和
+func (s *store) Bytes() int64 {
+	s.lock()
+	defer s.unlock()
+	return s.bytes + s.tags.Size()
+}
+
+// TagCount 返回标签记录的数量
+func (s *store) TagCount() int {
+	s.lock()
+	defer s.unlock()
+	return s.tags.Len()
+}
+
+// expiration 将 Set 的过期时间参数转换为过期的时间戳，0 表示永不过期
+func (s *store) expiration(d time.Duration) int64 {
+	if d == DefaultExpiration {
+		d = s.defaultExpiration
+	}
+	if d > 0 {
+		return time.Now().Add(d).UnixNano()
+	}
+	return 0
+}
+
+// get 返回未过期的项目，已过期的项目会被删除，调用方需要持有锁
+func (s *store) get(k string) *entry {
+	e, ok := s.items[k]
+	if !ok {
+		return nil
+	}
+	if e.expired(time.Now().UnixNano()) {
+		s.remove(e, driver.RemovalExpired)
+		return nil
+	}
+	return e
+}
+
+// set 写入项目，写入前按淘汰策略腾出空间，调用方需要持有锁
+// 覆盖已有的键时保留其访问记录；单个项目超过 MaxBytes 时不会被保存，返回 ErrTooLarge
+func (s *store) set(k string, v any, d time.Duration) error {
+	return s.setExpires(k, v, s.expiration(d))
+}
+
+// setExpires 以过期时间戳写入项目，0 表示永不过期，调用方需要持有锁
+func (s *store) setExpires(k string, v any, expires int64) error {
+	size := s.sizer(k, v)
+	if s.maxBytes > 0 && size > s.maxBytes {
+		return fmt.Errorf("%w: %s (%d > %d)", ErrTooLarge, k, size, s.maxBytes)
+	}
+	e, ok := s.items[k]
+	if ok {
+		// 覆盖写入不视为删除
+		s.remove(e, 0)
+	} else {
+		e = &entry{key: k}
+	}
+	s.evict(size)
+
+	e.value = v
+	e.size = size
+	e.expires = expires
+	e.version++
+	s.items[k] = e
+	s.bytes += size
+	s.policy.add(e)
+	return nil
+}
+
+// evict 按淘汰策略删除项目，直到能够写入 size 字节的新项目，调用方需要持有锁
+// 只剩下尚未写入的键的标签记录时不再淘汰
+func (s *store) evict(size int64) {
+	for len(s.items) > 0 && s.full(size) {
+		s.remove(s.policy.victim(), driver.RemovalEvicted)
+	}
+}
+
+// full 判断写入 size 字节的新项目是否会超出容量上限，标签记录的估算大小计入 MaxBytes
+func (s *store) full(size int64) bool {
+	return (s.maxEntries > 0 && len(s.items) >= s.maxEntries) ||
+		(s.maxBytes > 0 && s.bytes+s.tags.Size()+size > s.maxBytes)
+}
+
+// remove 删除项目，reason 不为 0 且注册了回调时记录删除事件，调用方需要持有锁
+func (s *store) remove(e *entry, reason driver.RemovalReason) {
+	delete(s.items, e.key)
+	s.bytes -= e.size
+	s.policy.remove(e)
+	if reason != 0 {
+		// 覆盖写入时 reason 为 0，保留标签记录
+		s.tags.RemoveKey(e.key)
+	}
+	if reason != 0 && !s.listeners.Empty() {
+		s.pending = append(s.pending, driver.RemovalEvent{Key: e.key, Value: e.value, HasValue: true, Reason: reason})
+	}
+}
+
+func (s *store) lock() {
+	s.mu.Lock()
+}
+
+// unlock 释放锁后再调用回调，回调中可以安全地访问缓存
+func (s *store) unlock() {
+	events := s.pending
+	s.pending = nil
+	s.mu.Unlock()
+	s.listeners.Notify(events...)
+}
+
+// OnRemoval 实现 driver.Notifier 接口，Flush 不会触发删除事件
+func (s *store) OnRemoval(fn func(driver.RemovalEvent)) (func(), error) {
+	return s.listeners.Add(fn), nil
+}
+
+// Add 仅当给定键的项目尚不存在或现有项目已过期时，才将项目添加到缓存。否则返回错误。
+func (s *store) Add(k string, v any, d time.Duration) error {
+	s.lock()
+	defer s.unlock()
+	if s.get(k) != nil {
+		return fmt.Errorf("%w: %s", driver.ErrKeyExists, k)
+	}
+	return s.set(k, v, d)
+}
+
+// Delete 从缓存中删除一个项目。如果密钥不在缓存中，则不执行任何操作。
+func (s *store) Delete(k string) {
+	s.lock()
+	defer s.unlock()
+	if e, ok := s.items[k]; ok {
+		s.remove(e, driver.RemovalDeleted)
+	}
+}
+
+// DeleteExpired 删除过期的缓存
+func (s *store) DeleteExpired() {
+	s.lock()
+	defer s.unlock()
+	now := time.Now().UnixNano()
+	for _, e := range s.items {
+		if e.expired(now) {
+			s.remove(e, driver.RemovalExpired)
+		}
+	}
+}
+
+// Flush 清空缓存
+func (s *store) Flush() {
+	s.lock()
+	s.items = make(map[string]*entry)
+	s.policy.reset()
+	s.bytes = 0
+	s.tags.Reset()
+	s.unlock()
+}
+
+// Get 从缓存中获取一个项目。返回该项或 nil，以及一个指示是否找到该键的布尔值。
+func (s *store) Get(k string) (any, bool) {
+	s.lock()
+	defer s.unlock()
+	e := s.get(k)
+	if e == nil {
+		return nil, false
+	}
+	s.policy.access(e)
+	return e.value, true
+}
+
+// GetWithExpiration 从缓存中返回一个项目及其过期时间。它返回该项目或 nil、过期时间（如果已设置）
+// (如果该项目永不过期，则返回时间的零值。Time 返回) 以及指示是否找到该键的 bool。
+func (s *store) GetWithExpiration(k string) (any, time.Time, bool) {
+	s.lock()
+	defer s.unlock()
+	e := s.get(k)
+	if e == nil {
+		return nil, time.Time{}, false
+	}
+	s.policy.access(e)
+	if e.expires == 0 {
+		return e.value, time.Time{}, true
+	}
+	return e.value, time.Unix(0, e.expires), true
+}
+
+// Replace 替换缓存,如果缓存不存在,则返回错误
+func (s *store) Replace(k string, x any, d time.Duration) error {
+	s.lock()
+	defer s.unlock()
+	if s.get(k) == nil {
+		return fmt.Errorf("%w: %s", driver.ErrCacheMiss, k)
+	}
+	return s.set(k, x, d)
+}
+
+// Set 添加/替换现有的缓存设置,包括过期时间,如果过期时间是0,则使用默认过期时间,如果为-1则表示永不过期
+// 项目超过 MaxBytes 时不会写入，原有的值保持不变，需要得知该错误时使用 SetCtx
+func (s *store) Set(k string, x any, d time.Duration) {
+	s.lock()
+	defer s.unlock()
+	_ = s.set(k, x, d)
+}
+
+// SetDefault 添加/替换现有的缓存设置,使用默认过期时间
+func (s *store) SetDefault(k string, x any) {
+	s.Set(k, x, DefaultExpiration)
+}
+
+// AddCtx Add 的 context 版本
+func (s *store) AddCtx(ctx context.Context, k string, v any, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.Add(k, v, d)
+}
+
+// DeleteCtx Delete 的 context 版本
+func (s *store) DeleteCtx(ctx context.Context, k string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.Delete(k)
+	return nil
+}
+
+// FlushCtx Flush 的 context 版本
+func (s *store) FlushCtx(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.Flush()
+	return nil
+}
+
+// GetCtx Get 的 context 版本，键不存在时返回 driver.ErrCacheMiss
+func (s *store) GetCtx(ctx context.Context, k string) (any, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	v, found := s.Get(k)
+	if !found {
+		return nil, driver.ErrCacheMiss
+	}
+	return v, nil
+}
+
+// GetWithExpirationCtx GetWithExpiration 的 context 版本，键不存在时返回 driver.ErrCacheMiss
+func (s *store) GetWithExpirationCtx(ctx context.Context, k string) (any, time.Time, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, time.Time{}, err
+	}
+	v, expiration, found := s.GetWithExpiration(k)
+	if !found {
+		return nil, time.Time{}, driver.ErrCacheMiss
+	}
+	return v, expiration, nil
+}
+
+// ReplaceCtx Replace 的 context 版本
+func (s *store) ReplaceCtx(ctx context.Context, k string, x any, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.Replace(k, x, d)
+}
+
+// SetCtx Set 的 context 版本，项目超过 MaxBytes 时返回 ErrTooLarge
+func (s *store) SetCtx(ctx context.Context, k string, x any, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.lock()
+	defer s.unlock()
+	return s.set(k, x, d)
+}
+
+// SetDefaultCtx SetDefault 的 context 版本
+func (s *store) SetDefaultCtx(ctx context.Context, k string, x any) error {
+	return s.SetCtx(ctx, k, x, DefaultExpiration)
+}
+
+// FlushPrefix 实现 driver.PrefixFlusher 接口，删除以 prefix 开头的键及标签
+func (s *store) FlushPrefix(ctx context.Context, prefix string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.lock()
+	for k, e := range s.items {
+		if strings.HasPrefix(k, prefix) {
+			s.remove(e, driver.RemovalDeleted)
+		}
+	}
+	s.tags.RemovePrefix(prefix)
+	s.unlock()
+	return nil
+}
@@ -0,0 +1,407 @@
+package bounded_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yu1ec/go-pkg/cachex/driver"
+	"github.com/yu1ec/go-pkg/cachex/driver/drivertest"
+	"github.com/yu1ec/go-pkg/cachex/driver/memory"
+	"github.com/yu1ec/go-pkg/cachex/driver/memory/bounded"
+)
+
+func newBounded(t *testing.T, cfg *bounded.BoundedConfig) *bounded.BoundedDriver {
+	d, err := bounded.New(cfg)
+	if err != nil {
+		t.Fatalf("failed to create bounded driver: %v", err)
+	}
+	return d.(*bounded.BoundedDriver)
+}
+
+func TestLRUEviction(t *testing.T) {
+	d := newBounded(t, &bounded.BoundedConfig{MaxEntries: 3})
+
+	d.Set("a", 1, 0)
+	d.Set("b", 2, 0)
+	d.Set("c", 3, 0)
+
+	// 访问 a 后，b 成为最久未被访问的项目
+	_, found := d.Get("a")
+	assert.True(t, found)
+	d.Set("d", 4, 0)
+
+	assert.Equal(t, 3, d.Len())
+	_, found = d.Get("b")
+	assert.False(t, found)
+	for _, k := range []string{"a", "c", "d"} {
+		_, found := d.Get(k)
+		assert.True(t, found, k)
+	}
+}
+
+func TestLFUEviction(t *testing.T) {
+	d := newBounded(t, &bounded.BoundedConfig{Policy: bounded.PolicyLFU, MaxEntries: 3})
+
+	d.Set("a", 1, 0)
+	d.Set("b", 2, 0)
+	d.Set("c", 3, 0)
+	for i := 0; i < 3; i++ {
+		d.Get("a")
+		d.Get("c")
+	}
+	d.Get("b")
+	d.Get("b")
+
+	// b 的访问次数最少
+	d.Set("d", 4, 0)
+	_, found := d.Get("b")
+	assert.False(t, found)
+
+	// d 的访问次数最少，次数相同时淘汰最久未访问的项目
+	d.Set("e", 5, 0)
+	_, found = d.Get("d")
+	assert.False(t, found)
+	_, found = d.Get("a")
+	assert.True(t, found)
+}
+
+func TestLFUAging(t *testing.T) {
+	d := newBounded(t, &bounded.BoundedConfig{Policy: bounded.PolicyLFU, MaxEntries: 2})
+
+	// old 过去被频繁访问，之后不再访问
+	d.Set("old", 1, 0)
+	for i := 0; i < 1000; i++ {
+		d.Get("old")
+	}
+	d.Set("hot", 2, 0)
+	for i := 0; i < 300; i++ {
+		d.Get("hot")
+	}
+
+	// 访问次数衰减后 old 的次数低于 hot
+	d.Set("new", 3, 0)
+	_, found := d.Get("old")
+	assert.False(t, found)
+	_, found = d.Get("hot")
+	assert.True(t, found)
+}
+
+func TestMaxBytes(t *testing.T) {
+	sizer := func(k string, v any) int64 { return int64(len(v.(string))) }
+	d := newBounded(t, &bounded.BoundedConfig{MaxBytes: 10, Sizer: sizer})
+
+	d.Set("a", "12345", 0)
+	d.Set("b", "12345", 0)
+	assert.Equal(t, int64(10), d.Bytes())
+
+	d.Set("c", "123", 0)
+	assert.Equal(t, int64(8), d.Bytes())
+	_, found := d.Get("a")
+	assert.False(t, found)
+
+	// 超过上限的单个项目不会被保留
+	d.Set("big", "12345678901", 0)
+	_, found = d.Get("big")
+	assert.False(t, found)
+	assert.LessOrEqual(t, d.Bytes(), int64(10))
+
+	// 超过上限的覆盖写入返回错误，原有的值保持不变
+	err := d.SetCtx(context.Background(), "c", "12345678901", 0)
+	assert.ErrorIs(t, err, bounded.ErrTooLarge)
+	v, found := d.Get("c")
+	assert.True(t, found)
+	assert.Equal(t, "123", v)
+	assert.ErrorIs(t, d.SetManyCtx(context.Background(), map[string]any{"c": "12345678901", "e": "1"}, 0), bounded.ErrTooLarge)
+	v, _ = d.Get("c")
+	assert.Equal(t, "123", v)
+	_, found = d.Get("e")
+	assert.True(t, found)
+
+	// 未提供 Sizer 时估算大小
+	d = newBounded(t, &bounded.BoundedConfig{MaxBytes: 1024})
+	for i := 0; i < 100; i++ {
+		d.Set(fmt.Sprintf("key:%d", i), []byte("0123456789012345678901234567890123456789"), 0)
+	}
+	assert.Less(t, d.Len(), 100)
+	assert.LessOrEqual(t, d.Bytes(), int64(1024))
+}
+
+func TestExpiration(t *testing.T) {
+	d := newBounded(t, &bounded.BoundedConfig{DefaultExpiration: 20 * time.Millisecond})
+
+	d.SetDefault("default", 1)
+	d.Set("short", 1, 10*time.Millisecond)
+	d.Set("forever", 1, bounded.NoExpiration)
+
+	_, expiration, found := d.GetWithExpiration("forever")
+	assert.True(t, found)
+	assert.True(t, expiration.IsZero())
+	_, expiration, found = d.GetWithExpiration("short")
+	assert.True(t, found)
+	assert.False(t, expiration.IsZero())
+
+	time.Sleep(30 * time.Millisecond)
+	_, found = d.Get("short")
+	assert.False(t, found)
+	_, found = d.Get("default")
+	assert.False(t, found)
+	_, found = d.Get("forever")
+	assert.True(t, found)
+
+	assert.NoError(t, d.Add("short", 2, 0))
+	d.Set("expired", 1, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	d.DeleteExpired()
+	assert.Equal(t, 2, d.Len())
+}
+
+func TestAddReplace(t *testing.T) {
+	d := newBounded(t, nil)
+
+	assert.True(t, errors.Is(d.Replace("k", 1, 0), driver.ErrCacheMiss))
+	assert.NoError(t, d.Add("k", 1, 0))
+	assert.True(t, errors.Is(d.Add("k", 2, 0), driver.ErrKeyExists))
+	assert.NoError(t, d.Replace("k", 3, 0))
+
+	v, found := d.Get("k")
+	assert.True(t, found)
+	assert.Equal(t, 3, v)
+}
+
+func TestNumericOperations(t *testing.T) {
+	d := newBounded(t, &bounded.BoundedConfig{MaxEntries: 10})
+
+	_, err := d.IncrementInt("missing", 1)
+	assert.True(t, errors.Is(err, driver.ErrCacheMiss))
+
+	d.Set("int", 1, 0)
+	n, err := d.IncrementInt("int", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+	n, err = d.DecrementInt("int", 5)
+	assert.NoError(t, err)
+	assert.Equal(t, -2, n)
+
+	d.Set("uint64", uint64(10), 0)
+	u, err := d.DecrementUint64("uint64", 3)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(7), u)
+
+	_, err = d.IncrementInt64("int", 1)
+	assert.Error(t, err)
+}
+
+func TestBatch(t *testing.T) {
+	d := newBounded(t, &bounded.BoundedConfig{MaxEntries: 2})
+
+	d.SetMany(map[string]any{"a": 1, "b": 2}, 0)
+	found, missing := d.GetMany([]string{"a", "b", "c"})
+	assert.Equal(t, map[string]any{"a": 1, "b": 2}, found)
+	assert.Equal(t, []string{"c"}, missing)
+
+	d.DeleteMany([]string{"a", "b"})
+	assert.Equal(t, 0, d.Len())
+	assert.Equal(t, int64(0), d.Bytes())
+}
+
+func TestMemoryImplementation(t *testing.T) {
+	d, err := memory.NewMemoryCache(map[string]any{
+		"implementation":     "lfu",
+		"max_entries":        2,
+		"default_expiration": "1m",
+	})
+	assert.NoError(t, err)
+	assert.IsType(t, &bounded.BoundedDriver{}, d)
+
+	d.Set("a", 1, 0)
+	d.Set("b", 2, 0)
+	d.Set("c", 3, 0)
+	assert.Equal(t, 2, d.(*bounded.BoundedDriver).Len())
+
+	_, err = memory.NewMemoryCache(map[string]any{"implementation": "lru", "max_entries": "many"})
+	assert.Error(t, err)
+}
+
+func TestMemoryConfigValidation(t *testing.T) {
+	d, err := memory.NewMemoryCache(map[string]any{"default_expiration": "1m"})
+	assert.NoError(t, err)
+	d.Set("a", 1, 0)
+	_, expiration, _ := d.GetWithExpiration("a")
+	assert.WithinDuration(t, time.Now().Add(time.Minute), expiration, time.Second)
+
+	d, err = memory.NewMemoryCache(&bounded.BoundedConfig{MaxEntries: 1})
+	assert.NoError(t, err)
+	assert.IsType(t, &bounded.BoundedDriver{}, d)
+
+	for name, config := range map[string]any{
+		"unknown option":       map[string]any{"CleanupInterval": time.Minute},
+		"option of other impl": map[string]any{"implementation": "gocache", "max_entries": 10},
+		"codec for lru":        map[string]any{"implementation": "lru", "codec": "json"},
+		"wrong value type":     map[string]any{"default_expiration": 60},
+		"wrong config type":    &struct{}{},
+	} {
+		_, err := memory.NewMemoryCache(config)
+		assert.ErrorIs(t, err, driver.ErrInvalidConfig, name)
+	}
+
+	_, err = bounded.New(map[string]any{})
+	assert.ErrorIs(t, err, driver.ErrInvalidConfig)
+}
+
+func TestMemoryURL(t *testing.T) {
+	d, err := driver.Open("memory://?implementation=lru&max_entries=2&default_expiration=1m")
+	assert.NoError(t, err)
+	d.Set("a", 1, 0)
+	d.Set("b", 2, 0)
+	d.Set("c", 3, 0)
+	assert.Equal(t, 2, d.(*bounded.BoundedDriver).Len())
+
+	for _, dsn := range []string{
+		"memory://?implementation=lru&max_entrys=2",
+		"memory://?max_entries=many",
+		"memory://?max_entries=10",
+		"memory://localhost",
+	} {
+		_, err := driver.Open(dsn)
+		assert.ErrorIs(t, err, driver.ErrInvalidConfig, dsn)
+	}
+}
+
+func TestRemovalEvents(t *testing.T) {
+	d := newBounded(t, &bounded.BoundedConfig{MaxEntries: 2})
+
+	var events []driver.RemovalEvent
+	cancel, err := d.OnRemoval(func(e driver.RemovalEvent) {
+		events = append(events, e)
+		// 回调在释放锁后调用，可以访问缓存
+		d.Get(e.Key)
+	})
+	assert.NoError(t, err)
+
+	d.Set("a", 1, 0)
+	d.Set("b", 2, time.Millisecond)
+	d.Set("a", 3, 0) // 覆盖写入不触发事件
+	d.Set("c", 4, 0)
+	d.Delete("a")
+	time.Sleep(5 * time.Millisecond)
+	d.Set("d", 5, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	d.Get("d")
+
+	assert.Equal(t, []driver.RemovalEvent{
+		{Key: "b", Value: 2, HasValue: true, Reason: driver.RemovalEvicted},
+		{Key: "a", Value: 3, HasValue: true, Reason: driver.RemovalDeleted},
+		{Key: "d", Value: 5, HasValue: true, Reason: driver.RemovalExpired},
+	}, events)
+
+	cancel()
+	d.Delete("c")
+	assert.Len(t, events, 3)
+}
+
+func TestConformance(t *testing.T) {
+	drivertest.Run(t, drivertest.Harness{
+		New: func(t *testing.T, defaultExpiration time.Duration) driver.Driver {
+			return newBounded(t, &bounded.BoundedConfig{MaxEntries: 1000, DefaultExpiration: defaultExpiration})
+		},
+	})
+}
+
+func TestParseURL(t *testing.T) {
+	cfg, err := bounded.ParseURL("memory-bounded://?policy=lfu&max_entries=100&max_bytes=4096&default_expiration=5m&cleanup_interval=30")
+	assert.NoError(t, err)
+	assert.Equal(t, bounded.PolicyLFU, cfg.Policy)
+	assert.Equal(t, 100, cfg.MaxEntries)
+	assert.Equal(t, int64(4096), cfg.MaxBytes)
+	assert.Equal(t, 5*time.Minute, cfg.DefaultExpiration)
+	assert.Equal(t, 30*time.Second, cfg.CleanupInterval)
+
+	d, err := driver.Open("memory-bounded://?max_entries=2")
+	assert.NoError(t, err)
+	d.Set("a", 1, 0)
+	d.Set("b", 2, 0)
+	d.Set("c", 3, 0)
+	assert.Equal(t, 2, d.(*bounded.BoundedDriver).Len())
+
+	for _, dsn := range []string{
+		"memory-bounded://?max_entrys=2",
+		"memory-bounded://?max_entries=many",
+		"memory-bounded://?policy=fifo",
+		"memory-bounded://?policy=lru&policy=lfu",
+		"memory-bounded://localhost",
+		"memory://",
+	} {
+		_, err := bounded.ParseURL(dsn)
+		assert.ErrorIs(t, err, driver.ErrInvalidConfig, dsn)
+	}
+}
+
+func TestTagMembershipShrinks(t *testing.T) {
+	d := newBounded(t, &bounded.BoundedConfig{MaxEntries: 2})
+	ctx := context.Background()
+
+	for _, k := range []string{"user:1", "user:2"} {
+		d.Set(k, "v", 20*time.Millisecond)
+		assert.NoError(t, d.TagKeys(ctx, "users", 20*time.Millisecond, k))
+	}
+	assert.Equal(t, 2, d.TagCount())
+
+	// 键过期后记录随之删除
+	time.Sleep(40 * time.Millisecond)
+	keys, err := d.TaggedKeys(ctx, "users")
+	assert.NoError(t, err)
+	assert.Empty(t, keys)
+	assert.Equal(t, 0, d.TagCount())
+
+	// 键被删除或淘汰时记录随之删除
+	for _, k := range []string{"a", "b", "c"} {
+		d.Set(k, "v", time.Minute)
+		assert.NoError(t, d.TagKeys(ctx, "letters", time.Minute, k))
+	}
+	assert.Equal(t, 2, d.TagCount())
+	d.Delete("c")
+	assert.Equal(t, 1, d.TagCount())
+}
+
+func TestTagSkipsUnstoredKeys(t *testing.T) {
+	sizer := func(k string, v any) int64 { return int64(len(v.(string))) }
+	d := newBounded(t, &bounded.BoundedConfig{MaxBytes: 1000, Sizer: sizer})
+	ctx := context.Background()
+
+	// 被拒绝写入或从未写入的键不会留下永不过期的记录
+	assert.ErrorIs(t, d.SetCtx(ctx, "big", strings.Repeat("x", 1001), 0), bounded.ErrTooLarge)
+	assert.NoError(t, d.TagKeys(ctx, "tag", 0, "big", "missing"))
+	assert.Equal(t, 0, d.TagCount())
+	keys, err := d.TaggedKeys(ctx, "tag")
+	assert.NoError(t, err)
+	assert.Empty(t, keys)
+
+	d.Set("a", "1", 0)
+	assert.NoError(t, d.TagKeys(ctx, "tag", 0, "a", "missing"))
+	keys, err = d.TaggedKeys(ctx, "tag")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a"}, keys)
+}
+
+func TestTagBytesCounted(t *testing.T) {
+	sizer := func(k string, v any) int64 { return 100 }
+	d := newBounded(t, &bounded.BoundedConfig{MaxBytes: 400, Sizer: sizer})
+	ctx := context.Background()
+
+	d.Set("a", "v", 0)
+	d.Set("b", "v", 0)
+	assert.Equal(t, int64(200), d.Bytes())
+
+	// 标签记录的大小计入 MaxBytes，超出时淘汰项目
+	assert.NoError(t, d.TagKeys(ctx, "tag", 0, "a", "b"))
+	assert.Greater(t, d.Bytes(), int64(200))
+	d.Set("c", "v", 0)
+	d.Set("d", "v", 0)
+	assert.LessOrEqual(t, d.Bytes(), int64(400))
+	assert.Less(t, d.Len(), 4)
+}
@@ -0,0 +1,55 @@
+package bounded
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/yu1ec/go-pkg/cachex/driver"
+)
+
+// ParseURL 将 DSN 解析为配置，格式为 memory-bounded://[?参数]
+// 支持的参数：policy（lru 或 lfu）、max_entries、max_bytes、default_expiration、cleanup_interval，时间参数支持 "5m" 形式或秒数
+// 未知参数返回包装了 driver.ErrInvalidConfig 的错误
+func ParseURL(dsn string) (*BoundedConfig, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("bounded: %w: %v", driver.ErrInvalidConfig, err)
+	}
+	return parseURL(u)
+}
+
+func parseURL(u *url.URL) (*BoundedConfig, error) {
+	if u.Scheme != "memory-bounded" {
+		return nil, fmt.Errorf("bounded: %w: unsupported scheme %q", driver.ErrInvalidConfig, u.Scheme)
+	}
+	if u.Host != "" || strings.Trim(u.Path, "/") != "" {
+		return nil, fmt.Errorf("bounded: %w: dsn must not have a host or path", driver.ErrInvalidConfig)
+	}
+
+	q := driver.NewURLQuery(u)
+	cfg := &BoundedConfig{
+		Policy:            q.String("policy"),
+		MaxEntries:        q.Int("max_entries"),
+		MaxBytes:          q.Int64("max_bytes"),
+		DefaultExpiration: q.Duration("default_expiration"),
+		CleanupInterval:   q.Duration("cleanup_interval"),
+	}
+	if err := q.Err(); err != nil {
+		return nil, fmt.Errorf("bounded: %w", err)
+	}
+	switch cfg.Policy {
+	case "", PolicyLRU, PolicyLFU:
+	default:
+		return nil, fmt.Errorf("bounded: %w: unsupported policy %q", driver.ErrInvalidConfig, cfg.Policy)
+	}
+	return cfg, nil
+}
+
+func openURL(u *url.URL) (driver.Driver, error) {
+	cfg, err := parseURL(u)
+	if err != nil {
+		return nil, err
+	}
+	return New(cfg)
+}
@@ -0,0 +1,148 @@
+package bounded
+
+import (
+	"container/heap"
+	"container/list"
+)
+
+// policy 淘汰策略，所有方法都在持有 store.mu 时调用
+type policy interface {
+	// add 记录加入的项目，覆盖写入时项目会先被 remove 再重新 add
+	add(e *entry)
+	// access 记录一次对项目的访问
+	access(e *entry)
+	// remove 移除项目的记录
+	remove(e *entry)
+	// victim 返回下一个应被淘汰的项目
+	victim() *entry
+	// reset 清空所有记录
+	reset()
+}
+
+// lru 使用双向链表，链表头部为最近访问的项目
+type lru struct {
+	ll    *list.List
+	elems map[*entry]*list.Element
+}
+
+func newLRU() *lru {
+	return &lru{ll: list.New(), elems: make(map[*entry]*list.Element)}
+}
+
+func (p *lru) add(e *entry) {
+	p.elems[e] = p.ll.PushFront(e)
+}
+
+func (p *lru) access(e *entry) {
+	if el, ok := p.elems[e]; ok {
+		p.ll.MoveToFront(el)
+	}
+}
+
+func (p *lru) remove(e *entry) {
+	if el, ok := p.elems[e]; ok {
+		p.ll.Remove(el)
+		delete(p.elems, e)
+	}
+}
+
+func (p *lru) victim() *entry {
+	return p.ll.Back().Value.(*entry)
+}
+
+func (p *lru) reset() {
+	p.ll.Init()
+	p.elems = make(map[*entry]*list.Element)
+}
+
+// lfuAgingFactor 每累计 lfuAgingFactor×项目数 次访问，将所有项目的访问次数减半，
+// 使过去频繁访问而现在不再访问的项目能够被淘汰
+const lfuAgingFactor = 16
+
+// lfu 使用按访问次数排序的最小堆，次数相同时比较最近一次访问的序号
+type lfu struct {
+	h   lfuHeap
+	seq uint64
+	// ops 上次衰减后的访问次数
+	ops int
+}
+
+func newLFU() *lfu {
+	return &lfu{}
+}
+
+func (p *lfu) add(e *entry) {
+	// 新项目的 freq 为 0，覆盖写入的项目保留原有的访问次数
+	p.seq++
+	e.freq++
+	e.seq = p.seq
+	heap.Push(&p.h, e)
+	p.age()
+}
+
+func (p *lfu) access(e *entry) {
+	p.seq++
+	e.freq++
+	e.seq = p.seq
+	heap.Fix(&p.h, e.index)
+	p.age()
+}
+
+// age 访问次数达到阈值时将所有项目的访问次数减半并重建堆，均摊到每次访问为 O(1)
+func (p *lfu) age() {
+	p.ops++
+	if p.ops < lfuAgingFactor*len(p.h) {
+		return
+	}
+	p.ops = 0
+	for _, e := range p.h {
+		e.freq /= 2
+	}
+	heap.Init(&p.h)
+}
+
+func (p *lfu) remove(e *entry) {
+	heap.Remove(&p.h, e.index)
+}
+
+func (p *lfu) victim() *entry {
+	return p.h[0]
+}
+
+func (p *lfu) reset() {
+	p.h = nil
+	p.seq = 0
+	p.ops = 0
+}
+
+type lfuHeap []*entry
+
+func (h lfuHeap) Len() int { return len(h) }
+
+func (h lfuHeap) Less(i, j int) bool {
+	if h[i].freq != h[j].freq {
+		return h[i].freq < h[j].freq
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h lfuHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *lfuHeap) Push(x any) {
+	e := x.(*entry)
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+
+func (h *lfuHeap) Pop() any {
+	old := *h
+	n := len(old)
+	e := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return e
+}
@@ -0,0 +1,46 @@
+package bounded
+
+import (
+	"context"
+	"time"
+
+	"github.com/yu1ec/go-pkg/cachex/driver"
+)
+
+// Scan 实现 driver.Scanner 接口，遍历与 pattern 匹配的未过期键
+func (s *store) Scan(pattern string, fn func(k string) bool) {
+	_ = s.ScanCtx(context.Background(), pattern, fn)
+}
+
+// ScanCtx 先在锁内收集匹配的键，再在锁外调用 fn，fn 中可以安全地读写缓存
+func (s *store) ScanCtx(ctx context.Context, pattern string, fn func(k string) bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	now := time.Now().UnixNano()
+	var keys []string
+	s.lock()
+	for k, e := range s.items {
+		if !e.expired(now) && driver.MatchPattern(pattern, k) {
+			keys = append(keys, k)
+		}
+	}
+	s.unlock()
+
+	for _, k := range keys {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !fn(k) {
+			return nil
+		}
+	}
+	return nil
+}
+
+func (s *store) LenCtx(ctx context.Context) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	return s.Len(), nil
+}
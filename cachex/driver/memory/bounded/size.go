@@ -0,0 +1,61 @@
+package bounded
+
+import "reflect"
+
+// maxSizeDepth 估算大小时递归的最大深度，避免过深或循环引用的结构
+const maxSizeDepth = 8
+
+// estimateSize 估算键和值占用的字节数，只用于容量控制，不追求精确
+func estimateSize(k string, v any) int64 {
+	return int64(len(k)) + sizeOf(reflect.ValueOf(v), 0)
+}
+
+func sizeOf(v reflect.Value, depth int) int64 {
+	if !v.IsValid() {
+		return 0
+	}
+	if depth > maxSizeDepth {
+		return int64(v.Type().Size())
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return int64(v.Type().Size()) + int64(v.Len())
+	case reflect.Slice:
+		size := int64(v.Type().Size())
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return size + int64(v.Len())
+		}
+		for i := 0; i < v.Len(); i++ {
+			size += sizeOf(v.Index(i), depth+1)
+		}
+		return size
+	case reflect.Array:
+		var size int64
+		for i := 0; i < v.Len(); i++ {
+			size += sizeOf(v.Index(i), depth+1)
+		}
+		return size
+	case reflect.Map:
+		size := int64(v.Type().Size())
+		iter := v.MapRange()
+		for iter.Next() {
+			size += sizeOf(iter.Key(), depth+1) + sizeOf(iter.Value(), depth+1)
+		}
+		return size
+	case reflect.Struct:
+		var size int64
+		for i := 0; i < v.NumField(); i++ {
+			size += sizeOf(v.Field(i), depth+1)
+		}
+		return size
+	case reflect.Pointer, reflect.Interface:
+		size := int64(v.Type().Size())
+		if !v.IsNil() {
+			size += sizeOf(v.Elem(), depth+1)
+		}
+		return size
+	default:
+		return int64(v.Type().Size())
+	}
+}
@@ -0,0 +1,57 @@
+package bounded
+
+import (
+	"context"
+	"time"
+
+	"github.com/yu1ec/go-pkg/cachex/driver"
+)
+
+// TagKeys 实现 driver.Tagger 接口，只记录已写入且未过期的键，记录随项目过期，
+// 键被删除、过期或淘汰时记录随之删除；写入失败或尚未写入的键不会留下记录
+// 记录的估算大小计入 MaxBytes，超出时按淘汰策略删除项目
+func (s *store) TagKeys(ctx context.Context, tag string, d time.Duration, keys ...string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.lock()
+	defer s.unlock()
+	now := time.Now().UnixNano()
+	for _, k := range keys {
+		if e, ok := s.items[k]; ok && !e.expired(now) {
+			s.tags.Add(tag, e.expires, k)
+		}
+	}
+	for len(s.items) > 0 && s.maxBytes > 0 && s.bytes+s.tags.Size() > s.maxBytes {
+		s.remove(s.policy.victim(), driver.RemovalEvicted)
+	}
+	return nil
+}
+
+// TaggedKeys 实现 driver.Tagger 接口，同时删除已过期的记录与已过期的项目
+func (s *store) TaggedKeys(ctx context.Context, tag string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.lock()
+	defer s.unlock()
+
+	now := time.Now().UnixNano()
+	for _, k := range s.tags.Keys(tag, now) {
+		if e, ok := s.items[k]; ok && e.expired(now) {
+			s.remove(e, driver.RemovalExpired)
+		}
+	}
+	return s.tags.Keys(tag, now), nil
+}
+
+// DeleteTag 实现 driver.Tagger 接口
+func (s *store) DeleteTag(ctx context.Context, tag string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.lock()
+	s.tags.RemoveTag(tag)
+	s.unlock()
+	return nil
+}
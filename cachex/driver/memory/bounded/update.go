@@ -0,0 +1,85 @@
+package bounded
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/yu1ec/go-pkg/cachex/driver"
+)
+
+func (s *store) keyLock(k string) *sync.Mutex {
+	h := fnv.New32a()
+	h.Write([]byte(k))
+	return &s.keyLocks[h.Sum32()%uint32(len(s.keyLocks))]
+}
+
+// CompareAndSwap 实现 driver.Updater 接口，使用 reflect.DeepEqual 比较当前值与 old
+func (s *store) CompareAndSwap(ctx context.Context, k string, old, new any, d time.Duration) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	s.lock()
+	defer s.unlock()
+
+	e := s.get(k)
+	if e == nil || !reflect.DeepEqual(e.value, old) {
+		return false, nil
+	}
+	if err := s.set(k, new, d); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// maxUpdateRetries Update 在调用 fn 期间有其他写入时的最大重试次数
+const maxUpdateRetries = 10
+
+// Update 实现 driver.Updater 接口
+// 同一个键的 Update 依次执行；fn 在锁外调用，写入前确认期间没有其他写入，否则重新调用 fn，
+// 重试 maxUpdateRetries 次仍有写入时返回 driver.ErrConflict
+func (s *store) Update(ctx context.Context, k string, fn func(old any, exists bool) (any, error)) (any, error) {
+	mu := s.keyLock(k)
+	mu.Lock()
+	defer mu.Unlock()
+
+	for i := 0; i < maxUpdateRetries; i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		var old any
+		var version uint64
+		s.lock()
+		e := s.get(k)
+		if e != nil {
+			old, version = e.value, e.version
+		}
+		s.unlock()
+
+		v, err := fn(old, e != nil)
+		if err != nil {
+			return nil, err
+		}
+
+		s.lock()
+		cur := s.get(k)
+		if cur == e && (e == nil || e.version == version) {
+			if e == nil {
+				err = s.set(k, v, DefaultExpiration)
+			} else {
+				err = s.setExpires(k, v, e.expires)
+			}
+			s.unlock()
+			if err != nil {
+				return nil, err
+			}
+			return v, nil
+		}
+		s.unlock()
+	}
+	return nil, fmt.Errorf("%w: %s", driver.ErrConflict, k)
+}
@@ -0,0 +1,74 @@
+package gocache
+
+import (
+	"context"
+	"time"
+
+	"github.com/yu1ec/go-pkg/cachex/driver"
+)
+
+// GetMany 批量获取缓存，在一次读锁内完成
+func (g *GoCacheDriver) GetMany(keys []string) (map[string]any, []string) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	found := make(map[string]any, len(keys))
+	var missing []string
+	for _, k := range keys {
+		if v, ok := g.cache.Get(k); ok {
+			found[k] = v
+		} else {
+			missing = append(missing, k)
+		}
+	}
+	return found, missing
+}
+
+// SetMany 批量添加/替换缓存，在一次写锁内完成
+func (g *GoCacheDriver) SetMany(items map[string]any, d time.Duration) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for k, v := range items {
+		g.touch(k)
+		g.cache.Set(k, v, d)
+	}
+}
+
+// DeleteMany 批量删除缓存，在一次写锁内完成
+func (g *GoCacheDriver) DeleteMany(keys []string) {
+	g.lockRemoval(driver.RemovalDeleted)
+	defer g.unlockRemoval()
+
+	for _, k := range keys {
+		g.touch(k)
+		g.cache.Delete(k)
+	}
+}
+
+// GetManyCtx GetMany 的 context 版本
+func (g *GoCacheDriver) GetManyCtx(ctx context.Context, keys []string) (map[string]any, []string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	found, missing := g.GetMany(keys)
+	return found, missing, nil
+}
+
+// SetManyCtx SetMany 的 context 版本
+func (g *GoCacheDriver) SetManyCtx(ctx context.Context, items map[string]any, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	g.SetMany(items, d)
+	return nil
+}
+
+// DeleteManyCtx DeleteMany 的 context 版本
+func (g *GoCacheDriver) DeleteManyCtx(ctx context.Context, keys []string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	g.DeleteMany(keys)
+	return nil
+}
@@ -0,0 +1,61 @@
+package gocache
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/yu1ec/go-pkg/cachex/driver"
+)
+
+// ParseURL 将 DSN 解析为配置，格式为 memory-gocache://[?参数]
+// 支持的参数：default_expiration（默认 5 分钟）、cleanup_interval（默认 10 分钟）与快照使用的 codec，时间参数支持 "5m" 形式或秒数
+// 未知参数返回包装了 driver.ErrInvalidConfig 的错误
+func ParseURL(dsn string) (*GoCacheConfig, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("gocache: %w: %v", driver.ErrInvalidConfig, err)
+	}
+	return parseURL(u)
+}
+
+func parseURL(u *url.URL) (*GoCacheConfig, error) {
+	if u.Scheme != "memory-gocache" {
+		return nil, fmt.Errorf("gocache: %w: unsupported scheme %q", driver.ErrInvalidConfig, u.Scheme)
+	}
+	if u.Host != "" || strings.Trim(u.Path, "/") != "" {
+		return nil, fmt.Errorf("gocache: %w: dsn must not have a host or path", driver.ErrInvalidConfig)
+	}
+
+	cfg := &GoCacheConfig{
+		DefaultExpiration: 5 * time.Minute,
+		CleanupInterval:   10 * time.Minute,
+	}
+	q := driver.NewURLQuery(u)
+	values := u.Query()
+	if values.Has("default_expiration") {
+		cfg.DefaultExpiration = q.Duration("default_expiration")
+	}
+	if values.Has("cleanup_interval") {
+		cfg.CleanupInterval = q.Duration("cleanup_interval")
+	}
+	cfg.Codec = q.String("codec")
+	if err := q.Err(); err != nil {
+		return nil, fmt.Errorf("gocache: %w", err)
+	}
+	if cfg.Codec != "" {
+		if _, err := driver.LookupCodec(cfg.Codec); err != nil {
+			return nil, fmt.Errorf("gocache: %w: %v", driver.ErrInvalidConfig, err)
+		}
+	}
+	return cfg, nil
+}
+
+func openURL(u *url.URL) (driver.Driver, error) {
+	cfg, err := parseURL(u)
+	if err != nil {
+		return nil, err
+	}
+	return New(cfg)
+}
@@ -1,6 +1,11 @@
 package gocache
 
 import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/patrickmn/go-cache"
@@ -9,80 +14,167 @@ import (
 
 func init() {
 	driver.Register("memory_gocache", New)
+	driver.RegisterURL("memory-gocache", openURL)
 }
 
+// GoCacheDriver 是基于 go-cache 的内存缓存驱动
+// 与 go-cache 相同，通过包装对象的 finalizer 停止清理协程，避免驱动被回收后协程泄漏
 type GoCacheDriver struct {
+	*goCache
+}
+
+type goCache struct {
 	cache *cache.Cache
+	// mu 保证批量操作在一次加锁内完成：写操作持有写锁，批量读取持有读锁
+	mu sync.RWMutex
+
+	// tagMu 保护 tags，可以在持有 mu 时获取
+	tagMu sync.Mutex
+	tags  driver.TagIndex
+	// defaultExpiration 标签记录使用的默认过期时间，与 go-cache 的配置一致
+	defaultExpiration time.Duration
+
+	listeners driver.RemovalListeners
+	// reason 当前删除操作的原因，持有 mu 写锁时设置，供 go-cache 的 OnEvicted 回调使用
+	reason driver.RemovalReason
+	// pending 持有锁期间产生的删除事件，释放锁后发送
+	pending []driver.RemovalEvent
+	janitor chan struct{}
+
+	// codec 快照中值的编解码器
+	codec driver.Codec
+
+	// keyLocks 按键的哈希分段加锁，使同一个键的 Update 依次执行
+	keyLocks [keyStripes]sync.Mutex
+	// versions 与 keyLocks 对应的分段写入版本，持有 mu 写锁时递增，Update 据此判断调用 fn 期间是否有其他写入
+	versions [keyStripes]uint64
 }
 
 type GoCacheConfig struct {
 	DefaultExpiration time.Duration
 	CleanupInterval   time.Duration
+	// Codec 快照（Save、Load）中值的编解码器名称，为空时使用 gob 以保留 Go 类型，自定义结构体需要通过 gob.Register 注册
+	Codec string
 }
 
 func New(config any) (driver.Driver, error) {
 	cfg, ok := config.(*GoCacheConfig)
-	if !ok {
+	if !ok && config != nil {
+		return nil, fmt.Errorf("%w: unsupported config type %T for gocache", driver.ErrInvalidConfig, config)
+	}
+	if cfg == nil {
 		cfg = &GoCacheConfig{
 			DefaultExpiration: 5 * time.Minute,
 			CleanupInterval:   10 * time.Minute,
 		}
 	}
 
-	c := cache.New(cfg.DefaultExpiration, cfg.CleanupInterval)
+	codecName := cfg.Codec
+	if codecName == "" {
+		codecName = driver.GobCodec.Name()
+	}
+	codec, err := driver.LookupCodec(codecName)
+	if err != nil {
+		return nil, err
+	}
 
-	return &GoCacheDriver{cache: c}, nil
-}
+	// 不使用 go-cache 自带的清理协程，由驱动自己清理，以便区分删除事件的原因
+	c := &goCache{
+		cache:             cache.New(cfg.DefaultExpiration, 0),
+		defaultExpiration: cfg.DefaultExpiration,
+		codec:             codec,
+	}
+	c.cache.OnEvicted(c.onEvicted)
 
-// Add 仅当给定键的项目尚不存在或现有项目已过期时，才将项目添加到缓存。否则返回错误。
-func (g *GoCacheDriver) Add(k string, v any, d time.Duration) error {
-	return g.cache.Add(k, v, d)
+	g := &GoCacheDriver{c}
+	if cfg.CleanupInterval > 0 {
+		c.janitor = make(chan struct{})
+		go c.runJanitor(cfg.CleanupInterval)
+		runtime.SetFinalizer(g, func(g *GoCacheDriver) { close(g.janitor) })
+	}
+	return g, nil
 }
 
-func (g *GoCacheDriver) IncrementInt(k string, n int) (int, error) {
-	return g.cache.IncrementInt(k, n)
+func (g *goCache) runJanitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			g.DeleteExpired()
+		case <-g.janitor:
+			return
+		}
+	}
 }
 
-func (g *GoCacheDriver) DecrementInt(k string, n int) (int, error) {
-	return g.cache.DecrementInt(k, n)
-}
+// onEvicted 在 go-cache 删除项目后调用，此时驱动持有 mu 写锁
+func (g *goCache) onEvicted(k string, v any) {
+	g.tagMu.Lock()
+	g.tags.RemoveKey(k)
+	g.tagMu.Unlock()
 
-func (g *GoCacheDriver) IncrementInt64(k string, n int64) (int64, error) {
-	return g.cache.IncrementInt64(k, n)
+	if g.listeners.Empty() {
+		return
+	}
+	g.pending = append(g.pending, driver.RemovalEvent{Key: k, Value: v, HasValue: true, Reason: g.reason})
 }
 
-func (g *GoCacheDriver) DecrementInt64(k string, n int64) (int64, error) {
-	return g.cache.DecrementInt64(k, n)
+// lockRemoval 获取写锁并设置删除原因
+func (g *goCache) lockRemoval(reason driver.RemovalReason) {
+	g.mu.Lock()
+	g.reason = reason
 }
 
-func (g *GoCacheDriver) IncrementUint(k string, n uint) (uint, error) {
-	return g.cache.IncrementUint(k, n)
+// unlockRemoval 释放写锁后再调用回调，回调中可以安全地访问缓存
+func (g *goCache) unlockRemoval() {
+	events := g.pending
+	g.pending = nil
+	g.mu.Unlock()
+	g.listeners.Notify(events...)
 }
-func (g *GoCacheDriver) DecrementUint(k string, n uint) (uint, error) {
-	return g.cache.DecrementUint(k, n)
+
+// OnRemoval 实现 driver.Notifier 接口，Flush 不会触发删除事件
+func (g *goCache) OnRemoval(fn func(driver.RemovalEvent)) (func(), error) {
+	return g.listeners.Add(fn), nil
 }
 
-func (g *GoCacheDriver) IncrementUint64(k string, n uint64) (uint64, error) {
-	return g.cache.IncrementUint64(k, n)
+// DeleteExpired 删除过期的缓存
+func (g *goCache) DeleteExpired() {
+	g.lockRemoval(driver.RemovalExpired)
+	defer g.unlockRemoval()
+	g.cache.DeleteExpired()
 }
 
-func (g *GoCacheDriver) DecrementUint64(k string, n uint64) (uint64, error) {
-	return g.cache.DecrementUint64(k, n)
+// Add 仅当给定键的项目尚不存在或现有项目已过期时，才将项目添加到缓存。否则返回错误。
+func (g *GoCacheDriver) Add(k string, v any, d time.Duration) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.touch(k)
+	if err := g.cache.Add(k, v, d); err != nil {
+		return fmt.Errorf("%w: %s", driver.ErrKeyExists, k)
+	}
+	return nil
 }
 
 // Delete 从缓存中删除一个项目。如果密钥不在缓存中，则不执行任何操作。
 func (g *GoCacheDriver) Delete(k string) {
+	g.lockRemoval(driver.RemovalDeleted)
+	defer g.unlockRemoval()
+	g.touch(k)
 	g.cache.Delete(k)
 }
 
-// DeleteExpired 删除过期的缓存
-func (g *GoCacheDriver) DeleteExpired() {
-	g.cache.DeleteExpired()
-}
-
 // Flush 清空缓存
 func (g *GoCacheDriver) Flush() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.touchAll()
 	g.cache.Flush()
+
+	g.tagMu.Lock()
+	g.tags.Reset()
+	g.tagMu.Unlock()
 }
 
 // Get 从缓存中获取一个项目。返回该项或 nil，以及一个指示是否找到该键的布尔值。
@@ -98,15 +190,123 @@ func (g *GoCacheDriver) GetWithExpiration(k string) (any, time.Time, bool) {
 
 // Replace 替换缓存,如果缓存不存在,则返回错误
 func (g *GoCacheDriver) Replace(k string, x any, d time.Duration) error {
-	return g.cache.Replace(k, x, d)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.touch(k)
+	if err := g.cache.Replace(k, x, d); err != nil {
+		return fmt.Errorf("%w: %s", driver.ErrCacheMiss, k)
+	}
+	return nil
 }
 
 // Set 添加/替换现有的缓存设置,包括过期时间,如果过期时间是0,则使用默认过期时间,如果为-1则表示永不过期
 func (g *GoCacheDriver) Set(k string, x any, d time.Duration) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.touch(k)
 	g.cache.Set(k, x, d)
 }
 
 // SetDefault 添加/替换现有的缓存设置,使用默认过期时间
 func (g *GoCacheDriver) SetDefault(k string, x any) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.touch(k)
 	g.cache.SetDefault(k, x)
 }
+
+// AddCtx Add 的 context 版本
+func (g *GoCacheDriver) AddCtx(ctx context.Context, k string, v any, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return g.Add(k, v, d)
+}
+
+// DeleteCtx Delete 的 context 版本
+func (g *GoCacheDriver) DeleteCtx(ctx context.Context, k string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	g.Delete(k)
+	return nil
+}
+
+// FlushCtx Flush 的 context 版本
+func (g *GoCacheDriver) FlushCtx(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	g.Flush()
+	return nil
+}
+
+// GetCtx Get 的 context 版本，键不存在时返回 driver.ErrCacheMiss
+func (g *GoCacheDriver) GetCtx(ctx context.Context, k string) (any, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	v, found := g.Get(k)
+	if !found {
+		return nil, driver.ErrCacheMiss
+	}
+	return v, nil
+}
+
+// GetWithExpirationCtx GetWithExpiration 的 context 版本，键不存在时返回 driver.ErrCacheMiss
+func (g *GoCacheDriver) GetWithExpirationCtx(ctx context.Context, k string) (any, time.Time, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, time.Time{}, err
+	}
+	v, expiration, found := g.GetWithExpiration(k)
+	if !found {
+		return nil, time.Time{}, driver.ErrCacheMiss
+	}
+	return v, expiration, nil
+}
+
+// ReplaceCtx Replace 的 context 版本
+func (g *GoCacheDriver) ReplaceCtx(ctx context.Context, k string, x any, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return g.Replace(k, x, d)
+}
+
+// SetCtx Set 的 context 版本
+func (g *GoCacheDriver) SetCtx(ctx context.Context, k string, x any, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	g.Set(k, x, d)
+	return nil
+}
+
+// SetDefaultCtx SetDefault 的 context 版本
+func (g *GoCacheDriver) SetDefaultCtx(ctx context.Context, k string, x any) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	g.SetDefault(k, x)
+	return nil
+}
+
+// FlushPrefix 实现 driver.PrefixFlusher 接口，删除以 prefix 开头的键及标签
+func (g *GoCacheDriver) FlushPrefix(ctx context.Context, prefix string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	g.lockRemoval(driver.RemovalDeleted)
+	for k := range g.cache.Items() {
+		if strings.HasPrefix(k, prefix) {
+			g.touch(k)
+			g.cache.Delete(k)
+		}
+	}
+	g.unlockRemoval()
+
+	g.tagMu.Lock()
+	g.tags.RemovePrefix(prefix)
+	g.tagMu.Unlock()
+	return nil
+}
@@ -0,0 +1,77 @@
+package gocache_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yu1ec/go-pkg/cachex/driver"
+	"github.com/yu1ec/go-pkg/cachex/driver/drivertest"
+	"github.com/yu1ec/go-pkg/cachex/driver/memory/gocache"
+)
+
+func TestConformance(t *testing.T) {
+	drivertest.Run(t, drivertest.Harness{
+		New: func(t *testing.T, defaultExpiration time.Duration) driver.Driver {
+			return newGoCache(t, &gocache.GoCacheConfig{DefaultExpiration: defaultExpiration})
+		},
+	})
+}
+
+func TestParseURL(t *testing.T) {
+	cfg, err := gocache.ParseURL("memory-gocache://?default_expiration=1m&codec=json")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Minute, cfg.DefaultExpiration)
+	assert.Equal(t, 10*time.Minute, cfg.CleanupInterval)
+	assert.Equal(t, "json", cfg.Codec)
+
+	// 未设置时使用与 gocache.New(nil) 相同的默认值
+	cfg, err = gocache.ParseURL("memory-gocache://")
+	assert.NoError(t, err)
+	assert.Equal(t, 5*time.Minute, cfg.DefaultExpiration)
+	assert.Equal(t, 10*time.Minute, cfg.CleanupInterval)
+
+	d, err := driver.Open("memory-gocache://?cleanup_interval=0")
+	assert.NoError(t, err)
+	d.Set("k", "v", 0)
+	v, ok := d.Get("k")
+	assert.True(t, ok)
+	assert.Equal(t, "v", v)
+
+	for _, dsn := range []string{
+		"memory-gocache://?default_expiraton=1m",
+		"memory-gocache://?default_expiration=soon",
+		"memory-gocache://?codec=unknown",
+		"memory-gocache://localhost",
+		"memory://",
+	} {
+		_, err := gocache.ParseURL(dsn)
+		assert.ErrorIs(t, err, driver.ErrInvalidConfig, dsn)
+	}
+}
+
+func TestTagMembershipShrinks(t *testing.T) {
+	d := newGoCache(t, &gocache.GoCacheConfig{CleanupInterval: 10 * time.Millisecond})
+	ctx := context.Background()
+
+	for _, k := range []string{"user:1", "user:2"} {
+		assert.NoError(t, d.TagKeys(ctx, "users", 30*time.Millisecond, k))
+		d.Set(k, "v", 30*time.Millisecond)
+	}
+	assert.NoError(t, d.TagKeys(ctx, "users", time.Minute, "user:3"))
+	d.Set("user:3", "v", time.Minute)
+	assert.Equal(t, 3, d.TagCount())
+
+	// 键被删除时记录随之删除
+	d.Delete("user:3")
+	assert.Equal(t, 2, d.TagCount())
+
+	// 键过期后记录随之删除
+	assert.Eventually(t, func() bool {
+		return d.TagCount() == 0
+	}, time.Second, 10*time.Millisecond)
+	keys, err := d.TaggedKeys(ctx, "users")
+	assert.NoError(t, err)
+	assert.Empty(t, keys)
+}
@@ -0,0 +1,99 @@
+package gocache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/yu1ec/go-pkg/cachex/driver"
+)
+
+// apply 将键的值替换为 fn 的结果，值的类型必须为 T，键不存在时返回 driver.ErrCacheMiss，保留原有的过期时间
+// fn 返回错误时值保持不变
+func apply[T driver.Integer | float64](g *GoCacheDriver, k string, fn func(v T) (T, error)) (T, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return applyLocked(g, k, fn)
+}
+
+// applyLocked apply 的实现，调用方需要持有 g.mu
+func applyLocked[T driver.Integer | float64](g *GoCacheDriver, k string, fn func(v T) (T, error)) (T, error) {
+	cur, expiration, found := g.cache.GetWithExpiration(k)
+	if !found {
+		return 0, fmt.Errorf("%w: %s", driver.ErrCacheMiss, k)
+	}
+	v, ok := cur.(T)
+	if !ok {
+		return 0, fmt.Errorf("%w: the value for %s is %T, not %T", driver.ErrNotNumeric, k, cur, v)
+	}
+	v, err := fn(v)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", err, k)
+	}
+	g.touch(k)
+	g.cache.Set(k, v, remaining(true, expiration))
+	return v, nil
+}
+
+// add 将键的值加上 n，negative 为 true 时减去 n，结果溢出时返回 driver.ErrOverflow
+func add[T driver.Integer](g *GoCacheDriver, k string, n T, negative bool) (T, error) {
+	return apply(g, k, func(v T) (T, error) {
+		return driver.AddInteger(v, n, negative)
+	})
+}
+
+// IncrementFloat64 实现 driver.FloatOperations 接口
+func (g *GoCacheDriver) IncrementFloat64(k string, n float64) (float64, error) {
+	return apply(g, k, func(v float64) (float64, error) {
+		return driver.AddFloat64(v, n)
+	})
+}
+
+// IncrementInt64WithTTL 实现 driver.Counter 接口
+func (g *GoCacheDriver) IncrementInt64WithTTL(ctx context.Context, k string, n int64, d time.Duration) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, found := g.cache.Get(k); !found {
+		g.touch(k)
+		g.cache.Set(k, n, d)
+		return n, nil
+	}
+	return applyLocked(g, k, func(v int64) (int64, error) {
+		return driver.AddInteger(v, n, false)
+	})
+}
+
+func (g *GoCacheDriver) IncrementInt(k string, n int) (int, error) {
+	return add(g, k, n, false)
+}
+
+func (g *GoCacheDriver) DecrementInt(k string, n int) (int, error) {
+	return add(g, k, n, true)
+}
+
+func (g *GoCacheDriver) IncrementInt64(k string, n int64) (int64, error) {
+	return add(g, k, n, false)
+}
+
+func (g *GoCacheDriver) DecrementInt64(k string, n int64) (int64, error) {
+	return add(g, k, n, true)
+}
+
+func (g *GoCacheDriver) IncrementUint(k string, n uint) (uint, error) {
+	return add(g, k, n, false)
+}
+
+func (g *GoCacheDriver) DecrementUint(k string, n uint) (uint, error) {
+	return add(g, k, n, true)
+}
+
+func (g *GoCacheDriver) IncrementUint64(k string, n uint64) (uint64, error) {
+	return add(g, k, n, false)
+}
+
+func (g *GoCacheDriver) DecrementUint64(k string, n uint64) (uint64, error) {
+	return add(g, k, n, true)
+}
@@ -0,0 +1,43 @@
+package gocache
+
+import (
+	"context"
+
+	"github.com/yu1ec/go-pkg/cachex/driver"
+)
+
+// Scan 实现 driver.Scanner 接口，遍历与 pattern 匹配的未过期键
+func (g *GoCacheDriver) Scan(pattern string, fn func(k string) bool) {
+	_ = g.ScanCtx(context.Background(), pattern, fn)
+}
+
+// Len 返回缓存中的项目数，包括已过期但尚未清理的项目
+func (g *GoCacheDriver) Len() int {
+	return g.cache.ItemCount()
+}
+
+// ScanCtx 遍历的是调用时的快照，fn 中可以安全地读写缓存
+func (g *GoCacheDriver) ScanCtx(ctx context.Context, pattern string, fn func(k string) bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	for k := range g.cache.Items() {
+		if !driver.MatchPattern(pattern, k) {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !fn(k) {
+			return nil
+		}
+	}
+	return nil
+}
+
+func (g *GoCacheDriver) LenCtx(ctx context.Context) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	return g.Len(), nil
+}
@@ -0,0 +1,180 @@
+package gocache
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+	"github.com/yu1ec/go-pkg/dirx"
+)
+
+func init() {
+	// 值以 any 的形式编码，gob 需要注册接口中可能出现的复合类型，基础类型已由 gob 预先注册
+	gob.Register(map[string]any{})
+	gob.Register([]any{})
+}
+
+// snapshotMagic 快照文件头，末尾的数字为格式版本
+const snapshotMagic = "cachex-gocache-snapshot-1\n"
+
+// maxSnapshotField 快照中单个键或值的最大长度，避免损坏的数据导致分配过多内存
+const maxSnapshotField = 1 << 30
+
+// Save 将所有未过期的项目写入 w，过期时间以绝对时间保存
+// 文件头记录编解码器名称；无法被编解码器编码的值（如函数、通道）会被跳过
+func (g *GoCacheDriver) Save(w io.Writer) error {
+	g.mu.RLock()
+	items := g.cache.Items()
+	g.mu.RUnlock()
+
+	bw := bufio.NewWriter(w)
+	if _, err := bw.WriteString(snapshotMagic); err != nil {
+		return err
+	}
+	if err := writeField(bw, []byte(g.codec.Name())); err != nil {
+		return err
+	}
+
+	for k, item := range items {
+		value, err := g.codec.Marshal(&item.Object)
+		if err != nil {
+			continue
+		}
+		if err := writeField(bw, []byte(k)); err != nil {
+			return err
+		}
+		var expiration [binary.MaxVarintLen64]byte
+		n := binary.PutVarint(expiration[:], item.Expiration)
+		if _, err := bw.Write(expiration[:n]); err != nil {
+			return err
+		}
+		if err := writeField(bw, value); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// Load 从 r 读取 Save 写出的快照，项目保留剩余的过期时间
+// 已过期或无法解码的项目会被跳过；已存在且未过期的键不会被覆盖
+// 快照使用的编解码器与当前配置不同时返回错误
+func (g *GoCacheDriver) Load(r io.Reader) error {
+	br := bufio.NewReader(r)
+	magic := make([]byte, len(snapshotMagic))
+	if _, err := io.ReadFull(br, magic); err != nil || string(magic) != snapshotMagic {
+		return errors.New("gocache: invalid snapshot")
+	}
+	codecName, err := readField(br)
+	if err != nil {
+		return fmt.Errorf("gocache: read snapshot: %w", err)
+	}
+	if string(codecName) != g.codec.Name() {
+		return fmt.Errorf("gocache: snapshot codec %s does not match %s", codecName, g.codec.Name())
+	}
+
+	now := time.Now().UnixNano()
+	for {
+		k, err := readField(br)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("gocache: read snapshot: %w", err)
+		}
+		expiration, err := binary.ReadVarint(br)
+		if err != nil {
+			return fmt.Errorf("gocache: read snapshot: %w", unexpectedEOF(err))
+		}
+		data, err := readField(br)
+		if err != nil {
+			return fmt.Errorf("gocache: read snapshot: %w", unexpectedEOF(err))
+		}
+
+		if expiration > 0 && expiration <= now {
+			continue
+		}
+		var v any
+		if err := g.codec.Unmarshal(data, &v); err != nil {
+			continue
+		}
+		d := cache.NoExpiration
+		if expiration > 0 {
+			d = time.Duration(expiration - now)
+		}
+		_ = g.Add(string(k), v, d)
+	}
+}
+
+// SaveFile 将快照原子地写入文件：先写入同目录下的临时文件，再重命名为目标文件
+func (g *GoCacheDriver) SaveFile(path string) error {
+	if err := dirx.CreateNestedDirFromFilepath(path, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".snapshot-*")
+	if err != nil {
+		return err
+	}
+	if err := g.Save(tmp); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+// LoadFile 从文件读取快照，文件不存在时返回的错误满足 errors.Is(err, fs.ErrNotExist)
+func (g *GoCacheDriver) LoadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return g.Load(f)
+}
+
+func writeField(w *bufio.Writer, b []byte) error {
+	var size [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(size[:], uint64(len(b)))
+	if _, err := w.Write(size[:n]); err != nil {
+		return err
+	}
+	_, err := w.Write(b)
+	return err
+}
+
+func readField(r *bufio.Reader) ([]byte, error) {
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	if n > maxSnapshotField {
+		return nil, errors.New("field too large")
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	return b, nil
+}
+
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
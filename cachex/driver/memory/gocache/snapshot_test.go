@@ -0,0 +1,89 @@
+package gocache_test
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yu1ec/go-pkg/cachex/driver/memory/gocache"
+)
+
+func newGoCache(t *testing.T, cfg *gocache.GoCacheConfig) *gocache.GoCacheDriver {
+	d, err := gocache.New(cfg)
+	if err != nil {
+		t.Fatalf("failed to create gocache driver: %v", err)
+	}
+	return d.(*gocache.GoCacheDriver)
+}
+
+func TestSnapshot(t *testing.T) {
+	src := newGoCache(t, nil)
+	src.Set("string", "value", time.Minute)
+	src.Set("int", 42, -1)
+	src.Set("map", map[string]any{"id": 1}, time.Minute)
+	src.Set("expired", "gone", time.Millisecond)
+	src.Set("func", func() {}, time.Minute) // 无法编码，跳过
+	time.Sleep(5 * time.Millisecond)
+
+	var buf bytes.Buffer
+	assert.NoError(t, src.Save(&buf))
+
+	dst := newGoCache(t, nil)
+	dst.Set("string", "existing", time.Minute)
+	assert.NoError(t, dst.Load(&buf))
+
+	// 已存在的键不会被覆盖
+	v, found := dst.Get("string")
+	assert.True(t, found)
+	assert.Equal(t, "existing", v)
+
+	v, expiration, found := dst.GetWithExpiration("int")
+	assert.True(t, found)
+	assert.Equal(t, 42, v)
+	assert.True(t, expiration.IsZero())
+
+	v, expiration, found = dst.GetWithExpiration("map")
+	assert.True(t, found)
+	assert.Equal(t, map[string]any{"id": 1}, v)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), expiration, time.Second)
+
+	_, found = dst.Get("expired")
+	assert.False(t, found)
+	_, found = dst.Get("func")
+	assert.False(t, found)
+}
+
+func TestSnapshotFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshots", "cache.snap")
+
+	dst := newGoCache(t, nil)
+	assert.True(t, errors.Is(dst.LoadFile(path), fs.ErrNotExist))
+
+	src := newGoCache(t, &gocache.GoCacheConfig{Codec: "json"})
+	src.Set("user", map[string]any{"name": "alice"}, time.Minute)
+	assert.NoError(t, src.SaveFile(path))
+
+	// 编解码器不一致
+	assert.Error(t, dst.LoadFile(path))
+
+	dst = newGoCache(t, &gocache.GoCacheConfig{Codec: "json"})
+	assert.NoError(t, dst.LoadFile(path))
+	v, found := dst.Get("user")
+	assert.True(t, found)
+	assert.Equal(t, map[string]any{"name": "alice"}, v)
+}
+
+func TestSnapshotInvalid(t *testing.T) {
+	d := newGoCache(t, nil)
+	assert.Error(t, d.Load(bytes.NewReader([]byte("not a snapshot"))))
+
+	src := newGoCache(t, nil)
+	src.Set("k", "v", time.Minute)
+	var buf bytes.Buffer
+	assert.NoError(t, src.Save(&buf))
+	assert.Error(t, d.Load(bytes.NewReader(buf.Bytes()[:buf.Len()-1])))
+}
@@ -0,0 +1,53 @@
+package gocache
+
+import (
+	"context"
+	"time"
+)
+
+// TagKeys 实现 driver.Tagger 接口，记录随 d 过期，键被删除或过期清理时记录随之删除
+func (g *GoCacheDriver) TagKeys(ctx context.Context, tag string, d time.Duration, keys ...string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if d == 0 {
+		d = g.defaultExpiration
+	}
+	var expires int64
+	if d > 0 {
+		expires = time.Now().Add(d).UnixNano()
+	}
+
+	g.tagMu.Lock()
+	g.tags.Add(tag, expires, keys...)
+	g.tagMu.Unlock()
+	return nil
+}
+
+// TaggedKeys 实现 driver.Tagger 接口，同时删除已过期的记录
+func (g *GoCacheDriver) TaggedKeys(ctx context.Context, tag string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	g.tagMu.Lock()
+	defer g.tagMu.Unlock()
+	return g.tags.Keys(tag, time.Now().UnixNano()), nil
+}
+
+// DeleteTag 实现 driver.Tagger 接口
+func (g *GoCacheDriver) DeleteTag(ctx context.Context, tag string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	g.tagMu.Lock()
+	g.tags.RemoveTag(tag)
+	g.tagMu.Unlock()
+	return nil
+}
+
+// TagCount 返回标签记录的数量
+func (g *GoCacheDriver) TagCount() int {
+	g.tagMu.Lock()
+	defer g.tagMu.Unlock()
+	return g.tags.Len()
+}
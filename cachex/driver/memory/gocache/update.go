@@ -0,0 +1,108 @@
+package gocache
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+	"github.com/yu1ec/go-pkg/cachex/driver"
+)
+
+// CompareAndSwap 实现 driver.Updater 接口，使用 reflect.DeepEqual 比较当前值与 old
+func (g *GoCacheDriver) CompareAndSwap(ctx context.Context, k string, old, new any, d time.Duration) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	cur, found := g.cache.Get(k)
+	if !found || !reflect.DeepEqual(cur, old) {
+		return false, nil
+	}
+	g.touch(k)
+	g.cache.Set(k, new, d)
+	return true, nil
+}
+
+// maxUpdateRetries Update 在调用 fn 期间有其他写入时的最大重试次数
+const maxUpdateRetries = 10
+
+// Update 实现 driver.Updater 接口
+// 同一个键的 Update 依次执行；fn 在锁外调用，写入前通过分段版本确认期间没有其他写入，否则重新调用 fn，
+// 重试 maxUpdateRetries 次仍有写入时返回 driver.ErrConflict
+func (g *GoCacheDriver) Update(ctx context.Context, k string, fn func(old any, exists bool) (any, error)) (any, error) {
+	mu := g.keyLock(k)
+	mu.Lock()
+	defer mu.Unlock()
+
+	i := keyIndex(k)
+	for n := 0; n < maxUpdateRetries; n++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		g.mu.RLock()
+		old, expiration, exists := g.cache.GetWithExpiration(k)
+		version := g.versions[i]
+		g.mu.RUnlock()
+
+		v, err := fn(old, exists)
+		if err != nil {
+			return nil, err
+		}
+
+		g.mu.Lock()
+		// 期间过期的键视为被修改
+		_, _, curExists := g.cache.GetWithExpiration(k)
+		if g.versions[i] == version && curExists == exists {
+			g.touch(k)
+			g.cache.Set(k, v, remaining(exists, expiration))
+			g.mu.Unlock()
+			return v, nil
+		}
+		g.mu.Unlock()
+	}
+	return nil, fmt.Errorf("%w: %s", driver.ErrConflict, k)
+}
+
+// keyStripes keyLocks 与 versions 的分段数
+const keyStripes = 256
+
+// keyIndex 返回键所在的分段
+func keyIndex(k string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(k))
+	return h.Sum32() % keyStripes
+}
+
+func (g *goCache) keyLock(k string) *sync.Mutex {
+	return &g.keyLocks[keyIndex(k)]
+}
+
+// touch 递增键所在分段的写入版本，调用方需要持有 mu 写锁
+func (g *goCache) touch(k string) {
+	g.versions[keyIndex(k)]++
+}
+
+// touchAll 递增所有分段的写入版本，调用方需要持有 mu 写锁
+func (g *goCache) touchAll() {
+	for i := range g.versions {
+		g.versions[i]++
+	}
+}
+
+// remaining 返回保留原有过期时间所需的过期参数，新键使用默认过期时间
+func remaining(exists bool, expiration time.Time) time.Duration {
+	if !exists {
+		return cache.DefaultExpiration
+	}
+	if expiration.IsZero() {
+		return cache.NoExpiration
+	}
+	// go-cache 将 0 视为默认过期时间、负数视为永不过期，刚好到期的键至少保留 1 纳秒
+	return max(time.Until(expiration), time.Nanosecond)
+}
@@ -2,30 +2,199 @@ package memory
 
 import (
 	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/yu1ec/go-pkg/cachex/driver"
+	"github.com/yu1ec/go-pkg/cachex/driver/memory/bounded"
 	"github.com/yu1ec/go-pkg/cachex/driver/memory/gocache"
 )
 
 func init() {
 	driver.Register("memory", NewMemoryCache)
+	driver.RegisterURL("memory", openURL)
 }
 
-// NewMemoryCache 创建一个内存缓存
+// 各实现支持的配置项
+var (
+	gocacheOptions = []string{"implementation", "default_expiration", "cleanup_interval", "codec"}
+	boundedOptions = []string{"implementation", "max_entries", "max_bytes", "default_expiration", "cleanup_interval", "sizer"}
+)
+
+// NewMemoryCache 创建一个内存缓存，config 可以是 nil（默认的 gocache）、*gocache.GoCacheConfig、*bounded.BoundedConfig 或 map[string]any
+// map 中 implementation 可选 gocache（默认，无容量上限）、lru、lfu：
+// gocache 支持 default_expiration（默认 5 分钟）、cleanup_interval（默认 10 分钟）与快照使用的 codec；
+// lru 与 lfu 支持 max_entries 最大项目数，max_bytes 最大字节数，sizer 计算项目大小的 func(k string, v any) int64，
+// default_expiration、cleanup_interval 为 time.Duration 或 "5m" 形式的字符串
+// 未知的配置项与类型错误的值返回包装了 driver.ErrInvalidConfig 的错误
 func NewMemoryCache(config any) (driver.Driver, error) {
-	cfg, ok := config.(map[string]any)
-	if !ok {
-		return nil, fmt.Errorf("invalid config for memory cache")
+	var cfg map[string]any
+	switch c := config.(type) {
+	case nil:
+		return gocache.New(nil)
+	case *gocache.GoCacheConfig:
+		return gocache.New(c)
+	case *bounded.BoundedConfig:
+		return bounded.New(c)
+	case map[string]any:
+		cfg = c
+	default:
+		return nil, fmt.Errorf("%w: unsupported config type %T for memory cache", driver.ErrInvalidConfig, config)
 	}
 
-	implementation, ok := cfg["implementation"].(string)
-	if !ok {
-		implementation = "gocache" // 默认使用 gocache
+	implementation := "gocache" // 默认使用 gocache
+	if v, ok := cfg["implementation"]; ok {
+		if implementation, ok = v.(string); !ok {
+			return nil, fmt.Errorf("%w: invalid implementation for memory cache: %T", driver.ErrInvalidConfig, v)
+		}
 	}
 	switch implementation {
 	case "gocache":
-		return gocache.New(config)
+		gocacheCfg, err := gocacheConfig(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return gocache.New(gocacheCfg)
+	case bounded.PolicyLRU, bounded.PolicyLFU:
+		boundedCfg, err := boundedConfig(implementation, cfg)
+		if err != nil {
+			return nil, err
+		}
+		return bounded.New(boundedCfg)
+	default:
+		return nil, fmt.Errorf("%w: unsupported implementation: %s", driver.ErrInvalidConfig, implementation)
+	}
+}
+
+// openURL 解析 memory://?implementation=lru&max_entries=10000 形式的 DSN，参数与 map 配置相同
+func openURL(u *url.URL) (driver.Driver, error) {
+	if u.Host != "" || strings.Trim(u.Path, "/") != "" {
+		return nil, fmt.Errorf("%w: memory dsn must not have a host or path", driver.ErrInvalidConfig)
+	}
+	q := driver.NewURLQuery(u)
+	cfg := make(map[string]any)
+	for name := range u.Query() {
+		switch name {
+		case "implementation", "codec":
+			cfg[name] = q.String(name)
+		case "max_entries", "max_bytes":
+			cfg[name] = q.Int64(name)
+		case "default_expiration", "cleanup_interval":
+			cfg[name] = q.Duration(name)
+		}
+	}
+	if err := q.Err(); err != nil {
+		return nil, fmt.Errorf("memory: %w", err)
+	}
+	return NewMemoryCache(cfg)
+}
+
+// checkOptions 检查 map 配置中是否存在当前实现不支持的配置项
+func checkOptions(cfg map[string]any, implementation string, known []string) error {
+	var unknown []string
+	for name := range cfg {
+		if !slices.Contains(known, name) {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("%w: unknown options for memory cache (%s): %s", driver.ErrInvalidConfig, implementation, strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+func gocacheConfig(cfg map[string]any) (*gocache.GoCacheConfig, error) {
+	if err := checkOptions(cfg, "gocache", gocacheOptions); err != nil {
+		return nil, err
+	}
+	c := &gocache.GoCacheConfig{
+		DefaultExpiration: 5 * time.Minute,
+		CleanupInterval:   10 * time.Minute,
+	}
+
+	var err error
+	if _, ok := cfg["default_expiration"]; ok {
+		if c.DefaultExpiration, err = durationValue(cfg, "default_expiration"); err != nil {
+			return nil, err
+		}
+	}
+	if _, ok := cfg["cleanup_interval"]; ok {
+		if c.CleanupInterval, err = durationValue(cfg, "cleanup_interval"); err != nil {
+			return nil, err
+		}
+	}
+	if v, ok := cfg["codec"]; ok {
+		if c.Codec, ok = v.(string); !ok {
+			return nil, fmt.Errorf("%w: invalid codec for memory cache: %T", driver.ErrInvalidConfig, v)
+		}
+	}
+	return c, nil
+}
+
+func boundedConfig(policy string, cfg map[string]any) (*bounded.BoundedConfig, error) {
+	if err := checkOptions(cfg, policy, boundedOptions); err != nil {
+		return nil, err
+	}
+	c := &bounded.BoundedConfig{Policy: policy}
+
+	var err error
+	if c.MaxEntries, err = intValue[int](cfg, "max_entries"); err != nil {
+		return nil, err
+	}
+	if c.MaxBytes, err = intValue[int64](cfg, "max_bytes"); err != nil {
+		return nil, err
+	}
+	if c.DefaultExpiration, err = durationValue(cfg, "default_expiration"); err != nil {
+		return nil, err
+	}
+	if c.CleanupInterval, err = durationValue(cfg, "cleanup_interval"); err != nil {
+		return nil, err
+	}
+	if v, ok := cfg["sizer"]; ok {
+		if c.Sizer, ok = v.(func(k string, v any) int64); !ok {
+			return nil, fmt.Errorf("%w: invalid sizer for memory cache: %T", driver.ErrInvalidConfig, v)
+		}
+	}
+	return c, nil
+}
+
+// intValue 读取整数配置，兼容 JSON 解码得到的 float64
+func intValue[T int | int64](cfg map[string]any, name string) (T, error) {
+	switch v := cfg[name].(type) {
+	case nil:
+		return 0, nil
+	case int:
+		return T(v), nil
+	case int64:
+		return T(v), nil
+	case float64:
+		if v != float64(int64(v)) {
+			return 0, fmt.Errorf("%w: invalid %s for memory cache: %v", driver.ErrInvalidConfig, name, v)
+		}
+		return T(v), nil
+	default:
+		return 0, fmt.Errorf("%w: invalid %s for memory cache: %T", driver.ErrInvalidConfig, name, v)
+	}
+}
+
+// durationValue 读取时间配置，支持 time.Duration 以及 "5m" 形式的字符串
+func durationValue(cfg map[string]any, name string) (time.Duration, error) {
+	switch v := cfg[name].(type) {
+	case nil:
+		return 0, nil
+	case time.Duration:
+		return v, nil
+	case string:
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return 0, fmt.Errorf("%w: invalid %s for memory cache: %v", driver.ErrInvalidConfig, name, err)
+		}
+		return d, nil
 	default:
-		return nil, fmt.Errorf("unsupported implementation: %s", implementation)
+		return 0, fmt.Errorf("%w: invalid %s for memory cache: %T", driver.ErrInvalidConfig, name, v)
 	}
 }
@@ -0,0 +1,978 @@
+package driver
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// msgpackCodec 是 MessagePack 格式的编解码器
+// 编码时通过反射直接写出 MessagePack 二进制，结构体字段与 encoding/json 一样遵循 json 标签（名称、omitempty、"-"），
+// 嵌入的结构体字段提升到外层，map 的键与 encoding/json 一样编码为字符串；[]byte 编码为 bin，浮点数保留 NaN 与无穷大
+// 自定义了 MarshalJSON 的类型沿用其 JSON 编码，实现了 encoding.TextMarshaler 的类型编码为字符串
+// 解码到 *any 时整数为 int64（超出范围时为 uint64），浮点数为 float64，bin 为 []byte，map 为 map[string]any；
+// 解码到其他类型时通过反射直接写入目标
+type msgpackCodec struct{}
+
+func (msgpackCodec) Name() string { return "msgpack" }
+
+func (msgpackCodec) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := encodeMsgpackValue(&buf, reflect.ValueOf(v)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (msgpackCodec) Unmarshal(data []byte, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("msgpack: Unmarshal requires a non-nil pointer, got %T", v)
+	}
+	d := &msgpackDecoder{data: data}
+	if err := d.decodeValue(rv.Elem()); err != nil {
+		return err
+	}
+	if d.pos != len(d.data) {
+		return errors.New("msgpack: trailing data")
+	}
+	return nil
+}
+
+var (
+	jsonMarshalerType   = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+func encodeMsgpackValue(buf *bytes.Buffer, rv reflect.Value) error {
+	if !rv.IsValid() {
+		buf.WriteByte(0xc0)
+		return nil
+	}
+	t := rv.Type()
+	if t.Kind() == reflect.Pointer && rv.IsNil() {
+		buf.WriteByte(0xc0)
+		return nil
+	}
+	// 与 encoding/json 相同，可寻址的值同样使用指针接收者的方法
+	m := rv
+	if t.Kind() != reflect.Pointer && rv.CanAddr() {
+		m = rv.Addr()
+	}
+	if m.Type().Implements(jsonMarshalerType) {
+		return encodeMsgpackJSON(buf, m.Interface().(json.Marshaler))
+	}
+	if m.Type().Implements(textMarshalerType) {
+		text, err := m.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return err
+		}
+		encodeMsgpackString(buf, string(text))
+		return nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Bool:
+		if rv.Bool() {
+			buf.WriteByte(0xc3)
+		} else {
+			buf.WriteByte(0xc2)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		encodeMsgpackInt(buf, rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if u := rv.Uint(); u > math.MaxInt64 {
+			buf.WriteByte(0xcf)
+			writeUint(buf, u, 8)
+		} else {
+			encodeMsgpackInt(buf, int64(u))
+		}
+	case reflect.Float32:
+		buf.WriteByte(0xca)
+		writeUint(buf, uint64(math.Float32bits(float32(rv.Float()))), 4)
+	case reflect.Float64:
+		buf.WriteByte(0xcb)
+		writeUint(buf, math.Float64bits(rv.Float()), 8)
+	case reflect.String:
+		encodeMsgpackString(buf, rv.String())
+	case reflect.Interface:
+		if rv.IsNil() {
+			buf.WriteByte(0xc0)
+			return nil
+		}
+		return encodeMsgpackValue(buf, rv.Elem())
+	case reflect.Pointer:
+		return encodeMsgpackValue(buf, rv.Elem())
+	case reflect.Slice:
+		if rv.IsNil() {
+			buf.WriteByte(0xc0)
+			return nil
+		}
+		if t.Elem().Kind() == reflect.Uint8 {
+			encodeMsgpackBytes(buf, rv.Bytes())
+			return nil
+		}
+		return encodeMsgpackArray(buf, rv)
+	case reflect.Array:
+		return encodeMsgpackArray(buf, rv)
+	case reflect.Map:
+		if rv.IsNil() {
+			buf.WriteByte(0xc0)
+			return nil
+		}
+		return encodeMsgpackMap(buf, rv)
+	case reflect.Struct:
+		return encodeMsgpackStruct(buf, rv)
+	default:
+		return fmt.Errorf("msgpack: unsupported type %s", t)
+	}
+	return nil
+}
+
+// encodeMsgpackJSON 按类型自定义的 JSON 编码写出值：解析为基础类型后再写出 MessagePack
+func encodeMsgpackJSON(buf *bytes.Buffer, m json.Marshaler) error {
+	raw, err := m.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var generic any
+	if err := dec.Decode(&generic); err != nil {
+		return err
+	}
+	return encodeMsgpack(buf, generic)
+}
+
+func encodeMsgpackArray(buf *bytes.Buffer, rv reflect.Value) error {
+	n := rv.Len()
+	writeMsgpackHeader(buf, n, 0x90, 0xdc)
+	for i := 0; i < n; i++ {
+		if err := encodeMsgpackValue(buf, rv.Index(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeMsgpackMap 写出 map，键与 encoding/json 一样转换为字符串，按键排序保证相同的值编码结果一致
+func encodeMsgpackMap(buf *bytes.Buffer, rv reflect.Value) error {
+	type pair struct {
+		key   string
+		value reflect.Value
+	}
+	pairs := make([]pair, 0, rv.Len())
+	iter := rv.MapRange()
+	for iter.Next() {
+		k, err := mapKeyString(iter.Key())
+		if err != nil {
+			return err
+		}
+		pairs = append(pairs, pair{k, iter.Value()})
+	}
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].key < pairs[j].key })
+
+	writeMsgpackHeader(buf, len(pairs), 0x80, 0xde)
+	for _, p := range pairs {
+		encodeMsgpackString(buf, p.key)
+		if err := encodeMsgpackValue(buf, p.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func mapKeyString(k reflect.Value) (string, error) {
+	if k.Kind() == reflect.String {
+		return k.String(), nil
+	}
+	if tm, ok := k.Interface().(encoding.TextMarshaler); ok {
+		text, err := tm.MarshalText()
+		return string(text), err
+	}
+	switch k.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(k.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(k.Uint(), 10), nil
+	}
+	return "", fmt.Errorf("msgpack: unsupported map key type %s", k.Type())
+}
+
+func encodeMsgpackStruct(buf *bytes.Buffer, rv reflect.Value) error {
+	fields := cachedFields(rv.Type())
+	values := make([]reflect.Value, len(fields))
+	n := 0
+	for i, f := range fields {
+		v, ok := fieldByIndex(rv, f.index)
+		if !ok || (f.omitEmpty && isEmptyValue(v)) {
+			continue
+		}
+		values[i] = v
+		n++
+	}
+
+	writeMsgpackHeader(buf, n, 0x80, 0xde)
+	for i, f := range fields {
+		if !values[i].IsValid() {
+			continue
+		}
+		encodeMsgpackString(buf, f.name)
+		if err := encodeMsgpackValue(buf, values[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fieldByIndex 按索引路径读取字段，经过 nil 的嵌入指针时返回 false
+func fieldByIndex(rv reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && rv.Kind() == reflect.Pointer {
+			if rv.IsNil() {
+				return reflect.Value{}, false
+			}
+			rv = rv.Elem()
+		}
+		rv = rv.Field(x)
+	}
+	return rv, true
+}
+
+// isEmptyValue 与 encoding/json 的 omitempty 规则一致
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Pointer:
+		return v.IsNil()
+	}
+	return false
+}
+
+// msgpackField 结构体中参与编解码的字段
+type msgpackField struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+// fieldCache 缓存结构体类型的字段，map[reflect.Type][]msgpackField
+var fieldCache sync.Map
+
+// cachedFields 返回结构体参与编解码的字段，规则与 encoding/json 相同：
+// 只包括导出的字段，json 标签可以重命名、忽略（"-"）或设置 omitempty，未设置名称的嵌入结构体的字段提升到外层，
+// 同名字段取嵌入层级最浅的一个
+func cachedFields(t reflect.Type) []msgpackField {
+	if f, ok := fieldCache.Load(t); ok {
+		return f.([]msgpackField)
+	}
+
+	type level struct {
+		t     reflect.Type
+		index []int
+	}
+	var fields []msgpackField
+	seen := make(map[string]bool)
+	visited := make(map[reflect.Type]bool)
+	// 逐层处理嵌入的结构体，较浅的字段先被记录
+	for current := []level{{t, nil}}; len(current) > 0; {
+		var next []level
+		for _, l := range current {
+			if visited[l.t] {
+				continue
+			}
+			visited[l.t] = true
+			for i := 0; i < l.t.NumField(); i++ {
+				sf := l.t.Field(i)
+				tag := sf.Tag.Get("json")
+				if tag == "-" {
+					continue
+				}
+				name, opts, _ := strings.Cut(tag, ",")
+				index := append(append([]int(nil), l.index...), i)
+				ft := sf.Type
+				if ft.Kind() == reflect.Pointer {
+					ft = ft.Elem()
+				}
+				if sf.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+					next = append(next, level{ft, index})
+					continue
+				}
+				if !sf.IsExported() {
+					continue
+				}
+				if name == "" {
+					name = sf.Name
+				}
+				if seen[name] {
+					continue
+				}
+				seen[name] = true
+				fields = append(fields, msgpackField{
+					name:      name,
+					index:     index,
+					omitEmpty: strings.Contains(","+opts+",", ",omitempty,"),
+				})
+			}
+		}
+		current = next
+	}
+
+	f, _ := fieldCache.LoadOrStore(t, fields)
+	return f.([]msgpackField)
+}
+
+// encodeMsgpack 写出 encoding/json 解码得到的基础类型，用于自定义了 MarshalJSON 的类型
+func encodeMsgpack(buf *bytes.Buffer, v any) error {
+	switch x := v.(type) {
+	case nil:
+		buf.WriteByte(0xc0)
+	case bool:
+		if x {
+			buf.WriteByte(0xc3)
+		} else {
+			buf.WriteByte(0xc2)
+		}
+	case json.Number:
+		if i, err := strconv.ParseInt(x.String(), 10, 64); err == nil {
+			encodeMsgpackInt(buf, i)
+			return nil
+		}
+		if u, err := strconv.ParseUint(x.String(), 10, 64); err == nil {
+			buf.WriteByte(0xcf)
+			writeUint(buf, u, 8)
+			return nil
+		}
+		f, err := x.Float64()
+		if err != nil {
+			return err
+		}
+		buf.WriteByte(0xcb)
+		writeUint(buf, math.Float64bits(f), 8)
+	case string:
+		encodeMsgpackString(buf, x)
+	case []any:
+		writeMsgpackHeader(buf, len(x), 0x90, 0xdc)
+		for _, item := range x {
+			if err := encodeMsgpack(buf, item); err != nil {
+				return err
+			}
+		}
+	case map[string]any:
+		writeMsgpackHeader(buf, len(x), 0x80, 0xde)
+		// 按键排序保证相同的值编码结果一致
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			encodeMsgpackString(buf, k)
+			if err := encodeMsgpack(buf, x[k]); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("msgpack: unsupported type %T", v)
+	}
+	return nil
+}
+
+func encodeMsgpackInt(buf *bytes.Buffer, i int64) {
+	switch {
+	case i >= 0 && i <= 0x7f:
+		buf.WriteByte(byte(i))
+	case i < 0 && i >= -32:
+		buf.WriteByte(byte(int8(i)))
+	case i >= math.MinInt8 && i <= math.MaxInt8:
+		buf.WriteByte(0xd0)
+		buf.WriteByte(byte(int8(i)))
+	case i >= math.MinInt16 && i <= math.MaxInt16:
+		buf.WriteByte(0xd1)
+		writeUint(buf, uint64(uint16(int16(i))), 2)
+	case i >= math.MinInt32 && i <= math.MaxInt32:
+		buf.WriteByte(0xd2)
+		writeUint(buf, uint64(uint32(int32(i))), 4)
+	default:
+		buf.WriteByte(0xd3)
+		writeUint(buf, uint64(i), 8)
+	}
+}
+
+func encodeMsgpackString(buf *bytes.Buffer, s string) {
+	n := len(s)
+	switch {
+	case n < 32:
+		buf.WriteByte(0xa0 | byte(n))
+	case n <= math.MaxUint8:
+		buf.WriteByte(0xd9)
+		buf.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(0xda)
+		writeUint(buf, uint64(n), 2)
+	default:
+		buf.WriteByte(0xdb)
+		writeUint(buf, uint64(n), 4)
+	}
+	buf.WriteString(s)
+}
+
+func encodeMsgpackBytes(buf *bytes.Buffer, b []byte) {
+	n := len(b)
+	switch {
+	case n <= math.MaxUint8:
+		buf.WriteByte(0xc4)
+		buf.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(0xc5)
+		writeUint(buf, uint64(n), 2)
+	default:
+		buf.WriteByte(0xc6)
+		writeUint(buf, uint64(n), 4)
+	}
+	buf.Write(b)
+}
+
+// writeMsgpackHeader 写出数组或 map 的长度，fix 为不超过 15 个元素时的格式，wide 为 16 位长度的格式，其后为 32 位长度的格式
+func writeMsgpackHeader(buf *bytes.Buffer, n int, fix, wide byte) {
+	switch {
+	case n < 16:
+		buf.WriteByte(fix | byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(wide)
+		writeUint(buf, uint64(n), 2)
+	default:
+		buf.WriteByte(wide + 1)
+		writeUint(buf, uint64(n), 4)
+	}
+}
+
+func writeUint(buf *bytes.Buffer, u uint64, size int) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], u)
+	buf.Write(b[8-size:])
+}
+
+var errMsgpackShort = errors.New("msgpack: unexpected end of data")
+
+type msgpackDecoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *msgpackDecoder) next(n int) ([]byte, error) {
+	if n < 0 || d.pos+n > len(d.data) {
+		return nil, errMsgpackShort
+	}
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+func (d *msgpackDecoder) uint(size int) (uint64, error) {
+	b, err := d.next(size)
+	if err != nil {
+		return 0, err
+	}
+	var u uint64
+	for _, c := range b {
+		u = u<<8 | uint64(c)
+	}
+	return u, nil
+}
+
+func (d *msgpackDecoder) decode() (any, error) {
+	b, err := d.next(1)
+	if err != nil {
+		return nil, err
+	}
+	c := b[0]
+
+	switch {
+	case c <= 0x7f:
+		return int64(c), nil
+	case c >= 0xe0:
+		return int64(int8(c)), nil
+	case c&0xe0 == 0xa0:
+		return d.str(int(c & 0x1f))
+	case c&0xf0 == 0x90:
+		return d.array(int(c & 0x0f))
+	case c&0xf0 == 0x80:
+		return d.object(int(c & 0x0f))
+	}
+
+	switch c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := d.uint(1 << (c - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		b, err := d.next(int(n))
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte(nil), b...), nil
+	case 0xca:
+		u, err := d.uint(4)
+		return float64(math.Float32frombits(uint32(u))), err
+	case 0xcb:
+		u, err := d.uint(8)
+		return math.Float64frombits(u), err
+	case 0xcc, 0xcd, 0xce:
+		u, err := d.uint(1 << (c - 0xcc))
+		return int64(u), err
+	case 0xcf:
+		u, err := d.uint(8)
+		if err != nil {
+			return nil, err
+		}
+		if u > math.MaxInt64 {
+			return u, nil
+		}
+		return int64(u), nil
+	case 0xd0:
+		u, err := d.uint(1)
+		return int64(int8(u)), err
+	case 0xd1:
+		u, err := d.uint(2)
+		return int64(int16(u)), err
+	case 0xd2:
+		u, err := d.uint(4)
+		return int64(int32(u)), err
+	case 0xd3:
+		u, err := d.uint(8)
+		return int64(u), err
+	case 0xd9, 0xda, 0xdb:
+		n, err := d.uint(1 << (c - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return d.str(int(n))
+	case 0xdc, 0xdd:
+		n, err := d.uint(2 << (c - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return d.array(int(n))
+	case 0xde, 0xdf:
+		n, err := d.uint(2 << (c - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return d.object(int(n))
+	}
+	return nil, fmt.Errorf("msgpack: unsupported format 0x%02x", c)
+}
+
+func (d *msgpackDecoder) str(n int) (any, error) {
+	b, err := d.next(n)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func (d *msgpackDecoder) array(n int) (any, error) {
+	if n > len(d.data)-d.pos {
+		return nil, errMsgpackShort
+	}
+	arr := make([]any, n)
+	for i := range arr {
+		v, err := d.decode()
+		if err != nil {
+			return nil, err
+		}
+		arr[i] = v
+	}
+	return arr, nil
+}
+
+func (d *msgpackDecoder) object(n int) (any, error) {
+	if n > len(d.data)-d.pos {
+		return nil, errMsgpackShort
+	}
+	m := make(map[string]any, n)
+	for i := 0; i < n; i++ {
+		key, err := d.key()
+		if err != nil {
+			return nil, err
+		}
+		v, err := d.decode()
+		if err != nil {
+			return nil, err
+		}
+		m[key] = v
+	}
+	return m, nil
+}
+
+// decodeValue 将下一个值直接解码到 rv，rv 必须可以设置
+func (d *msgpackDecoder) decodeValue(rv reflect.Value) error {
+	if d.pos >= len(d.data) {
+		return errMsgpackShort
+	}
+	c := d.data[d.pos]
+	// 与 encoding/json 相同，nil 只清空指针、接口、map 与切片，其他类型保持不变
+	if c == 0xc0 {
+		d.pos++
+		switch rv.Kind() {
+		case reflect.Interface, reflect.Pointer, reflect.Map, reflect.Slice:
+			rv.Set(reflect.Zero(rv.Type()))
+		}
+		return nil
+	}
+
+	if rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		return d.decodeValue(rv.Elem())
+	}
+	if rv.CanAddr() {
+		if u, ok := rv.Addr().Interface().(json.Unmarshaler); ok {
+			return d.decodeJSON(u)
+		}
+		if u, ok := rv.Addr().Interface().(encoding.TextUnmarshaler); ok {
+			v, err := d.decode()
+			if err != nil {
+				return err
+			}
+			text, ok := v.(string)
+			if !ok {
+				return fmt.Errorf("msgpack: cannot decode %T into %s", v, rv.Type())
+			}
+			return u.UnmarshalText([]byte(text))
+		}
+	}
+
+	t := rv.Type()
+	switch t.Kind() {
+	case reflect.Slice:
+		if t.Elem().Kind() != reflect.Uint8 || isMsgpackArray(c) {
+			return d.decodeSlice(rv)
+		}
+	case reflect.Array:
+		return d.decodeArray(rv)
+	case reflect.Map:
+		return d.decodeMap(rv)
+	case reflect.Struct:
+		return d.decodeStruct(rv)
+	}
+
+	v, err := d.decode()
+	if err != nil {
+		return err
+	}
+	mismatch := fmt.Errorf("msgpack: cannot decode %T into %s", v, t)
+	switch t.Kind() {
+	case reflect.Interface:
+		if v == nil {
+			rv.Set(reflect.Zero(t))
+			return nil
+		}
+		if !reflect.TypeOf(v).AssignableTo(t) {
+			return mismatch
+		}
+		rv.Set(reflect.ValueOf(v))
+	case reflect.Bool:
+		b, ok := v.(bool)
+		if !ok {
+			return mismatch
+		}
+		rv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, ok := v.(int64)
+		if !ok || rv.OverflowInt(i) {
+			return mismatch
+		}
+		rv.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		var u uint64
+		switch x := v.(type) {
+		case int64:
+			if x < 0 {
+				return mismatch
+			}
+			u = uint64(x)
+		case uint64:
+			u = x
+		default:
+			return mismatch
+		}
+		if rv.OverflowUint(u) {
+			return mismatch
+		}
+		rv.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		switch x := v.(type) {
+		case float64:
+			f = x
+		case int64:
+			f = float64(x)
+		case uint64:
+			f = float64(x)
+		default:
+			return mismatch
+		}
+		if !math.IsInf(f, 0) && rv.OverflowFloat(f) {
+			return mismatch
+		}
+		rv.SetFloat(f)
+	case reflect.String:
+		s, ok := v.(string)
+		if !ok {
+			return mismatch
+		}
+		rv.SetString(s)
+	case reflect.Slice:
+		b, ok := v.([]byte)
+		if !ok {
+			return mismatch
+		}
+		rv.SetBytes(b)
+	default:
+		return mismatch
+	}
+	return nil
+}
+
+// decodeJSON 将下一个值按 JSON 交给类型自定义的 UnmarshalJSON，与 encodeMsgpackJSON 对应
+func (d *msgpackDecoder) decodeJSON(u json.Unmarshaler) error {
+	v, err := d.decode()
+	if err != nil {
+		return err
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return u.UnmarshalJSON(raw)
+}
+
+func isMsgpackArray(c byte) bool {
+	return c&0xf0 == 0x90 || c == 0xdc || c == 0xdd
+}
+
+// header 读取数组或 map 的长度，fix 与 wide 的含义同 writeMsgpackHeader
+func (d *msgpackDecoder) header(fix, wide byte, t reflect.Type) (int, error) {
+	b, err := d.next(1)
+	if err != nil {
+		return 0, err
+	}
+	var n uint64
+	switch c := b[0]; {
+	case c&0xf0 == fix:
+		n = uint64(c & 0x0f)
+	case c == wide:
+		n, err = d.uint(2)
+	case c == wide+1:
+		n, err = d.uint(4)
+	default:
+		return 0, fmt.Errorf("msgpack: cannot decode format 0x%02x into %s", c, t)
+	}
+	if err != nil {
+		return 0, err
+	}
+	// 每个元素至少占一个字节，长度超出剩余数据时不必分配
+	if n > uint64(len(d.data)-d.pos) {
+		return 0, errMsgpackShort
+	}
+	return int(n), nil
+}
+
+func (d *msgpackDecoder) decodeSlice(rv reflect.Value) error {
+	n, err := d.header(0x90, 0xdc, rv.Type())
+	if err != nil {
+		return err
+	}
+	s := reflect.MakeSlice(rv.Type(), n, n)
+	for i := 0; i < n; i++ {
+		if err := d.decodeValue(s.Index(i)); err != nil {
+			return err
+		}
+	}
+	rv.Set(s)
+	return nil
+}
+
+// decodeArray 与 encoding/json 相同，多余的元素被丢弃，不足的元素置为零值
+func (d *msgpackDecoder) decodeArray(rv reflect.Value) error {
+	n, err := d.header(0x90, 0xdc, rv.Type())
+	if err != nil {
+		return err
+	}
+	for i := 0; i < n; i++ {
+		if i >= rv.Len() {
+			if _, err := d.decode(); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := d.decodeValue(rv.Index(i)); err != nil {
+			return err
+		}
+	}
+	for i := n; i < rv.Len(); i++ {
+		rv.Index(i).Set(reflect.Zero(rv.Type().Elem()))
+	}
+	return nil
+}
+
+func (d *msgpackDecoder) decodeMap(rv reflect.Value) error {
+	t := rv.Type()
+	n, err := d.header(0x80, 0xde, t)
+	if err != nil {
+		return err
+	}
+	if rv.IsNil() {
+		rv.Set(reflect.MakeMapWithSize(t, n))
+	}
+	for i := 0; i < n; i++ {
+		k, err := d.key()
+		if err != nil {
+			return err
+		}
+		key, err := mapKeyValue(k, t.Key())
+		if err != nil {
+			return err
+		}
+		elem := reflect.New(t.Elem()).Elem()
+		if err := d.decodeValue(elem); err != nil {
+			return err
+		}
+		rv.SetMapIndex(key, elem)
+	}
+	return nil
+}
+
+// mapKeyValue 将字符串键转换为 map 的键类型，与 mapKeyString 对应
+func mapKeyValue(k string, t reflect.Type) (reflect.Value, error) {
+	if t.Kind() == reflect.String {
+		return reflect.ValueOf(k).Convert(t), nil
+	}
+	if p := reflect.New(t); p.Type().Implements(textUnmarshalerType) {
+		if err := p.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(k)); err != nil {
+			return reflect.Value{}, err
+		}
+		return p.Elem(), nil
+	}
+	key := reflect.New(t).Elem()
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(k, 10, 64)
+		if err != nil || key.OverflowInt(i) {
+			return reflect.Value{}, fmt.Errorf("msgpack: invalid map key %q for %s", k, t)
+		}
+		key.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u, err := strconv.ParseUint(k, 10, 64)
+		if err != nil || key.OverflowUint(u) {
+			return reflect.Value{}, fmt.Errorf("msgpack: invalid map key %q for %s", k, t)
+		}
+		key.SetUint(u)
+	default:
+		return reflect.Value{}, fmt.Errorf("msgpack: unsupported map key type %s", t)
+	}
+	return key, nil
+}
+
+// decodeStruct 按 cachedFields 的字段名写入字段，名称先精确匹配再忽略大小写匹配，未知的字段被跳过
+func (d *msgpackDecoder) decodeStruct(rv reflect.Value) error {
+	n, err := d.header(0x80, 0xde, rv.Type())
+	if err != nil {
+		return err
+	}
+	fields := cachedFields(rv.Type())
+	for i := 0; i < n; i++ {
+		k, err := d.key()
+		if err != nil {
+			return err
+		}
+		f := lookupField(fields, k)
+		if f == nil {
+			if _, err := d.decode(); err != nil {
+				return err
+			}
+			continue
+		}
+		fv, err := fieldForSet(rv, f.index)
+		if err != nil {
+			return err
+		}
+		if err := d.decodeValue(fv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func lookupField(fields []msgpackField, name string) *msgpackField {
+	for i := range fields {
+		if fields[i].name == name {
+			return &fields[i]
+		}
+	}
+	for i := range fields {
+		if strings.EqualFold(fields[i].name, name) {
+			return &fields[i]
+		}
+	}
+	return nil
+}
+
+// fieldForSet 按索引路径取得要写入的字段，经过 nil 的嵌入指针时分配新值
+func fieldForSet(rv reflect.Value, index []int) (reflect.Value, error) {
+	for i, x := range index {
+		if i > 0 && rv.Kind() == reflect.Pointer {
+			if rv.IsNil() {
+				if !rv.CanSet() {
+					return reflect.Value{}, fmt.Errorf("msgpack: cannot set embedded pointer to unexported struct %s", rv.Type().Elem())
+				}
+				rv.Set(reflect.New(rv.Type().Elem()))
+			}
+			rv = rv.Elem()
+		}
+		rv = rv.Field(x)
+	}
+	return rv, nil
+}
+
+// key 读取 map 的字符串键
+func (d *msgpackDecoder) key() (string, error) {
+	k, err := d.decode()
+	if err != nil {
+		return "", err
+	}
+	s, ok := k.(string)
+	if !ok {
+		return "", fmt.Errorf("msgpack: unsupported map key type %T", k)
+	}
+	return s, nil
+}
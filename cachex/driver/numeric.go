@@ -0,0 +1,36 @@
+package driver
+
+import "math"
+
+// Integer 数值操作支持的整数类型
+type Integer interface {
+	int | int64 | uint | uint64
+}
+
+// AddInteger 返回 v 加上 n 的结果，negative 为 true 时返回 v 减去 n 的结果
+// 结果超出 T 的取值范围（包括无符号类型减到负数）时返回 ErrOverflow，供驱动实现数值操作时使用
+func AddInteger[T Integer](v, n T, negative bool) (T, error) {
+	var zero T
+	signed := ^zero < 0
+	if negative {
+		r := v - n
+		if (signed && ((n > 0 && r > v) || (n < 0 && r < v))) || (!signed && v < n) {
+			return v, ErrOverflow
+		}
+		return r, nil
+	}
+	r := v + n
+	if (signed && ((n > 0 && r < v) || (n < 0 && r > v))) || (!signed && r < v) {
+		return v, ErrOverflow
+	}
+	return r, nil
+}
+
+// AddFloat64 返回 v 加上 n 的结果，结果为无穷大或 NaN 时返回 ErrOverflow
+func AddFloat64(v, n float64) (float64, error) {
+	r := v + n
+	if math.IsInf(r, 0) || math.IsNaN(r) {
+		return v, ErrOverflow
+	}
+	return r, nil
+}
@@ -0,0 +1,40 @@
+package driver_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yu1ec/go-pkg/cachex/driver"
+)
+
+func TestAddInteger(t *testing.T) {
+	v, err := driver.AddInteger(int64(1), 2, false)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), v)
+
+	v, err = driver.AddInteger(int64(1), 2, true)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(-1), v)
+
+	// 溢出时返回原值
+	v, err = driver.AddInteger(int64(math.MaxInt64), 1, false)
+	assert.ErrorIs(t, err, driver.ErrOverflow)
+	assert.Equal(t, int64(math.MaxInt64), v)
+
+	_, err = driver.AddInteger(int64(math.MinInt64), -1, false)
+	assert.ErrorIs(t, err, driver.ErrOverflow)
+	_, err = driver.AddInteger(int64(math.MinInt64), 1, true)
+	assert.ErrorIs(t, err, driver.ErrOverflow)
+	_, err = driver.AddInteger(int64(math.MaxInt64), -1, true)
+	assert.ErrorIs(t, err, driver.ErrOverflow)
+
+	_, err = driver.AddInteger(uint64(math.MaxUint64), 1, false)
+	assert.ErrorIs(t, err, driver.ErrOverflow)
+	_, err = driver.AddInteger(uint(1), 2, true)
+	assert.ErrorIs(t, err, driver.ErrOverflow)
+
+	u, err := driver.AddInteger(uint(2), 2, true)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(0), u)
+}
@@ -0,0 +1,98 @@
+package driver
+
+import "strings"
+
+// MatchPattern 判断 s 是否与 glob 模式 pattern 匹配，语法与 Redis 的 KEYS/SCAN 相同：
+// * 匹配任意字符串（包括空串与 /），? 匹配单个字符，[abc]、[a-z] 与 [^abc] 匹配字符集，\ 转义下一个字符
+func MatchPattern(pattern, s string) bool {
+	return match([]rune(pattern), []rune(s))
+}
+
+func match(p, s []rune) bool {
+	for len(p) > 0 {
+		switch p[0] {
+		case '*':
+			for len(p) > 1 && p[1] == '*' {
+				p = p[1:]
+			}
+			if len(p) == 1 {
+				return true
+			}
+			for i := 0; i <= len(s); i++ {
+				if match(p[1:], s[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(s) == 0 {
+				return false
+			}
+		case '[':
+			if len(s) == 0 {
+				return false
+			}
+			var matched bool
+			matched, p = matchClass(p[1:], s[0])
+			if !matched {
+				return false
+			}
+			s = s[1:]
+			continue
+		case '\\':
+			if len(p) > 1 {
+				p = p[1:]
+			}
+			fallthrough
+		default:
+			if len(s) == 0 || s[0] != p[0] {
+				return false
+			}
+		}
+		p, s = p[1:], s[1:]
+	}
+	return len(s) == 0
+}
+
+// matchClass 判断 c 是否属于字符集，p 为 [ 之后的模式，返回是否匹配以及 ] 之后的模式
+func matchClass(p []rune, c rune) (bool, []rune) {
+	negate := len(p) > 0 && p[0] == '^'
+	if negate {
+		p = p[1:]
+	}
+	matched := false
+	for len(p) > 0 && p[0] != ']' {
+		switch {
+		case p[0] == '\\' && len(p) > 1:
+			matched = matched || p[1] == c
+			p = p[2:]
+		case len(p) > 2 && p[1] == '-' && p[2] != ']':
+			lo, hi := p[0], p[2]
+			if lo > hi {
+				lo, hi = hi, lo
+			}
+			matched = matched || (c >= lo && c <= hi)
+			p = p[3:]
+		default:
+			matched = matched || p[0] == c
+			p = p[1:]
+		}
+	}
+	if len(p) > 0 {
+		p = p[1:]
+	}
+	return matched != negate, p
+}
+
+// EscapePattern 转义 glob 模式中的特殊字符，使 s 按字面匹配，常用于在模式前加上键的前缀
+func EscapePattern(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		switch c {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
@@ -0,0 +1,42 @@
+package driver_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yu1ec/go-pkg/cachex/driver"
+)
+
+func TestMatchPattern(t *testing.T) {
+	cases := []struct {
+		pattern, s string
+		want       bool
+	}{
+		{"*", "", true},
+		{"*", "user:1/profile", true},
+		{"user:*", "user:1", true},
+		{"user:*", "users:1", false},
+		{"user:*:profile", "user:42:profile", true},
+		{"user:*:profile", "user:42:settings", false},
+		{"h?llo", "hello", true},
+		{"h?llo", "hllo", false},
+		{"h[ae]llo", "hallo", true},
+		{"h[ae]llo", "hillo", false},
+		{"h[^e]llo", "hallo", true},
+		{"h[^e]llo", "hello", false},
+		{"h[a-c]llo", "hbllo", true},
+		{"h[a-c]llo", "hdllo", false},
+		{`h\*llo`, "h*llo", true},
+		{`h\*llo`, "hello", false},
+		{"键:*", "键:值", true},
+		{"a*b*c", "aXXbYYc", true},
+		{"a*b*c", "aXXbYY", false},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.want, driver.MatchPattern(c.pattern, c.s), "%q ~ %q", c.pattern, c.s)
+	}
+
+	pattern := driver.EscapePattern("app:[v1]*") + "*"
+	assert.True(t, driver.MatchPattern(pattern, "app:[v1]*user"))
+	assert.False(t, driver.MatchPattern(pattern, "app:v-user"))
+}
@@ -0,0 +1,116 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// GetMany 使用 MGET 批量获取缓存，集群与 Ring 模式下使用 pipeline
+func (r *RedisDriver) GetMany(keys []string) (map[string]any, []string) {
+	found, missing, err := r.GetManyCtx(context.Background(), keys)
+	if err != nil {
+		return map[string]any{}, keys
+	}
+	return found, missing
+}
+
+// SetMany 使用 pipeline 批量写入缓存
+func (r *RedisDriver) SetMany(items map[string]any, d time.Duration) {
+	_ = r.SetManyCtx(context.Background(), items, d)
+}
+
+// DeleteMany 批量删除缓存
+func (r *RedisDriver) DeleteMany(keys []string) {
+	_ = r.DeleteManyCtx(context.Background(), keys)
+}
+
+// GetManyCtx GetMany 的 context 版本
+func (r *RedisDriver) GetManyCtx(ctx context.Context, keys []string) (map[string]any, []string, error) {
+	found := make(map[string]any, len(keys))
+	if len(keys) == 0 {
+		return found, nil, nil
+	}
+
+	prefixed := make([]string, len(keys))
+	for i, k := range keys {
+		prefixed[i] = r.key(k)
+	}
+	vals, err := r.mget(ctx, prefixed)
+	if err != nil {
+		return nil, nil, mapError(err)
+	}
+
+	var missing []string
+	for i, val := range vals {
+		s, ok := val.(string)
+		if !ok {
+			missing = append(missing, keys[i])
+			continue
+		}
+		v, err := r.decode([]byte(s))
+		if err != nil {
+			return nil, nil, err
+		}
+		found[keys[i]] = v
+	}
+	return found, missing, nil
+}
+
+// SetManyCtx SetMany 的 context 版本
+func (r *RedisDriver) SetManyCtx(ctx context.Context, items map[string]any, d time.Duration) error {
+	if len(items) == 0 {
+		return nil
+	}
+	ttl := r.expiration(d)
+	_, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for k, x := range items {
+			val, err := r.encode(x)
+			if err != nil {
+				return err
+			}
+			pipe.Set(ctx, r.key(k), val, ttl)
+		}
+		return nil
+	})
+	return mapError(err)
+}
+
+// DeleteManyCtx DeleteMany 的 context 版本
+func (r *RedisDriver) DeleteManyCtx(ctx context.Context, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	prefixed := make([]string, len(keys))
+	for i, k := range keys {
+		prefixed[i] = r.key(k)
+	}
+	return r.deleteKeys(ctx, prefixed)
+}
+
+// mget 批量读取已加上前缀的键，集群与 Ring 模式下使用 pipeline 逐个读取以避免跨槽错误
+func (r *RedisDriver) mget(ctx context.Context, keys []string) ([]any, error) {
+	if !r.sharded() {
+		return r.client.MGet(ctx, keys...).Result()
+	}
+
+	cmds := make([]*redis.StringCmd, len(keys))
+	_, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, k := range keys {
+			cmds[i] = pipe.Get(ctx, k)
+		}
+		return nil
+	})
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	vals := make([]any, len(keys))
+	for i, cmd := range cmds {
+		if val, err := cmd.Result(); err == nil {
+			vals[i] = val
+		}
+	}
+	return vals, nil
+}
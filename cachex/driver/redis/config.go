@@ -0,0 +1,137 @@
+package redis
+
+import (
+	"crypto/tls"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// 部署模式
+const (
+	// ModeStandalone 单节点模式
+	ModeStandalone = "standalone"
+	// ModeSentinel 哨兵模式，需要设置 MasterName，Addrs 为哨兵地址
+	ModeSentinel = "sentinel"
+	// ModeCluster 集群模式，Addrs 为集群种子节点地址
+	ModeCluster = "cluster"
+	// ModeRing Ring 模式，按键的哈希将数据分片到 Addrs 中的各个节点
+	ModeRing = "ring"
+)
+
+type RedisConfig struct {
+	// Addr 单节点地址，与 Addrs 同时设置时忽略
+	Addr string
+	// Addrs 节点地址列表：单节点地址、哨兵地址、集群种子节点或 Ring 分片地址
+	Addrs []string
+	// Mode 部署模式，为空时自动选择：设置了 MasterName 为哨兵模式，多个地址为集群模式，否则为单节点模式
+	Mode string
+	// MasterName 哨兵模式下的主节点名称
+	MasterName string
+
+	// Username ACL 用户名
+	Username string
+	Password string
+	// SentinelUsername 哨兵的 ACL 用户名
+	SentinelUsername string
+	// SentinelPassword 哨兵的密码
+	SentinelPassword string
+	// DB 数据库，集群模式下无效
+	DB int
+
+	// TLSConfig 不为 nil 时使用 TLS 连接
+	TLSConfig *tls.Config
+
+	// PoolSize 每个节点的最大连接数，0 表示使用 go-redis 默认值
+	PoolSize int
+	// MinIdleConns 每个节点的最小空闲连接数
+	MinIdleConns int
+	// DialTimeout 建立连接的超时时间
+	DialTimeout time.Duration
+	// ReadTimeout 读取超时时间
+	ReadTimeout time.Duration
+	// WriteTimeout 写入超时时间
+	WriteTimeout time.Duration
+	// PoolTimeout 从连接池获取连接的超时时间
+	PoolTimeout time.Duration
+
+	// Codec 值的编解码器名称，可选 json、gob、msgpack 或通过 driver.RegisterCodec 注册的名称
+	// 整数与浮点数不经过编解码器，以十进制文本保存，以便进行数值操作
+	// 为空时值直接交给 go-redis 格式化，仅支持基础类型
+	Codec string
+	// CompressThreshold 编码后超过该字节数时进行压缩，0 表示不压缩，仅在设置了 Codec 时生效
+	CompressThreshold int
+
+	// DefaultExpiration 过期时间参数为 0 时使用的默认过期时间，0 表示永不过期
+	DefaultExpiration time.Duration
+
+	// Prefix 键的命名空间前缀，所有键都会自动加上该前缀，设置后 Flush 只删除该前缀下的键
+	Prefix string
+
+	// ConfigureKeyspaceEvents 为 true 时 OnRemoval 会通过 CONFIG SET 开启删除通知需要的键空间通知
+	// 托管的 Redis 服务通常禁止 CONFIG 命令，此时需要在服务端配置 notify-keyspace-events
+	ConfigureKeyspaceEvents bool
+}
+
+// newClient 根据部署模式创建 go-redis 客户端
+func newClient(cfg *RedisConfig) (redis.UniversalClient, error) {
+	addrs := cfg.Addrs
+	if len(addrs) == 0 && cfg.Addr != "" {
+		addrs = []string{cfg.Addr}
+	}
+
+	opts := &redis.UniversalOptions{
+		Addrs:            addrs,
+		MasterName:       cfg.MasterName,
+		Username:         cfg.Username,
+		Password:         cfg.Password,
+		SentinelUsername: cfg.SentinelUsername,
+		SentinelPassword: cfg.SentinelPassword,
+		DB:               cfg.DB,
+		TLSConfig:        cfg.TLSConfig,
+		PoolSize:         cfg.PoolSize,
+		MinIdleConns:     cfg.MinIdleConns,
+		DialTimeout:      cfg.DialTimeout,
+		ReadTimeout:      cfg.ReadTimeout,
+		WriteTimeout:     cfg.WriteTimeout,
+		PoolTimeout:      cfg.PoolTimeout,
+		// 使用 ctx 的截止时间作为网络超时，使调用方的超时能够传递到 Redis 调用
+		ContextTimeoutEnabled: true,
+	}
+
+	switch cfg.Mode {
+	case "":
+		return redis.NewUniversalClient(opts), nil
+	case ModeStandalone:
+		return redis.NewClient(opts.Simple()), nil
+	case ModeSentinel:
+		if cfg.MasterName == "" {
+			return nil, fmt.Errorf("redis: sentinel mode requires MasterName")
+		}
+		return redis.NewFailoverClient(opts.Failover()), nil
+	case ModeCluster:
+		return redis.NewClusterClient(opts.Cluster()), nil
+	case ModeRing:
+		shards := make(map[string]string, len(addrs))
+		for i, addr := range addrs {
+			shards[fmt.Sprintf("shard%d", i)] = addr
+		}
+		return redis.NewRing(&redis.RingOptions{
+			Addrs:                 shards,
+			Username:              cfg.Username,
+			Password:              cfg.Password,
+			DB:                    cfg.DB,
+			TLSConfig:             cfg.TLSConfig,
+			PoolSize:              cfg.PoolSize,
+			MinIdleConns:          cfg.MinIdleConns,
+			DialTimeout:           cfg.DialTimeout,
+			ReadTimeout:           cfg.ReadTimeout,
+			WriteTimeout:          cfg.WriteTimeout,
+			PoolTimeout:           cfg.PoolTimeout,
+			ContextTimeoutEnabled: true,
+		}), nil
+	default:
+		return nil, fmt.Errorf("redis: unsupported mode %s", cfg.Mode)
+	}
+}
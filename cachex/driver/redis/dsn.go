@@ -0,0 +1,99 @@
+package redis
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/yu1ec/go-pkg/cachex/driver"
+)
+
+// ParseURL 将 DSN 解析为配置，格式为 redis://[[username]:password@]host[:port][/db][?参数]，rediss 协议使用 TLS
+// 支持的参数：addr（可重复，追加节点地址）、mode、master_name、sentinel_username、sentinel_password、
+// pool_size、min_idle_conns、dial_timeout、read_timeout、write_timeout、pool_timeout、
+// codec、compress_threshold、default_expiration、prefix、configure_keyspace_events
+// 时间参数支持 "5m" 形式或秒数，未知参数返回包装了 driver.ErrInvalidConfig 的错误
+func ParseURL(dsn string) (*RedisConfig, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, invalidURL("%v", err)
+	}
+	return parseURL(u)
+}
+
+func parseURL(u *url.URL) (*RedisConfig, error) {
+	if u.Scheme != "redis" && u.Scheme != "rediss" {
+		return nil, invalidURL("unsupported scheme %q", u.Scheme)
+	}
+
+	cfg := &RedisConfig{}
+	if u.Host != "" {
+		host, port := u.Hostname(), u.Port()
+		if host == "" {
+			host = "localhost"
+		}
+		if port == "" {
+			port = "6379"
+		}
+		cfg.Addrs = append(cfg.Addrs, net.JoinHostPort(host, port))
+	}
+	if u.User != nil {
+		cfg.Username = u.User.Username()
+		cfg.Password, _ = u.User.Password()
+	}
+	if db := strings.Trim(u.Path, "/"); db != "" {
+		var err error
+		if cfg.DB, err = strconv.Atoi(db); err != nil {
+			return nil, invalidURL("invalid db %q", db)
+		}
+	}
+	if u.Scheme == "rediss" {
+		cfg.TLSConfig = &tls.Config{ServerName: u.Hostname(), MinVersion: tls.VersionTLS12}
+	}
+
+	q := driver.NewURLQuery(u)
+	cfg.Addrs = append(cfg.Addrs, q.Values("addr")...)
+	cfg.Mode = q.String("mode")
+	cfg.MasterName = q.String("master_name")
+	cfg.SentinelUsername = q.String("sentinel_username")
+	cfg.SentinelPassword = q.String("sentinel_password")
+	cfg.PoolSize = q.Int("pool_size")
+	cfg.MinIdleConns = q.Int("min_idle_conns")
+	cfg.DialTimeout = q.Duration("dial_timeout")
+	cfg.ReadTimeout = q.Duration("read_timeout")
+	cfg.WriteTimeout = q.Duration("write_timeout")
+	cfg.PoolTimeout = q.Duration("pool_timeout")
+	cfg.Codec = q.String("codec")
+	cfg.CompressThreshold = q.Int("compress_threshold")
+	cfg.DefaultExpiration = q.Duration("default_expiration")
+	cfg.Prefix = q.String("prefix")
+	cfg.ConfigureKeyspaceEvents = q.Bool("configure_keyspace_events")
+	if err := q.Err(); err != nil {
+		return nil, fmt.Errorf("redis: %w", err)
+	}
+	if len(cfg.Addrs) == 0 {
+		return nil, invalidURL("missing address")
+	}
+	if cfg.Codec != "" {
+		if _, err := driver.LookupCodec(cfg.Codec); err != nil {
+			return nil, invalidURL("%v", err)
+		}
+	}
+	return cfg, nil
+}
+
+// invalidURL 返回包装了 driver.ErrInvalidConfig 的错误
+func invalidURL(format string, args ...any) error {
+	return fmt.Errorf("redis: %w: %s", driver.ErrInvalidConfig, fmt.Sprintf(format, args...))
+}
+
+func openURL(u *url.URL) (driver.Driver, error) {
+	cfg, err := parseURL(u)
+	if err != nil {
+		return nil, err
+	}
+	return New(cfg)
+}
@@ -0,0 +1,50 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/yu1ec/go-pkg/cachex/driver"
+)
+
+// errPoolTimeout go-redis 连接池超时的错误信息，go-redis 未导出该错误
+const errPoolTimeout = "redis: connection pool timeout"
+
+// mapError 将 go-redis 返回的错误转换为 driver 包中定义的错误
+// redis.Nil 转换为 driver.ErrCacheMiss，数值溢出与类型错误分别包装为 driver.ErrOverflow 与 driver.ErrNotNumeric，连接类错误包装为 driver.ErrUnavailable，
+// ctx 取消或超时原样返回，其余错误（如类型错误）原样返回
+func mapError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, redis.Nil):
+		return driver.ErrCacheMiss
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return err
+	case strings.Contains(err.Error(), "would overflow"), strings.Contains(err.Error(), "NaN or Infinity"):
+		// INCRBY/DECRBY 的结果超出 64 位有符号整数的范围，或 INCRBYFLOAT 的结果为无穷大
+		return fmt.Errorf("%w: %w", driver.ErrOverflow, err)
+	case strings.Contains(err.Error(), "not an integer"), strings.Contains(err.Error(), "not a valid float"):
+		return fmt.Errorf("%w: %w", driver.ErrNotNumeric, err)
+	case isUnavailable(err):
+		return fmt.Errorf("%w: %w", driver.ErrUnavailable, err)
+	default:
+		return err
+	}
+}
+
+func isUnavailable(err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	return errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, redis.ErrClosed) ||
+		err.Error() == errPoolTimeout
+}
@@ -0,0 +1,135 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/yu1ec/go-pkg/cachex/driver"
+)
+
+// keyspaceEvents 删除通知需要的键空间通知类型：E 键事件，g 通用命令（DEL、UNLINK），x 过期，e 淘汰
+const keyspaceEvents = "Egxe"
+
+// removalReasons 键事件名称对应的删除原因
+var removalReasons = map[string]driver.RemovalReason{
+	"del":     driver.RemovalDeleted,
+	"expired": driver.RemovalExpired,
+	"evicted": driver.RemovalEvicted,
+}
+
+// OnRemoval 实现 driver.Notifier 接口，基于 Redis 的键空间通知，事件中不包含值
+// 需要服务端开启 notify-keyspace-events（至少包含 Egxe），设置了 RedisConfig.ConfigureKeyspaceEvents 时会自动开启
+// 集群与 Ring 模式下在注册时的每个主节点上订阅；FLUSHDB 不会触发删除事件
+func (r *RedisDriver) OnRemoval(fn func(driver.RemovalEvent)) (func(), error) {
+	ctx := context.Background()
+	nodes, err := r.subscribeNodes(ctx)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	var subs []*redis.PubSub
+	cancel := func() {
+		for _, sub := range subs {
+			sub.Close()
+		}
+	}
+	for _, node := range nodes {
+		if r.configureKeyspaceEvents {
+			if err := enableKeyspaceEvents(ctx, node); err != nil {
+				cancel()
+				return nil, err
+			}
+		}
+
+		db := 0
+		if c, ok := node.(*redis.Client); ok {
+			db = c.Options().DB
+		}
+		channels := make([]string, 0, len(removalReasons))
+		for event := range removalReasons {
+			channels = append(channels, fmt.Sprintf("__keyevent@%d__:%s", db, event))
+		}
+
+		sub := node.Subscribe(ctx, channels...)
+		// 等待订阅确认，保证返回后不会错过事件
+		if _, err := sub.Receive(ctx); err != nil {
+			sub.Close()
+			cancel()
+			return nil, mapError(err)
+		}
+		subs = append(subs, sub)
+	}
+
+	for _, sub := range subs {
+		go r.dispatchRemovals(sub, fn)
+	}
+
+	var once sync.Once
+	return func() { once.Do(cancel) }, nil
+}
+
+// subscribeNodes 返回需要订阅键空间通知的节点，键空间通知只在键所在的节点上发布
+func (r *RedisDriver) subscribeNodes(ctx context.Context) ([]redis.UniversalClient, error) {
+	var (
+		mu    sync.Mutex
+		nodes []redis.UniversalClient
+	)
+	collect := func(ctx context.Context, node *redis.Client) error {
+		mu.Lock()
+		nodes = append(nodes, node)
+		mu.Unlock()
+		return nil
+	}
+
+	var err error
+	switch c := r.client.(type) {
+	case *redis.ClusterClient:
+		err = c.ForEachMaster(ctx, collect)
+	case *redis.Ring:
+		err = c.ForEachShard(ctx, collect)
+	default:
+		nodes = append(nodes, r.client)
+	}
+	return nodes, err
+}
+
+func (r *RedisDriver) dispatchRemovals(sub *redis.PubSub, fn func(driver.RemovalEvent)) {
+	for msg := range sub.Channel() {
+		reason, ok := removalReasons[msg.Channel[strings.LastIndex(msg.Channel, ":")+1:]]
+		if !ok || !strings.HasPrefix(msg.Payload, r.prefix) {
+			continue
+		}
+		k := strings.TrimPrefix(msg.Payload, r.prefix)
+		// 跳过驱动内部使用的锁与标签键
+		if strings.HasPrefix(k, lockKeyPrefix) || strings.HasPrefix(k, tagKeyPrefix) {
+			continue
+		}
+		fn(driver.RemovalEvent{Key: k, Reason: reason})
+	}
+}
+
+// enableKeyspaceEvents 在现有配置的基础上补充删除通知需要的类型
+func enableKeyspaceEvents(ctx context.Context, node redis.UniversalClient) error {
+	current, err := node.ConfigGet(ctx, "notify-keyspace-events").Result()
+	if err != nil {
+		return mapError(err)
+	}
+	flags := current["notify-keyspace-events"]
+	// A 是 g$lshzxetd 的别名，已包含所需的类型
+	if !strings.Contains(flags, "A") {
+		for _, c := range keyspaceEvents {
+			if !strings.ContainsRune(flags, c) {
+				flags += string(c)
+			}
+		}
+	} else if !strings.Contains(flags, "E") {
+		flags += "E"
+	}
+	if flags == current["notify-keyspace-events"] {
+		return nil
+	}
+	return mapError(node.ConfigSet(ctx, "notify-keyspace-events", flags).Err())
+}
@@ -0,0 +1,200 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/yu1ec/go-pkg/cachex/driver"
+)
+
+// incrScript 键存在时执行 ARGV[2]（INCRBY 或 DECRBY），ARGV[3] 为 1 时结果不能为负数，否则撤销并返回溢出错误
+// 键不存在时返回 nil；结果以字符串返回，避免 Lua 数值丢失精度
+var incrScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return false
+end
+local v = redis.call(ARGV[2], KEYS[1], ARGV[1])
+if ARGV[3] == "1" and v < 0 then
+	redis.call(ARGV[2] == "INCRBY" and "DECRBY" or "INCRBY", KEYS[1], ARGV[1])
+	return redis.error_reply("ERR increment or decrement would overflow")
+end
+return redis.call("GET", KEYS[1])
+`)
+
+// incrFloatScript 键存在时执行 INCRBYFLOAT，键不存在时返回 nil；结果超出 float64 的范围时恢复原值并返回错误
+var incrFloatScript = redis.NewScript(`
+local old = redis.call("GET", KEYS[1])
+if not old then
+	return false
+end
+local v = redis.call("INCRBYFLOAT", KEYS[1], ARGV[1])
+local f = tonumber(v)
+if f == nil or f == math.huge or f == -math.huge then
+	redis.call("SET", KEYS[1], old, "KEEPTTL")
+	return redis.error_reply("ERR increment would produce NaN or Infinity")
+end
+return v
+`)
+
+// counterScript 执行 INCRBY，键原本不存在且 ARGV[2] 大于 0 时设置 ARGV[2] 毫秒的过期时间
+var counterScript = redis.NewScript(`
+local created = redis.call("EXISTS", KEYS[1]) == 0
+redis.call("INCRBY", KEYS[1], ARGV[1])
+if created and tonumber(ARGV[2]) > 0 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return redis.call("GET", KEYS[1])
+`)
+
+// incr 以原子方式增减整数值，键不存在时返回 driver.ErrCacheMiss
+// unsigned 为 true 时结果不能为负数；Redis 的整数为 64 位有符号整数，无符号值也受此范围限制
+func (r *RedisDriver) incr(ctx context.Context, k string, n int64, decrement, unsigned bool) (int64, error) {
+	cmd, flag := "INCRBY", "0"
+	if decrement {
+		cmd = "DECRBY"
+	}
+	if unsigned {
+		flag = "1"
+	}
+	s, err := incrScript.Run(ctx, r.client, []string{r.key(k)}, n, cmd, flag).Text()
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", mapError(err), k)
+	}
+	return strconv.ParseInt(s, 10, 64)
+}
+
+// incrUnsigned 增减无符号整数值，n 超出 Redis 整数的范围时返回 driver.ErrOverflow
+func (r *RedisDriver) incrUnsigned(ctx context.Context, k string, n uint64, decrement bool) (uint64, error) {
+	if n > math.MaxInt64 {
+		return 0, fmt.Errorf("%w: %s", driver.ErrOverflow, k)
+	}
+	v, err := r.incr(ctx, k, int64(n), decrement, true)
+	return uint64(v), err
+}
+
+// incrInt 增减 int 值，结果超出 int 的范围（32 位平台）时返回 driver.ErrOverflow
+func (r *RedisDriver) incrInt(ctx context.Context, k string, n int, decrement bool) (int, error) {
+	v, err := r.incr(ctx, k, int64(n), decrement, false)
+	if err != nil {
+		return 0, err
+	}
+	if int64(int(v)) != v {
+		// 撤销本次操作
+		_, _ = r.incr(ctx, k, int64(n), !decrement, false)
+		return 0, fmt.Errorf("%w: %s", driver.ErrOverflow, k)
+	}
+	return int(v), nil
+}
+
+// 实现 NumericOperations 接口，需要取消或超时控制时使用对应的 Ctx 版本
+func (r *RedisDriver) IncrementInt(k string, n int) (int, error) {
+	return r.IncrementIntCtx(context.Background(), k, n)
+}
+
+func (r *RedisDriver) DecrementInt(k string, n int) (int, error) {
+	return r.DecrementIntCtx(context.Background(), k, n)
+}
+
+func (r *RedisDriver) IncrementInt64(k string, n int64) (int64, error) {
+	return r.IncrementInt64Ctx(context.Background(), k, n)
+}
+
+func (r *RedisDriver) DecrementInt64(k string, n int64) (int64, error) {
+	return r.DecrementInt64Ctx(context.Background(), k, n)
+}
+
+func (r *RedisDriver) IncrementUint(k string, n uint) (uint, error) {
+	return r.IncrementUintCtx(context.Background(), k, n)
+}
+
+func (r *RedisDriver) DecrementUint(k string, n uint) (uint, error) {
+	return r.DecrementUintCtx(context.Background(), k, n)
+}
+
+func (r *RedisDriver) IncrementUint64(k string, n uint64) (uint64, error) {
+	return r.IncrementUint64Ctx(context.Background(), k, n)
+}
+
+func (r *RedisDriver) DecrementUint64(k string, n uint64) (uint64, error) {
+	return r.DecrementUint64Ctx(context.Background(), k, n)
+}
+
+// IncrementIntCtx IncrementInt 的 context 版本
+func (r *RedisDriver) IncrementIntCtx(ctx context.Context, k string, n int) (int, error) {
+	return r.incrInt(ctx, k, n, false)
+}
+
+// DecrementIntCtx DecrementInt 的 context 版本
+func (r *RedisDriver) DecrementIntCtx(ctx context.Context, k string, n int) (int, error) {
+	return r.incrInt(ctx, k, n, true)
+}
+
+// IncrementInt64Ctx IncrementInt64 的 context 版本
+func (r *RedisDriver) IncrementInt64Ctx(ctx context.Context, k string, n int64) (int64, error) {
+	return r.incr(ctx, k, n, false, false)
+}
+
+// DecrementInt64Ctx DecrementInt64 的 context 版本
+func (r *RedisDriver) DecrementInt64Ctx(ctx context.Context, k string, n int64) (int64, error) {
+	return r.incr(ctx, k, n, true, false)
+}
+
+// IncrementUintCtx IncrementUint 的 context 版本
+func (r *RedisDriver) IncrementUintCtx(ctx context.Context, k string, n uint) (uint, error) {
+	v, err := r.incrUnsigned(ctx, k, uint64(n), false)
+	if err == nil && uint64(uint(v)) != v {
+		_, _ = r.incrUnsigned(ctx, k, uint64(n), true)
+		return 0, fmt.Errorf("%w: %s", driver.ErrOverflow, k)
+	}
+	return uint(v), err
+}
+
+// DecrementUintCtx DecrementUint 的 context 版本
+func (r *RedisDriver) DecrementUintCtx(ctx context.Context, k string, n uint) (uint, error) {
+	v, err := r.incrUnsigned(ctx, k, uint64(n), true)
+	return uint(v), err
+}
+
+// IncrementUint64Ctx IncrementUint64 的 context 版本
+func (r *RedisDriver) IncrementUint64Ctx(ctx context.Context, k string, n uint64) (uint64, error) {
+	return r.incrUnsigned(ctx, k, n, false)
+}
+
+// DecrementUint64Ctx DecrementUint64 的 context 版本
+func (r *RedisDriver) DecrementUint64Ctx(ctx context.Context, k string, n uint64) (uint64, error) {
+	return r.incrUnsigned(ctx, k, n, true)
+}
+
+// IncrementFloat64 实现 driver.FloatOperations 接口，使用 INCRBYFLOAT
+func (r *RedisDriver) IncrementFloat64(k string, n float64) (float64, error) {
+	return r.IncrementFloat64Ctx(context.Background(), k, n)
+}
+
+// IncrementFloat64Ctx IncrementFloat64 的 context 版本
+func (r *RedisDriver) IncrementFloat64Ctx(ctx context.Context, k string, n float64) (float64, error) {
+	if math.IsInf(n, 0) || math.IsNaN(n) {
+		return 0, fmt.Errorf("%w: %s", driver.ErrOverflow, k)
+	}
+	s, err := incrFloatScript.Run(ctx, r.client, []string{r.key(k)}, n).Text()
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", mapError(err), k)
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+// IncrementInt64WithTTL 实现 driver.Counter 接口，在服务端通过 Lua 脚本原子地递增并为新键设置过期时间
+func (r *RedisDriver) IncrementInt64WithTTL(ctx context.Context, k string, n int64, d time.Duration) (int64, error) {
+	var ms int64
+	if d = r.expiration(d); d > 0 {
+		ms = max(d.Milliseconds(), 1)
+	}
+	s, err := counterScript.Run(ctx, r.client, []string{r.key(k)}, n, ms).Text()
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", mapError(err), k)
+	}
+	return strconv.ParseInt(s, 10, 64)
+}
@@ -1,8 +1,15 @@
 package redis
 
 import (
+	"bytes"
 	"context"
+	"encoding"
+	"encoding/gob"
+	"encoding/json"
 	"fmt"
+	"math"
+	"reflect"
+	"strconv"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -11,54 +18,150 @@ import (
 
 func init() {
 	driver.Register("redis", New)
+	driver.RegisterURL("redis", openURL)
+	driver.RegisterURL("rediss", openURL)
+
+	// 值以 any 的形式编码，gob 需要注册接口中可能出现的复合类型，基础类型已由 gob 预先注册
+	gob.Register(map[string]any{})
+	gob.Register([]any{})
 }
 
+// lockKeyPrefix 分布式锁键的前缀
+const lockKeyPrefix = "cachex:lock:"
+
+// unlockScript 仅当锁仍由 ARGV[1] 持有时才删除，避免误删其他实例的锁
+var unlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// extendScript 仅当锁由 ARGV[1] 持有时将过期时间重置为 ARGV[2] 毫秒
+var extendScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
 type RedisDriver struct {
-	ctx    context.Context
-	client *redis.Client
-}
+	client     redis.UniversalClient
+	serializer *driver.Serializer
+	prefix     string
 
-type RedisConfig struct {
-	Addr     string
-	Password string
-	DB       int
+	defaultExpiration       time.Duration
+	configureKeyspaceEvents bool
 }
 
 func New(config any) (driver.Driver, error) {
 	cfg, ok := config.(*RedisConfig)
 	if !ok {
-		return nil, fmt.Errorf("invalid config for redis cache")
+		return nil, fmt.Errorf("%w: invalid config for redis cache", driver.ErrInvalidConfig)
 	}
 
-	ctx := context.Background()
-	client := redis.NewClient(&redis.Options{
-		Addr:     cfg.Addr,
-		Password: cfg.Password,
-		DB:       cfg.DB,
-	})
+	client, err := newClient(cfg)
+	if err != nil {
+		return nil, err
+	}
 
 	// 检查连接是否成功
-	if err := client.Ping(ctx).Err(); err != nil {
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		client.Close()
+		return nil, mapError(err)
+	}
+
+	d, err := NewWithClient(client, cfg)
+	if err != nil {
+		client.Close()
 		return nil, err
 	}
+	return d, nil
+}
+
+// NewWithClient 使用已有的 go-redis 客户端创建驱动，客户端可以是单节点、哨兵、集群或 Ring 客户端
+// cfg 中仅 Codec、CompressThreshold、DefaultExpiration、Prefix 与 ConfigureKeyspaceEvents 生效，可以为 nil
+func NewWithClient(client redis.UniversalClient, cfg *RedisConfig) (*RedisDriver, error) {
+	if cfg == nil {
+		cfg = &RedisConfig{}
+	}
+
+	var serializer *driver.Serializer
+	if cfg.Codec != "" {
+		codec, err := driver.LookupCodec(cfg.Codec)
+		if err != nil {
+			return nil, err
+		}
+		serializer = &driver.Serializer{Codec: codec, CompressThreshold: cfg.CompressThreshold}
+	}
 
-	return &RedisDriver{ctx: ctx, client: client}, nil
+	return &RedisDriver{
+		client:                  client,
+		serializer:              serializer,
+		prefix:                  cfg.Prefix,
+		defaultExpiration:       cfg.DefaultExpiration,
+		configureKeyspaceEvents: cfg.ConfigureKeyspaceEvents,
+	}, nil
 }
 
-// 实现 BaseDriver 接口
-func (r *RedisDriver) Add(k string, v any, d time.Duration) error {
-	success, err := r.client.SetNX(r.ctx, k, v, d).Result()
-	if err != nil {
-		return err
+// Client 返回底层的 go-redis 客户端，用于发布订阅等驱动未封装的操作
+// 通过客户端直接访问时键不会自动加上 Prefix
+func (r *RedisDriver) Client() redis.UniversalClient {
+	return r.client
+}
+
+// Ping 实现 driver.Pinger 接口，集群与 Ring 模式下检查每个节点
+func (r *RedisDriver) Ping(ctx context.Context) error {
+	return mapError(r.forEachNode(ctx, func(ctx context.Context, node redis.Cmdable) error {
+		return node.Ping(ctx).Err()
+	}))
+}
+
+// Close 关闭底层的 go-redis 客户端，通过 NewWithClient 共享客户端时不应调用
+func (r *RedisDriver) Close() error {
+	return r.client.Close()
+}
+
+// RunScript 在驱动的客户端上执行 Lua 脚本，keys 会加上 Prefix，返回的错误与驱动的其他操作一样经过转换
+// 如连接类错误包装为 driver.ErrUnavailable
+func (r *RedisDriver) RunScript(ctx context.Context, script *redis.Script, keys []string, args ...any) *redis.Cmd {
+	prefixed := make([]string, len(keys))
+	for i, k := range keys {
+		prefixed[i] = r.key(k)
 	}
-	if !success {
-		return fmt.Errorf("key already exists")
+	cmd := script.Run(ctx, r.client, prefixed, args...)
+	cmd.SetErr(mapError(cmd.Err()))
+	return cmd
+}
+
+// Prefix 返回键的命名空间前缀
+func (r *RedisDriver) Prefix() string {
+	return r.prefix
+}
+
+func (r *RedisDriver) key(k string) string {
+	return r.prefix + k
+}
+
+// expiration 将过期时间参数转换为 go-redis 的过期时间：0 使用默认过期时间，负数表示永不过期
+// go-redis 中 0 表示永不过期，-1 表示保留原有的过期时间（KEEPTTL），因此负数统一转换为 0
+func (r *RedisDriver) expiration(d time.Duration) time.Duration {
+	if d == 0 {
+		d = r.defaultExpiration
 	}
-	return nil
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+// 实现 BaseDriver 接口
+func (r *RedisDriver) Add(k string, v any, d time.Duration) error {
+	return r.AddCtx(context.Background(), k, v, d)
 }
 
 func (r *RedisDriver) Delete(k string) {
-	r.client.Del(r.ctx, k)
+	_ = r.DeleteCtx(context.Background(), k)
 }
 
 func (r *RedisDriver) DeleteExpired() {
@@ -66,82 +169,314 @@ func (r *RedisDriver) DeleteExpired() {
 }
 
 func (r *RedisDriver) Flush() {
-	r.client.FlushAll(r.ctx)
+	_ = r.FlushCtx(context.Background())
 }
 
 func (r *RedisDriver) Get(k string) (any, bool) {
-	val, err := r.client.Get(r.ctx, k).Result()
-	if err == redis.Nil {
-		return nil, false
-	}
+	val, err := r.GetCtx(context.Background(), k)
 	return val, err == nil
 }
 
 func (r *RedisDriver) GetWithExpiration(k string) (any, time.Time, bool) {
-	val, err := r.client.Get(r.ctx, k).Result()
-	if err == redis.Nil {
-		return nil, time.Time{}, false
+	val, expiration, err := r.GetWithExpirationCtx(context.Background(), k)
+	return val, expiration, err == nil
+}
+
+func (r *RedisDriver) Replace(k string, x any, d time.Duration) error {
+	return r.ReplaceCtx(context.Background(), k, x, d)
+}
+
+func (r *RedisDriver) Set(k string, x any, d time.Duration) {
+	_ = r.SetCtx(context.Background(), k, x, d)
+}
+
+func (r *RedisDriver) SetDefault(k string, x any) {
+	_ = r.SetDefaultCtx(context.Background(), k, x)
+}
+
+// 实现 ContextDriver 接口
+func (r *RedisDriver) AddCtx(ctx context.Context, k string, v any, d time.Duration) error {
+	val, err := r.encode(v)
+	if err != nil {
+		return err
+	}
+	success, err := r.client.SetNX(ctx, r.key(k), val, r.expiration(d)).Result()
+	if err != nil {
+		return mapError(err)
+	}
+	if !success {
+		return fmt.Errorf("%w: %s", driver.ErrKeyExists, k)
+	}
+	return nil
+}
+
+func (r *RedisDriver) DeleteCtx(ctx context.Context, k string) error {
+	return mapError(r.client.Del(ctx, r.key(k)).Err())
+}
+
+// FlushCtx 未设置 Prefix 时清空当前数据库，设置了 Prefix 时只删除该前缀下的键
+func (r *RedisDriver) FlushCtx(ctx context.Context) error {
+	if r.prefix == "" {
+		return mapError(r.forEachNode(ctx, func(ctx context.Context, node redis.Cmdable) error {
+			return node.FlushDB(ctx).Err()
+		}))
+	}
+	return r.deleteMatch(ctx, driver.EscapePattern(r.prefix)+"*")
+}
+
+// FlushPrefix 实现 driver.PrefixFlusher 接口，删除以 prefix 开头的键
+func (r *RedisDriver) FlushPrefix(ctx context.Context, prefix string) error {
+	return r.deleteMatch(ctx, driver.EscapePattern(r.key(prefix))+"*")
+}
+
+func (r *RedisDriver) GetCtx(ctx context.Context, k string) (any, error) {
+	data, err := r.client.Get(ctx, r.key(k)).Bytes()
+	if err != nil {
+		return nil, mapError(err)
 	}
+	return r.decode(data)
+}
+
+func (r *RedisDriver) GetWithExpirationCtx(ctx context.Context, k string) (any, time.Time, error) {
+	data, err := r.client.Get(ctx, r.key(k)).Bytes()
+	if err != nil {
+		return nil, time.Time{}, mapError(err)
+	}
+	val, err := r.decode(data)
 	if err != nil {
-		return nil, time.Time{}, false
+		return nil, time.Time{}, err
 	}
-	ttl, err := r.client.TTL(r.ctx, k).Result()
+	ttl, err := r.client.PTTL(ctx, r.key(k)).Result()
 	if err != nil {
-		return val, time.Time{}, true
+		return nil, time.Time{}, mapError(err)
 	}
 	if ttl < 0 {
-		return val, time.Time{}, true
+		return val, time.Time{}, nil
 	}
-	return val, time.Now().Add(ttl), true
+	return val, time.Now().Add(ttl), nil
 }
 
-func (r *RedisDriver) Replace(k string, x any, d time.Duration) error {
-	_, err := r.client.Get(r.ctx, k).Result()
-	if err == redis.Nil {
-		return fmt.Errorf("key not found")
+func (r *RedisDriver) ReplaceCtx(ctx context.Context, k string, x any, d time.Duration) error {
+	val, err := r.encode(x)
+	if err != nil {
+		return err
+	}
+	replaced, err := r.client.SetXX(ctx, r.key(k), val, r.expiration(d)).Result()
+	if err != nil {
+		return mapError(err)
 	}
-	return r.client.Set(r.ctx, k, x, d).Err()
+	if !replaced {
+		return fmt.Errorf("%w: %s", driver.ErrCacheMiss, k)
+	}
+	return nil
 }
 
-func (r *RedisDriver) Set(k string, x any, d time.Duration) {
-	r.client.Set(r.ctx, k, x, d)
+func (r *RedisDriver) SetCtx(ctx context.Context, k string, x any, d time.Duration) error {
+	val, err := r.encode(x)
+	if err != nil {
+		return err
+	}
+	return mapError(r.client.Set(ctx, r.key(k), val, r.expiration(d)).Err())
 }
 
-func (r *RedisDriver) SetDefault(k string, x any) {
-	r.client.Set(r.ctx, k, x, 0)
+func (r *RedisDriver) SetDefaultCtx(ctx context.Context, k string, x any) error {
+	return r.SetCtx(ctx, k, x, 0)
+}
+
+// GetInto 实现 driver.ValueDecoder 接口，使用配置的编解码器将值解码到 v 中
+// 未配置编解码器时 v 只能是 *string 或 *[]byte
+func (r *RedisDriver) GetInto(ctx context.Context, k string, v any) error {
+	data, err := r.client.Get(ctx, r.key(k)).Bytes()
+	if err != nil {
+		return mapError(err)
+	}
+	if r.serializer != nil {
+		return r.unmarshal(data, v)
+	}
+
+	switch p := v.(type) {
+	case *string:
+		*p = string(data)
+	case *[]byte:
+		*p = data
+	default:
+		return fmt.Errorf("redis: decoding into %T requires a codec", v)
+	}
+	return nil
+}
+
+// encode 使用配置的编解码器编码值，未配置时原样交给 go-redis
+// 与 file 驱动相同，值以 any 的形式编码，gob 等编解码器因此能够解码到 any；
+// 未通过 gob.Register 注册的类型无法以 any 编码，此时按具体类型编码，只能通过 GetInto 读取
+// 整数与浮点数不经过编解码器与压缩，以十进制文本保存，使 INCRBY、INCRBYFLOAT 在任何编解码器下都可以处理
+// 编解码器的输出可能被误认为十进制文本时加上 codecMagic 前缀
+func (r *RedisDriver) encode(v any) (any, error) {
+	if r.serializer == nil {
+		return v, nil
+	}
+	if s, ok := formatNumber(v); ok {
+		return s, nil
+	}
+	data, err := r.serializer.Marshal(&v)
+	if err != nil {
+		if data, err = r.serializer.Marshal(v); err != nil {
+			return nil, fmt.Errorf("redis: encode value: %w", err)
+		}
+	}
+	if _, ok := r.parseNumber(data); ok || bytes.HasPrefix(data, codecMagic) {
+		return append(append([]byte(nil), codecMagic...), data...), nil
+	}
+	return data, nil
 }
 
-// 实现 NumericOperations 接口
-func (r *RedisDriver) IncrementInt(k string, n int) (int, error) {
-	return int(r.client.IncrBy(r.ctx, k, int64(n)).Val()), nil
+// decode 使用配置的编解码器解码值，未配置时返回字符串
+func (r *RedisDriver) decode(data []byte) (any, error) {
+	if r.serializer == nil {
+		return string(data), nil
+	}
+	data, n, ok := r.split(data)
+	if ok {
+		return n, nil
+	}
+	var v any
+	if err := r.serializer.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("redis: decode value: %w", err)
+	}
+	return v, nil
 }
 
-func (r *RedisDriver) DecrementInt(k string, n int) (int, error) {
-	return int(r.client.DecrBy(r.ctx, k, int64(n)).Val()), nil
+// unmarshal 将 data 解码到指针 v 中，gob 以 any 编码的值无法直接解码到具体类型，此时先解码到 any 再赋值
+func (r *RedisDriver) unmarshal(data []byte, v any) error {
+	payload, n, ok := r.split(data)
+	if ok {
+		if p, ok := v.(*any); ok {
+			*p = n
+			return nil
+		}
+		return json.Unmarshal(data, v)
+	}
+	data = payload
+	err := r.serializer.Unmarshal(data, v)
+	if err == nil {
+		return nil
+	}
+	var x any
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || r.serializer.Unmarshal(data, &x) != nil {
+		return err
+	}
+	if x == nil {
+		rv.Elem().SetZero()
+		return nil
+	}
+	xv := reflect.ValueOf(x)
+	if !xv.Type().AssignableTo(rv.Elem().Type()) {
+		return fmt.Errorf("redis: cannot decode %T into %T", x, v)
+	}
+	rv.Elem().Set(xv)
+	return nil
 }
 
-func (r *RedisDriver) IncrementInt64(k string, n int64) (int64, error) {
-	return r.client.IncrBy(r.ctx, k, n).Result()
+// codecMagic 编解码器的输出可能被误认为十进制文本保存的数值时加上的前缀，
+// 例如 msgpack 将 48 到 57 的整数编码为单个字节，恰好是 '0' 到 '9'；以该前缀开头的输出同样需要加上前缀
+var codecMagic = []byte("\x00cv")
+
+// split 区分十进制文本保存的数值与编解码器的输出，数值时返回解码后的数值与 true，
+// 否则返回去掉 codecMagic 前缀后交给编解码器的数据
+func (r *RedisDriver) split(data []byte) ([]byte, any, bool) {
+	if bytes.HasPrefix(data, codecMagic) {
+		return data[len(codecMagic):], nil, false
+	}
+	if n, ok := r.parseNumber(data); ok {
+		return data, n, true
+	}
+	return data, nil, false
 }
 
-func (r *RedisDriver) DecrementInt64(k string, n int64) (int64, error) {
-	return r.client.DecrBy(r.ctx, k, n).Result()
+// formatNumber 将整数与有限的浮点数格式化为十进制文本，按 reflect.Kind 判断，因此 time.Duration 等
+// 具名数值类型同样以十进制文本保存；自定义了 JSON、文本或 gob 编码的类型仍然交给编解码器
+func formatNumber(v any) (string, bool) {
+	switch v.(type) {
+	case json.Marshaler, encoding.TextMarshaler, gob.GobEncoder:
+		return "", false
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10), true
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			return "", false
+		}
+		return strconv.FormatFloat(f, 'f', -1, rv.Type().Bits()), true
+	}
+	return "", false
 }
 
-func (r *RedisDriver) IncrementUint(k string, n uint) (uint, error) {
-	val, err := r.client.IncrBy(r.ctx, k, int64(n)).Uint64()
-	return uint(val), err
+// parseNumber 识别以十进制文本保存的数值，整数解码为 int64（超出范围时为 uint64），其他为 float64
+// JSON 编码的数值与十进制文本相同，交给 JSON 解码以保持数值解码为 float64 的行为
+func (r *RedisDriver) parseNumber(data []byte) (any, bool) {
+	if r.serializer.Codec == driver.JSONCodec || len(data) == 0 || len(data) > 64 {
+		return nil, false
+	}
+	if c := data[0]; c != '-' && (c < '0' || c > '9') {
+		return nil, false
+	}
+	for _, c := range data {
+		if (c < '0' || c > '9') && c != '-' && c != '+' && c != '.' && c != 'e' && c != 'E' {
+			return nil, false
+		}
+	}
+	s := string(data)
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i, true
+	}
+	if u, err := strconv.ParseUint(s, 10, 64); err == nil {
+		return u, true
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f, true
+	}
+	return nil, false
+}
+
+// TryLock 实现 driver.Locker 接口，使用 SET NX PX 获取锁，释放时校验持有者
+func (r *RedisDriver) TryLock(ctx context.Context, k string, ttl time.Duration) (func(), bool, error) {
+	token, err := driver.NewLockToken()
+	if err != nil {
+		return nil, false, err
+	}
+
+	lockKey := lockKeyPrefix + k
+	ok, err := r.AcquireLock(ctx, lockKey, token, ttl)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+
+	unlock := func() {
+		// 释放锁不受调用方 ctx 取消的影响，避免锁残留到过期
+		_, _ = r.ReleaseLock(context.Background(), lockKey, token)
+	}
+	return unlock, true, nil
 }
 
-func (r *RedisDriver) DecrementUint(k string, n uint) (uint, error) {
-	val, err := r.client.DecrBy(r.ctx, k, int64(n)).Uint64()
-	return uint(val), err
+// AcquireLock 实现 driver.TokenLocker 接口，使用 SET NX PX 获取锁，k 会加上 Prefix
+func (r *RedisDriver) AcquireLock(ctx context.Context, k, token string, ttl time.Duration) (bool, error) {
+	ok, err := r.client.SetNX(ctx, r.key(k), token, ttl).Result()
+	return ok, mapError(err)
 }
 
-func (r *RedisDriver) IncrementUint64(k string, n uint64) (uint64, error) {
-	return r.client.IncrBy(r.ctx, k, int64(n)).Uint64()
+// ExtendLock 实现 driver.TokenLocker 接口
+func (r *RedisDriver) ExtendLock(ctx context.Context, k, token string, ttl time.Duration) (bool, error) {
+	n, err := extendScript.Run(ctx, r.client, []string{r.key(k)}, token, max(ttl.Milliseconds(), 1)).Int()
+	return n == 1, mapError(err)
 }
 
-func (r *RedisDriver) DecrementUint64(k string, n uint64) (uint64, error) {
-	return r.client.DecrBy(r.ctx, k, int64(n)).Uint64()
+// ReleaseLock 实现 driver.TokenLocker 接口
+func (r *RedisDriver) ReleaseLock(ctx context.Context, k, token string) (bool, error) {
+	n, err := unlockScript.Run(ctx, r.client, []string{r.key(k)}, token).Int()
+	return n == 1, mapError(err)
 }
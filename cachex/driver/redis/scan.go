@@ -0,0 +1,172 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/yu1ec/go-pkg/cachex/driver"
+)
+
+// scanCount 每次 SCAN 期望返回的键数量，同时也是每批删除的键数量
+const scanCount = 500
+
+// sharded 判断客户端是否会把不同的键路由到不同节点，此时多键命令需要拆分为单键命令
+func (r *RedisDriver) sharded() bool {
+	switch r.client.(type) {
+	case *redis.ClusterClient, *redis.Ring:
+		return true
+	}
+	return false
+}
+
+// forEachNode 在每个主节点（集群）或分片（Ring）上执行 fn，其他客户端直接在客户端上执行
+func (r *RedisDriver) forEachNode(ctx context.Context, fn func(ctx context.Context, node redis.Cmdable) error) error {
+	switch c := r.client.(type) {
+	case *redis.ClusterClient:
+		return c.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+			return fn(ctx, node)
+		})
+	case *redis.Ring:
+		return c.ForEachShard(ctx, func(ctx context.Context, node *redis.Client) error {
+			return fn(ctx, node)
+		})
+	default:
+		return fn(ctx, r.client)
+	}
+}
+
+// maxDeletePasses deleteMatch 最多重复扫描的轮数，避免其他调用方持续写入匹配的键时无法返回
+const maxDeletePasses = 16
+
+// deleteMatch 使用增量 SCAN 查找匹配 match 的键，每返回一页就在所在节点上 UNLINK，内存中最多保留一页键
+// 边扫描边删除会使部分实现（如 miniredis）的游标跳过键，因此删除过键时重新扫描，直到一轮扫描没有匹配的键
+// 或达到 maxDeletePasses；扫描期间新写入的键可能不会被删除
+func (r *RedisDriver) deleteMatch(ctx context.Context, match string) error {
+	for pass := 0; pass < maxDeletePasses; pass++ {
+		var deleted atomic.Bool
+		err := r.forEachNode(ctx, func(ctx context.Context, node redis.Cmdable) error {
+			var cursor uint64
+			for {
+				batch, next, err := node.Scan(ctx, cursor, match, scanCount).Result()
+				if err != nil {
+					return err
+				}
+				if len(batch) > 0 {
+					if err := r.unlink(ctx, node, batch); err != nil {
+						return err
+					}
+					deleted.Store(true)
+				}
+				cursor = next
+				if cursor == 0 {
+					return nil
+				}
+			}
+		})
+		if err != nil {
+			return mapError(err)
+		}
+		if !deleted.Load() {
+			return nil
+		}
+	}
+	return nil
+}
+
+// deleteKeys 分批删除已加上前缀的键
+func (r *RedisDriver) deleteKeys(ctx context.Context, keys []string) error {
+	for start := 0; start < len(keys); start += scanCount {
+		end := start + scanCount
+		if end > len(keys) {
+			end = len(keys)
+		}
+		if err := r.unlink(ctx, r.client, keys[start:end]); err != nil {
+			return mapError(err)
+		}
+	}
+	return nil
+}
+
+// unlink 在 node 上删除一批键，集群与 Ring 模式下使用 pipeline 逐个删除以避免跨槽错误
+func (r *RedisDriver) unlink(ctx context.Context, node redis.Cmdable, keys []string) error {
+	if !r.sharded() {
+		return node.Unlink(ctx, keys...).Err()
+	}
+	_, err := node.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, k := range keys {
+			pipe.Unlink(ctx, k)
+		}
+		return nil
+	})
+	return err
+}
+
+// errStopScan 用于在 fn 返回 false 后停止所有节点上的扫描
+var errStopScan = errors.New("redis: stop scan")
+
+// Scan 实现 driver.Scanner 接口，遍历与 pattern 匹配的键，跳过驱动内部使用的锁与标签键
+func (r *RedisDriver) Scan(pattern string, fn func(k string) bool) {
+	_ = r.ScanCtx(context.Background(), pattern, fn)
+}
+
+// Len 返回当前前缀下键的数量，不包括驱动内部使用的锁与标签键
+func (r *RedisDriver) Len() int {
+	n, _ := r.LenCtx(context.Background())
+	return n
+}
+
+// ScanCtx 使用增量 SCAN 遍历，集群与 Ring 模式下各节点并发扫描，fn 的调用会被串行化
+// 与 SCAN 命令一致，同一个键可能被返回多次
+func (r *RedisDriver) ScanCtx(ctx context.Context, pattern string, fn func(k string) bool) error {
+	match := driver.EscapePattern(r.prefix) + pattern
+	var (
+		mu      sync.Mutex
+		stopped bool
+	)
+	err := r.forEachNode(ctx, func(ctx context.Context, node redis.Cmdable) error {
+		var cursor uint64
+		for {
+			batch, next, err := node.Scan(ctx, cursor, match, scanCount).Result()
+			if err != nil {
+				return err
+			}
+			mu.Lock()
+			for _, key := range batch {
+				if stopped {
+					break
+				}
+				k := strings.TrimPrefix(key, r.prefix)
+				if strings.HasPrefix(k, lockKeyPrefix) || strings.HasPrefix(k, tagKeyPrefix) {
+					continue
+				}
+				stopped = !fn(k)
+			}
+			done := stopped
+			mu.Unlock()
+			if done {
+				return errStopScan
+			}
+			cursor = next
+			if cursor == 0 {
+				return nil
+			}
+		}
+	})
+	if errors.Is(err, errStopScan) {
+		return nil
+	}
+	return mapError(err)
+}
+
+func (r *RedisDriver) LenCtx(ctx context.Context) (int, error) {
+	var n int
+	err := r.ScanCtx(ctx, "*", func(string) bool {
+		n++
+		return true
+	})
+	return n, err
+}
@@ -0,0 +1,83 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// tagKeyPrefix 标签集合键的前缀
+const tagKeyPrefix = "cachex:tag:"
+
+// tagScript 将 ARGV[2:] 加入集合，并把集合的过期时间延长到至少 ARGV[1] 毫秒，使集合不早于其中的键过期
+// ARGV[1] 为 0 表示键不过期，此时集合也不过期；已经不过期的集合保持不过期
+var tagScript = redis.NewScript(`
+local existed = redis.call('EXISTS', KEYS[1])
+local pttl = redis.call('PTTL', KEYS[1])
+redis.call('SADD', KEYS[1], unpack(ARGV, 2))
+local ttl = tonumber(ARGV[1])
+if ttl <= 0 then
+	redis.call('PERSIST', KEYS[1])
+elseif (existed == 0 or pttl >= 0) and pttl < ttl then
+	redis.call('PEXPIRE', KEYS[1], ttl)
+end
+return 1
+`)
+
+// TagKeys 实现 driver.Tagger 接口，使用 Redis 集合记录标签下的键
+// 集合的过期时间会延长到覆盖 keys 的过期时间，所有键都过期后集合也随之过期
+func (r *RedisDriver) TagKeys(ctx context.Context, tag string, d time.Duration, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	args := make([]any, 0, len(keys)+1)
+	args = append(args, r.expiration(d).Milliseconds())
+	for _, k := range keys {
+		args = append(args, k)
+	}
+	return mapError(tagScript.Run(ctx, r.client, []string{r.key(tagKeyPrefix + tag)}, args...).Err())
+}
+
+// TaggedKeys 实现 driver.Tagger 接口，只返回仍然存在的键，并从集合中移除已过期或已删除的键
+func (r *RedisDriver) TaggedKeys(ctx context.Context, tag string) ([]string, error) {
+	set := r.key(tagKeyPrefix + tag)
+	members, err := r.client.SMembers(ctx, set).Result()
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	keys := make([]string, 0, len(members))
+	var missing []any
+	for start := 0; start < len(members); start += scanCount {
+		batch := members[start:min(start+scanCount, len(members))]
+		cmds := make([]*redis.IntCmd, len(batch))
+		_, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			for i, k := range batch {
+				cmds[i] = pipe.Exists(ctx, r.key(k))
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, mapError(err)
+		}
+		for i, cmd := range cmds {
+			if cmd.Val() > 0 {
+				keys = append(keys, batch[i])
+			} else {
+				missing = append(missing, batch[i])
+			}
+		}
+	}
+	if len(missing) > 0 {
+		if err := r.client.SRem(ctx, set, missing...).Err(); err != nil {
+			return nil, mapError(err)
+		}
+	}
+	return keys, nil
+}
+
+// DeleteTag 实现 driver.Tagger 接口
+func (r *RedisDriver) DeleteTag(ctx context.Context, tag string) error {
+	return mapError(r.client.Del(ctx, r.key(tagKeyPrefix+tag)).Err())
+}
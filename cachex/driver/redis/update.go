@@ -0,0 +1,162 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/yu1ec/go-pkg/cachex/driver"
+)
+
+// maxUpdateRetries Update 在 WATCH 冲突时的最大重试次数
+const maxUpdateRetries = 10
+
+// casScript 当前值与 ARGV[1] 相同时写入 ARGV[2]，ARGV[3] 为大于 0 的过期毫秒数时设置过期时间
+var casScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) ~= ARGV[1] then
+	return 0
+end
+if tonumber(ARGV[3]) > 0 then
+	redis.call("SET", KEYS[1], ARGV[2], "PX", ARGV[3])
+else
+	redis.call("SET", KEYS[1], ARGV[2])
+end
+return 1
+`)
+
+// CompareAndSwap 实现 driver.Updater 接口
+// 未配置编解码器时在服务端通过 Lua 脚本原子比较原始值；配置编解码器时 gob 等格式对相等的值（如 map）
+// 不保证编码结果相同，因此在 WATCH/MULTI 中将当前值解码为 old 的类型后使用 reflect.DeepEqual 比较，
+// 读取后键被修改时视为比较失败返回 false
+func (r *RedisDriver) CompareAndSwap(ctx context.Context, k string, old, new any, d time.Duration) (bool, error) {
+	newVal, err := r.encode(new)
+	if err != nil {
+		return false, err
+	}
+	if r.serializer != nil && old != nil {
+		return r.compareAndSwapDecoded(ctx, k, old, newVal, d)
+	}
+
+	oldVal, err := r.encode(old)
+	if err != nil {
+		return false, err
+	}
+	var ms int64
+	if d = r.expiration(d); d > 0 {
+		// 不足 1 毫秒的过期时间按 1 毫秒处理，避免被当作永不过期
+		ms = max(d.Milliseconds(), 1)
+	}
+	swapped, err := casScript.Run(ctx, r.client, []string{r.key(k)}, oldVal, newVal, ms).Int()
+	if err != nil {
+		return false, mapError(err)
+	}
+	return swapped == 1, nil
+}
+
+// compareAndSwapDecoded 将当前值解码为 old 的类型后比较，相等时写入已编码的 newVal
+func (r *RedisDriver) compareAndSwapDecoded(ctx context.Context, k string, old, newVal any, d time.Duration) (bool, error) {
+	key := r.key(k)
+	var swapped bool
+
+	txf := func(tx *redis.Tx) error {
+		data, err := tx.Get(ctx, key).Bytes()
+		if errors.Is(err, redis.Nil) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		cur := reflect.New(reflect.TypeOf(old))
+		// 无法解码为 old 的类型说明当前值与 old 不同
+		if r.unmarshal(data, cur.Interface()) != nil || !reflect.DeepEqual(cur.Elem().Interface(), old) {
+			return nil
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, newVal, r.expiration(d))
+			return nil
+		})
+		if err == nil {
+			swapped = true
+		}
+		return err
+	}
+
+	err := r.client.Watch(ctx, txf, key)
+	switch {
+	case err == nil:
+		return swapped, nil
+	case errors.Is(err, redis.TxFailedErr):
+		return false, nil
+	default:
+		return false, mapError(err)
+	}
+}
+
+// Update 实现 driver.Updater 接口，使用 WATCH/MULTI 乐观锁，键在读取后被修改时随机退避后重新调用 fn
+// 已存在的键保留剩余的过期时间，新键使用默认过期时间；重试 maxUpdateRetries 次仍冲突时返回 driver.ErrConflict
+func (r *RedisDriver) Update(ctx context.Context, k string, fn func(old any, exists bool) (any, error)) (any, error) {
+	key := r.key(k)
+	var result any
+	var fnErr error
+
+	txf := func(tx *redis.Tx) error {
+		var old any
+		data, err := tx.Get(ctx, key).Bytes()
+		exists := err == nil
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return err
+		}
+		if exists {
+			if old, err = r.decode(data); err != nil {
+				return err
+			}
+		}
+
+		v, err := fn(old, exists)
+		if err != nil {
+			fnErr = err
+			return err
+		}
+		val, err := r.encode(v)
+		if err != nil {
+			return err
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			if exists {
+				pipe.SetArgs(ctx, key, val, redis.SetArgs{KeepTTL: true})
+			} else {
+				pipe.Set(ctx, key, val, r.expiration(0))
+			}
+			return nil
+		})
+		if err == nil {
+			result = v
+		}
+		return err
+	}
+
+	for i := 0; i < maxUpdateRetries; i++ {
+		err := r.client.Watch(ctx, txf, key)
+		switch {
+		case err == nil:
+			return result, nil
+		case fnErr != nil:
+			return nil, fnErr
+		case errors.Is(err, redis.TxFailedErr):
+			// 随机退避，避免冲突的调用方再次同时重试
+			backoff := time.Duration(rand.Int63n(int64(i+1) * int64(time.Millisecond)))
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(backoff):
+			}
+		default:
+			return nil, mapError(err)
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", driver.ErrConflict, k)
+}
@@ -0,0 +1,92 @@
+package driver
+
+import "sync"
+
+// RemovalReason 项目离开缓存的原因
+type RemovalReason int
+
+const (
+	// RemovalDeleted 被显式删除
+	RemovalDeleted RemovalReason = iota + 1
+	// RemovalExpired 已过期
+	RemovalExpired
+	// RemovalEvicted 因容量上限被淘汰
+	RemovalEvicted
+)
+
+func (r RemovalReason) String() string {
+	switch r {
+	case RemovalDeleted:
+		return "deleted"
+	case RemovalExpired:
+		return "expired"
+	case RemovalEvicted:
+		return "evicted"
+	default:
+		return "unknown"
+	}
+}
+
+// RemovalEvent 删除事件
+type RemovalEvent struct {
+	Key string
+	// Value 被删除的值，驱动无法提供时（如 Redis）为 nil
+	Value any
+	// HasValue 表示 Value 是否有效
+	HasValue bool
+	Reason   RemovalReason
+}
+
+// RemovalListeners 管理删除回调，供驱动实现 Notifier 接口
+type RemovalListeners struct {
+	mu   sync.RWMutex
+	next int
+	fns  map[int]func(RemovalEvent)
+}
+
+// Add 注册回调，返回取消注册的函数
+func (l *RemovalListeners) Add(fn func(RemovalEvent)) func() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.fns == nil {
+		l.fns = make(map[int]func(RemovalEvent))
+	}
+	id := l.next
+	l.next++
+	l.fns[id] = fn
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			delete(l.fns, id)
+			l.mu.Unlock()
+		})
+	}
+}
+
+// Empty 判断是否没有注册任何回调，驱动可以据此跳过收集事件
+func (l *RemovalListeners) Empty() bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return len(l.fns) == 0
+}
+
+// Notify 依次将事件发送给所有回调
+func (l *RemovalListeners) Notify(events ...RemovalEvent) {
+	if len(events) == 0 {
+		return
+	}
+	l.mu.RLock()
+	fns := make([]func(RemovalEvent), 0, len(l.fns))
+	for _, fn := range l.fns {
+		fns = append(fns, fn)
+	}
+	l.mu.RUnlock()
+
+	for _, e := range events {
+		for _, fn := range fns {
+			fn(e)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package driver
+
+import "strings"
+
+// tagEntryOverhead 每条标签记录除标签与键本身外的估算开销，单位/字节
+const tagEntryOverhead = 64
+
+// TagIndex 记录标签与键的从属关系，供内存驱动实现 Tagger 接口
+// 每条记录带有过期时间，键被删除、过期或淘汰时驱动应调用 RemoveKey，使记录不会无限增长
+// TagIndex 不是并发安全的，调用方需要持有锁
+type TagIndex struct {
+	// tags 标签到键及记录过期时间（UnixNano，0 表示永不过期）的映射
+	tags map[string]map[string]int64
+	// keys 键到所属标签的映射
+	keys map[string]map[string]struct{}
+	size int64
+}
+
+// Add 将 keys 加入标签 tag，expires 为记录的过期时间，0 表示永不过期；已有的记录取较晚的过期时间
+func (t *TagIndex) Add(tag string, expires int64, keys ...string) {
+	if t.tags == nil {
+		t.tags = make(map[string]map[string]int64)
+		t.keys = make(map[string]map[string]struct{})
+	}
+	members, ok := t.tags[tag]
+	if !ok {
+		members = make(map[string]int64, len(keys))
+		t.tags[tag] = members
+	}
+	for _, k := range keys {
+		if old, ok := members[k]; ok {
+			if old != 0 && (expires == 0 || expires > old) {
+				members[k] = expires
+			}
+			continue
+		}
+		members[k] = expires
+		if t.keys[k] == nil {
+			t.keys[k] = make(map[string]struct{})
+		}
+		t.keys[k][tag] = struct{}{}
+		t.size += entrySize(tag, k)
+	}
+}
+
+// Keys 返回标签 tag 下未过期的键，已过期的记录会被删除，now 为当前时间（UnixNano）
+func (t *TagIndex) Keys(tag string, now int64) []string {
+	members := t.tags[tag]
+	keys := make([]string, 0, len(members))
+	for k, expires := range members {
+		if expires > 0 && now > expires {
+			t.remove(tag, k)
+			continue
+		}
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// RemoveKey 删除键 k 的所有标签记录
+func (t *TagIndex) RemoveKey(k string) {
+	for tag := range t.keys[k] {
+		t.remove(tag, k)
+	}
+}
+
+// RemoveTag 删除标签 tag 的所有记录
+func (t *TagIndex) RemoveTag(tag string) {
+	for k := range t.tags[tag] {
+		t.remove(tag, k)
+	}
+}
+
+// RemovePrefix 删除以 prefix 开头的标签的所有记录
+func (t *TagIndex) RemovePrefix(prefix string) {
+	for tag := range t.tags {
+		if strings.HasPrefix(tag, prefix) {
+			t.RemoveTag(tag)
+		}
+	}
+}
+
+// Reset 清空所有记录
+func (t *TagIndex) Reset() {
+	*t = TagIndex{}
+}
+
+// Size 返回所有记录的估算大小，单位/字节
+func (t *TagIndex) Size() int64 {
+	return t.size
+}
+
+// Len 返回记录的数量
+func (t *TagIndex) Len() int {
+	var n int
+	for _, members := range t.tags {
+		n += len(members)
+	}
+	return n
+}
+
+func (t *TagIndex) remove(tag, k string) {
+	members, ok := t.tags[tag]
+	if !ok {
+		return
+	}
+	if _, ok := members[k]; !ok {
+		return
+	}
+	delete(members, k)
+	if len(members) == 0 {
+		delete(t.tags, tag)
+	}
+	delete(t.keys[k], tag)
+	if len(t.keys[k]) == 0 {
+		delete(t.keys, k)
+	}
+	t.size -= entrySize(tag, k)
+}
+
+func entrySize(tag, k string) int64 {
+	return int64(len(tag)+len(k)) + tagEntryOverhead
+}
@@ -0,0 +1,40 @@
+package driver_test
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yu1ec/go-pkg/cachex/driver"
+)
+
+func TestTagIndex(t *testing.T) {
+	var idx driver.TagIndex
+	now := time.Now().UnixNano()
+
+	idx.Add("users", 0, "user:1", "user:2")
+	idx.Add("active", now+int64(time.Minute), "user:1")
+	idx.Add("expired", now-1, "user:3")
+	assert.Equal(t, 4, idx.Len())
+	size := idx.Size()
+	assert.Greater(t, size, int64(0))
+
+	// 重复记录不会增加大小
+	idx.Add("users", 0, "user:1")
+	assert.Equal(t, size, idx.Size())
+
+	keys := idx.Keys("users", now)
+	sort.Strings(keys)
+	assert.Equal(t, []string{"user:1", "user:2"}, keys)
+	assert.Empty(t, idx.Keys("expired", now))
+	assert.Equal(t, 3, idx.Len())
+
+	idx.RemoveKey("user:1")
+	assert.Equal(t, []string{"user:2"}, idx.Keys("users", now))
+	assert.Empty(t, idx.Keys("active", now))
+
+	idx.RemovePrefix("use")
+	assert.Equal(t, 0, idx.Len())
+	assert.Equal(t, int64(0), idx.Size())
+}
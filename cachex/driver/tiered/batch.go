@@ -0,0 +1,83 @@
+package tiered
+
+import (
+	"context"
+	"time"
+)
+
+// GetMany 批量获取缓存，一级缓存未命中的键从二级缓存批量读取并提升
+func (t *TieredDriver) GetMany(keys []string) (map[string]any, []string) {
+	found, missing, err := t.GetManyCtx(context.Background(), keys)
+	if err != nil {
+		return map[string]any{}, keys
+	}
+	return found, missing
+}
+
+// SetMany 批量写入二级缓存，并删除一级缓存中的副本
+func (t *TieredDriver) SetMany(items map[string]any, d time.Duration) {
+	_ = t.SetManyCtx(context.Background(), items, d)
+}
+
+// DeleteMany 批量删除两级缓存
+func (t *TieredDriver) DeleteMany(keys []string) {
+	_ = t.DeleteManyCtx(context.Background(), keys)
+}
+
+// GetManyCtx GetMany 的 context 版本
+// 批量读取无法得到二级缓存中每个键的过期时间，提升到一级缓存时使用 L1TTL，之后的 GetWithExpiration 会回源读取
+func (t *TieredDriver) GetManyCtx(ctx context.Context, keys []string) (map[string]any, []string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	local, missing := t.l1.GetMany(keys)
+	found := make(map[string]any, len(keys))
+	for k, v := range local {
+		if e, ok := v.(*l1Entry); ok {
+			found[k] = e.value
+		}
+	}
+	if len(missing) == 0 {
+		return found, nil, nil
+	}
+
+	versions := make(map[string]uint64, len(missing))
+	for _, k := range missing {
+		versions[k] = t.version(k)
+	}
+	fromL2, missing, err := t.l2.GetManyCtx(ctx, missing)
+	if err != nil {
+		return nil, nil, err
+	}
+	for k, v := range fromL2 {
+		t.promote(k, v, versions[k], time.Time{}, false)
+		found[k] = v
+	}
+	return found, missing, nil
+}
+
+// SetManyCtx SetMany 的 context 版本
+func (t *TieredDriver) SetManyCtx(ctx context.Context, items map[string]any, d time.Duration) error {
+	keys := make([]string, 0, len(items))
+	for k := range items {
+		keys = append(keys, k)
+	}
+	err := t.l2.SetManyCtx(ctx, items, d)
+	t.evict(keys...)
+	if err != nil {
+		return err
+	}
+	t.publish(ctx, invalidation{Keys: keys})
+	return nil
+}
+
+// DeleteManyCtx DeleteMany 的 context 版本
+func (t *TieredDriver) DeleteManyCtx(ctx context.Context, keys []string) error {
+	err := t.l2.DeleteManyCtx(ctx, keys)
+	t.evict(keys...)
+	if err != nil {
+		return err
+	}
+	t.publish(ctx, invalidation{Keys: keys})
+	return nil
+}
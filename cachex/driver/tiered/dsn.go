@@ -0,0 +1,89 @@
+package tiered
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/yu1ec/go-pkg/cachex/driver"
+	"github.com/yu1ec/go-pkg/cachex/driver/memory/gocache"
+	"github.com/yu1ec/go-pkg/cachex/driver/redis"
+)
+
+// tieredParams 两级缓存自身的参数，其余参数交给二级缓存的 Redis DSN 解析
+var tieredParams = []string{"l1_ttl", "l1_default_expiration", "l1_cleanup_interval", "l1_codec", "channel"}
+
+// ParseURL 将 DSN 解析为配置，格式与 Redis 的 DSN 相同，协议名为 tiered，tiereds 协议使用 TLS，
+// 如 tiered://:pass@host:6379/0?codec=json&l1_ttl=30s
+// 除 Redis DSN 的参数外支持：l1_ttl、l1_default_expiration、l1_cleanup_interval、l1_codec 与 channel，时间参数支持 "5m" 形式或秒数
+// 未知参数返回包装了 driver.ErrInvalidConfig 的错误
+func ParseURL(dsn string) (*TieredConfig, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("tiered: %w: %v", driver.ErrInvalidConfig, err)
+	}
+	return parseURL(u)
+}
+
+func parseURL(u *url.URL) (*TieredConfig, error) {
+	l2 := *u
+	switch u.Scheme {
+	case "tiered":
+		l2.Scheme = "redis"
+	case "tiereds":
+		l2.Scheme = "rediss"
+	default:
+		return nil, fmt.Errorf("tiered: %w: unsupported scheme %q", driver.ErrInvalidConfig, u.Scheme)
+	}
+
+	own, rest := url.Values{}, url.Values{}
+	for name, values := range u.Query() {
+		if slices.Contains(tieredParams, name) {
+			own[name] = values
+		} else {
+			rest[name] = values
+		}
+	}
+
+	cfg := &TieredConfig{}
+	q := driver.NewURLQuery(&url.URL{RawQuery: own.Encode()})
+	cfg.L1TTL = q.Duration("l1_ttl")
+	cfg.Channel = q.String("channel")
+	if own.Has("l1_default_expiration") || own.Has("l1_cleanup_interval") || own.Has("l1_codec") {
+		cfg.L1 = &gocache.GoCacheConfig{
+			DefaultExpiration: 5 * time.Minute,
+			CleanupInterval:   10 * time.Minute,
+			Codec:             q.String("l1_codec"),
+		}
+		if own.Has("l1_default_expiration") {
+			cfg.L1.DefaultExpiration = q.Duration("l1_default_expiration")
+		}
+		if own.Has("l1_cleanup_interval") {
+			cfg.L1.CleanupInterval = q.Duration("l1_cleanup_interval")
+		}
+	}
+	if err := q.Err(); err != nil {
+		return nil, fmt.Errorf("tiered: %w", err)
+	}
+	if cfg.L1 != nil && cfg.L1.Codec != "" {
+		if _, err := driver.LookupCodec(cfg.L1.Codec); err != nil {
+			return nil, fmt.Errorf("tiered: %w: %v", driver.ErrInvalidConfig, err)
+		}
+	}
+
+	l2.RawQuery = rest.Encode()
+	var err error
+	if cfg.L2, err = redis.ParseURL(l2.String()); err != nil {
+		return nil, fmt.Errorf("tiered: %w", err)
+	}
+	return cfg, nil
+}
+
+func openURL(u *url.URL) (driver.Driver, error) {
+	cfg, err := parseURL(u)
+	if err != nil {
+		return nil, err
+	}
+	return New(cfg)
+}
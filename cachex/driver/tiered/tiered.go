@@ -0,0 +1,527 @@
+package tiered
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash/maphash"
+	"sync"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/yu1ec/go-pkg/cachex/driver"
+	"github.com/yu1ec/go-pkg/cachex/driver/memory/gocache"
+	"github.com/yu1ec/go-pkg/cachex/driver/redis"
+)
+
+func init() {
+	driver.Register("tiered", New)
+	driver.RegisterURL("tiered", openURL)
+	driver.RegisterURL("tiereds", openURL)
+}
+
+// DefaultChannel 默认的失效通知频道
+const DefaultChannel = "cachex:tiered:invalidate"
+
+// TieredDriver 是两级缓存驱动，一级为进程内的 gocache，二级为 Redis
+// 读取时优先读取一级缓存，未命中时从二级缓存读取并提升到一级缓存；
+// 写入时只写入二级缓存并删除一级缓存中的副本，通过 Redis 发布订阅通知其他实例删除各自的副本
+// 一级缓存只保存从二级缓存读取并解码后的值，保证各实例读到的类型一致，也不会持有调用方仍可修改的对象
+type TieredDriver struct {
+	l1     *gocache.GoCacheDriver
+	l2     *redis.RedisDriver
+	l1TTL  time.Duration
+	id     string
+	pubsub *goredis.PubSub
+	done   chan struct{}
+
+	channel string
+
+	// seed、stripes 记录每个键（按哈希分组）一级缓存副本被删除的次数，
+	// 读取二级缓存期间副本被删除过时不再提升，避免把写入前读到的旧值放回一级缓存
+	seed    maphash.Seed
+	stripes [versionStripes]versionStripe
+}
+
+// versionStripes 版本号分组的数量，不同的键落在同一组时只会使提升被多余地跳过
+const versionStripes = 256
+
+type versionStripe struct {
+	mu      sync.Mutex
+	version uint64
+}
+
+type TieredConfig struct {
+	// L1 一级缓存配置，为 nil 时使用 gocache 默认配置
+	L1 *gocache.GoCacheConfig
+	// L2 二级缓存配置
+	L2 *redis.RedisConfig
+	// L1TTL 一级缓存的最长过期时间，为 0 时使用 1 分钟
+	L1TTL time.Duration
+	// Channel 失效通知使用的 Redis 频道，为空时使用 L2.Prefix 加上 DefaultChannel
+	Channel string
+}
+
+// invalidation 失效通知消息
+type invalidation struct {
+	Source string   `json:"src"`
+	Key    string   `json:"key,omitempty"`
+	Keys   []string `json:"keys,omitempty"`
+	Prefix string   `json:"prefix,omitempty"`
+	All    bool     `json:"all,omitempty"`
+}
+
+func New(config any) (driver.Driver, error) {
+	cfg, ok := config.(*TieredConfig)
+	if !ok || cfg == nil || cfg.L2 == nil {
+		return nil, fmt.Errorf("%w: invalid config for tiered cache", driver.ErrInvalidConfig)
+	}
+
+	l1, err := gocache.New(cfg.L1)
+	if err != nil {
+		return nil, err
+	}
+	l2, err := redis.New(cfg.L2)
+	if err != nil {
+		return nil, err
+	}
+
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	t := &TieredDriver{
+		l1:      l1.(*gocache.GoCacheDriver),
+		l2:      l2.(*redis.RedisDriver),
+		l1TTL:   cfg.L1TTL,
+		id:      hex.EncodeToString(id),
+		done:    make(chan struct{}),
+		channel: cfg.Channel,
+		seed:    maphash.MakeSeed(),
+	}
+	if t.l1TTL <= 0 {
+		t.l1TTL = time.Minute
+	}
+	if t.channel == "" {
+		t.channel = cfg.L2.Prefix + DefaultChannel
+	}
+
+	ctx := context.Background()
+	t.pubsub = t.l2.Client().Subscribe(ctx, t.channel)
+	// 等待订阅确认，保证 New 返回后不会错过失效通知
+	if _, err := t.pubsub.Receive(ctx); err != nil {
+		t.pubsub.Close()
+		t.l2.Client().Close()
+		return nil, err
+	}
+	go t.listen()
+
+	return t, nil
+}
+
+// Close 停止接收失效通知并关闭 Redis 连接
+func (t *TieredDriver) Close() error {
+	select {
+	case <-t.done:
+		return nil
+	default:
+		close(t.done)
+	}
+	if err := t.pubsub.Close(); err != nil {
+		return err
+	}
+	return t.l2.Close()
+}
+
+// Ping 实现 driver.Pinger 接口，检查二级缓存是否可用
+func (t *TieredDriver) Ping(ctx context.Context) error {
+	return t.l2.Ping(ctx)
+}
+
+func (t *TieredDriver) listen() {
+	ch := t.pubsub.Channel()
+	for {
+		select {
+		case <-t.done:
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			var inv invalidation
+			if err := json.Unmarshal([]byte(msg.Payload), &inv); err != nil || inv.Source == t.id {
+				continue
+			}
+			switch {
+			case inv.All:
+				t.evictAll(t.l1.Flush)
+			case inv.Prefix != "":
+				t.evictAll(func() { _ = t.l1.FlushPrefix(context.Background(), inv.Prefix) })
+			case len(inv.Keys) > 0:
+				t.evict(inv.Keys...)
+			default:
+				t.evict(inv.Key)
+			}
+		}
+	}
+}
+
+// publish 通知其他实例删除一级缓存中的副本，通知失败不影响本次写入
+func (t *TieredDriver) publish(ctx context.Context, inv invalidation) {
+	inv.Source = t.id
+	payload, err := json.Marshal(inv)
+	if err != nil {
+		return
+	}
+	t.l2.Client().Publish(ctx, t.channel, payload)
+}
+
+// l1Entry 一级缓存中的项目，expires 为二级缓存中的过期时间，零值表示永不过期
+// 批量读取无法得到二级缓存的过期时间，此时 known 为 false，GetWithExpiration 需要回源读取
+type l1Entry struct {
+	value   any
+	expires time.Time
+	known   bool
+}
+
+// stripe 返回 k 所在的版本号分组
+func (t *TieredDriver) stripe(k string) *versionStripe {
+	return &t.stripes[maphash.String(t.seed, k)%versionStripes]
+}
+
+// version 返回 k 当前的版本号，需要在读取二级缓存之前获取，并在提升时传给 promote
+func (t *TieredDriver) version(k string) uint64 {
+	s := t.stripe(k)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.version
+}
+
+// evict 删除一级缓存中的副本并增加版本号，使正在进行的二级缓存读取不再提升
+func (t *TieredDriver) evict(keys ...string) {
+	for _, k := range keys {
+		s := t.stripe(k)
+		s.mu.Lock()
+		s.version++
+		t.l1.Delete(k)
+		s.mu.Unlock()
+	}
+}
+
+// evictAll 增加所有键的版本号并执行 fn 删除一级缓存中的副本
+func (t *TieredDriver) evictAll(fn func()) {
+	for i := range t.stripes {
+		t.stripes[i].mu.Lock()
+		t.stripes[i].version++
+	}
+	defer func() {
+		for i := range t.stripes {
+			t.stripes[i].mu.Unlock()
+		}
+	}()
+	fn()
+}
+
+// promote 将从二级缓存读取的值提升到一级缓存，一级缓存的过期时间不超过 L1TTL 与二级缓存的过期时间
+// version 为读取二级缓存之前获取的版本号，读取期间副本被删除过时不提升
+func (t *TieredDriver) promote(k string, v any, version uint64, expires time.Time, known bool) {
+	d := t.l1TTL
+	if !expires.IsZero() {
+		remaining := time.Until(expires)
+		if remaining <= 0 {
+			return
+		}
+		d = min(d, remaining)
+	}
+	s := t.stripe(k)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.version != version {
+		return
+	}
+	t.l1.Set(k, &l1Entry{value: v, expires: expires, known: known}, d)
+}
+
+// local 读取一级缓存中的项目
+func (t *TieredDriver) local(k string) (*l1Entry, bool) {
+	v, found := t.l1.Get(k)
+	if !found {
+		return nil, false
+	}
+	e, ok := v.(*l1Entry)
+	return e, ok
+}
+
+// Add 仅当给定键的项目尚不存在或现有项目已过期时，才将项目添加到缓存。否则返回错误。
+func (t *TieredDriver) Add(k string, v any, d time.Duration) error {
+	return t.AddCtx(context.Background(), k, v, d)
+}
+
+// Delete 从缓存中删除一个项目。如果密钥不在缓存中，则不执行任何操作。
+func (t *TieredDriver) Delete(k string) {
+	_ = t.DeleteCtx(context.Background(), k)
+}
+
+// DeleteExpired 删除一级缓存中过期的项目，二级缓存由 Redis 自动处理
+func (t *TieredDriver) DeleteExpired() {
+	t.l1.DeleteExpired()
+}
+
+// Flush 清空缓存
+func (t *TieredDriver) Flush() {
+	_ = t.FlushCtx(context.Background())
+}
+
+// Get 从缓存中获取一个项目。返回该项或 nil，以及一个指示是否找到该键的布尔值。
+func (t *TieredDriver) Get(k string) (any, bool) {
+	v, err := t.GetCtx(context.Background(), k)
+	return v, err == nil
+}
+
+// GetWithExpiration 从缓存中返回一个项目及其过期时间
+func (t *TieredDriver) GetWithExpiration(k string) (any, time.Time, bool) {
+	v, expiration, err := t.GetWithExpirationCtx(context.Background(), k)
+	return v, expiration, err == nil
+}
+
+// Replace 替换缓存,如果缓存不存在,则返回错误
+func (t *TieredDriver) Replace(k string, x any, d time.Duration) error {
+	return t.ReplaceCtx(context.Background(), k, x, d)
+}
+
+// Set 添加/替换现有的缓存设置,包括过期时间,如果过期时间是0,则使用默认过期时间,如果为-1则表示永不过期
+func (t *TieredDriver) Set(k string, x any, d time.Duration) {
+	_ = t.SetCtx(context.Background(), k, x, d)
+}
+
+// SetDefault 添加/替换现有的缓存设置,使用默认过期时间
+func (t *TieredDriver) SetDefault(k string, x any) {
+	_ = t.SetDefaultCtx(context.Background(), k, x)
+}
+
+// AddCtx Add 的 context 版本
+func (t *TieredDriver) AddCtx(ctx context.Context, k string, v any, d time.Duration) error {
+	if err := t.l2.AddCtx(ctx, k, v, d); err != nil {
+		return err
+	}
+	t.evict(k)
+	t.publish(ctx, invalidation{Key: k})
+	return nil
+}
+
+// DeleteCtx Delete 的 context 版本
+func (t *TieredDriver) DeleteCtx(ctx context.Context, k string) error {
+	err := t.l2.DeleteCtx(ctx, k)
+	t.evict(k)
+	if err != nil {
+		return err
+	}
+	t.publish(ctx, invalidation{Key: k})
+	return nil
+}
+
+// FlushCtx Flush 的 context 版本
+func (t *TieredDriver) FlushCtx(ctx context.Context) error {
+	err := t.l2.FlushCtx(ctx)
+	t.evictAll(t.l1.Flush)
+	if err != nil {
+		return err
+	}
+	t.publish(ctx, invalidation{All: true})
+	return nil
+}
+
+// FlushPrefix 实现 driver.PrefixFlusher 接口
+func (t *TieredDriver) FlushPrefix(ctx context.Context, prefix string) error {
+	err := t.l2.FlushPrefix(ctx, prefix)
+	t.evictAll(func() { _ = t.l1.FlushPrefix(ctx, prefix) })
+	if err != nil {
+		return err
+	}
+	t.publish(ctx, invalidation{Prefix: prefix})
+	return nil
+}
+
+// OnRemoval 实现 driver.Notifier 接口，只报告二级缓存（Redis）中的删除事件，一级缓存的副本被替换或删除不会触发回调
+func (t *TieredDriver) OnRemoval(fn func(driver.RemovalEvent)) (func(), error) {
+	return t.l2.OnRemoval(fn)
+}
+
+// GetCtx 优先读取一级缓存，未命中时读取二级缓存并提升到一级缓存
+func (t *TieredDriver) GetCtx(ctx context.Context, k string) (any, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if e, found := t.local(k); found {
+		return e.value, nil
+	}
+	v, _, err := t.getL2(ctx, k)
+	return v, err
+}
+
+// GetWithExpirationCtx GetWithExpiration 的 context 版本，一级缓存命中时返回提升时记录的二级缓存过期时间
+func (t *TieredDriver) GetWithExpirationCtx(ctx context.Context, k string) (any, time.Time, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, time.Time{}, err
+	}
+	if e, found := t.local(k); found && e.known {
+		return e.value, e.expires, nil
+	}
+	return t.getL2(ctx, k)
+}
+
+// getL2 从二级缓存读取并提升到一级缓存
+func (t *TieredDriver) getL2(ctx context.Context, k string) (any, time.Time, error) {
+	version := t.version(k)
+	v, expiration, err := t.l2.GetWithExpirationCtx(ctx, k)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	t.promote(k, v, version, expiration, true)
+	return v, expiration, nil
+}
+
+// ReplaceCtx Replace 的 context 版本
+func (t *TieredDriver) ReplaceCtx(ctx context.Context, k string, x any, d time.Duration) error {
+	if err := t.l2.ReplaceCtx(ctx, k, x, d); err != nil {
+		return err
+	}
+	t.evict(k)
+	t.publish(ctx, invalidation{Key: k})
+	return nil
+}
+
+// SetCtx 写入二级缓存并删除一级缓存中的副本，通知其他实例删除各自的副本
+// 无论写入是否成功都删除一级缓存中的旧值，避免读到过期数据；写入之前开始的读取不会再把旧值提升到一级缓存
+func (t *TieredDriver) SetCtx(ctx context.Context, k string, x any, d time.Duration) error {
+	err := t.l2.SetCtx(ctx, k, x, d)
+	t.evict(k)
+	if err != nil {
+		return err
+	}
+	t.publish(ctx, invalidation{Key: k})
+	return nil
+}
+
+// SetDefaultCtx SetDefault 的 context 版本
+func (t *TieredDriver) SetDefaultCtx(ctx context.Context, k string, x any) error {
+	return t.SetCtx(ctx, k, x, 0)
+}
+
+// GetInto 实现 driver.ValueDecoder 接口，直接从二级缓存读取并解码
+func (t *TieredDriver) GetInto(ctx context.Context, k string, v any) error {
+	return t.l2.GetInto(ctx, k, v)
+}
+
+// TryLock 实现 driver.Locker 接口，锁由二级缓存提供
+func (t *TieredDriver) TryLock(ctx context.Context, k string, ttl time.Duration) (func(), bool, error) {
+	return t.l2.TryLock(ctx, k, ttl)
+}
+
+// CompareAndSwap 实现 driver.Updater 接口，只在二级缓存中进行，完成后删除各实例一级缓存中的副本
+func (t *TieredDriver) CompareAndSwap(ctx context.Context, k string, old, new any, d time.Duration) (bool, error) {
+	defer t.invalidate(k)
+	return t.l2.CompareAndSwap(ctx, k, old, new, d)
+}
+
+// Update 实现 driver.Updater 接口，只在二级缓存中进行，完成后删除各实例一级缓存中的副本
+func (t *TieredDriver) Update(ctx context.Context, k string, fn func(old any, exists bool) (any, error)) (any, error) {
+	defer t.invalidate(k)
+	return t.l2.Update(ctx, k, fn)
+}
+
+// invalidate 数值操作只在二级缓存中进行，完成后删除各实例一级缓存中的副本
+func (t *TieredDriver) invalidate(k string) {
+	t.evict(k)
+	t.publish(context.Background(), invalidation{Key: k})
+}
+
+func (t *TieredDriver) IncrementInt(k string, n int) (int, error) {
+	defer t.invalidate(k)
+	return t.l2.IncrementInt(k, n)
+}
+
+func (t *TieredDriver) DecrementInt(k string, n int) (int, error) {
+	defer t.invalidate(k)
+	return t.l2.DecrementInt(k, n)
+}
+
+func (t *TieredDriver) IncrementInt64(k string, n int64) (int64, error) {
+	defer t.invalidate(k)
+	return t.l2.IncrementInt64(k, n)
+}
+
+func (t *TieredDriver) DecrementInt64(k string, n int64) (int64, error) {
+	defer t.invalidate(k)
+	return t.l2.DecrementInt64(k, n)
+}
+
+func (t *TieredDriver) IncrementUint(k string, n uint) (uint, error) {
+	defer t.invalidate(k)
+	return t.l2.IncrementUint(k, n)
+}
+
+func (t *TieredDriver) DecrementUint(k string, n uint) (uint, error) {
+	defer t.invalidate(k)
+	return t.l2.DecrementUint(k, n)
+}
+
+func (t *TieredDriver) IncrementUint64(k string, n uint64) (uint64, error) {
+	defer t.invalidate(k)
+	return t.l2.IncrementUint64(k, n)
+}
+
+func (t *TieredDriver) DecrementUint64(k string, n uint64) (uint64, error) {
+	defer t.invalidate(k)
+	return t.l2.DecrementUint64(k, n)
+}
+
+// TagKeys 实现 driver.Tagger 接口，标签记录在二级缓存中
+func (t *TieredDriver) TagKeys(ctx context.Context, tag string, d time.Duration, keys ...string) error {
+	return t.l2.TagKeys(ctx, tag, d, keys...)
+}
+
+// TaggedKeys 实现 driver.Tagger 接口
+func (t *TieredDriver) TaggedKeys(ctx context.Context, tag string) ([]string, error) {
+	return t.l2.TaggedKeys(ctx, tag)
+}
+
+// DeleteTag 实现 driver.Tagger 接口
+func (t *TieredDriver) DeleteTag(ctx context.Context, tag string) error {
+	return t.l2.DeleteTag(ctx, tag)
+}
+
+// IncrementFloat64 实现 driver.FloatOperations 接口
+func (t *TieredDriver) IncrementFloat64(k string, n float64) (float64, error) {
+	defer t.invalidate(k)
+	return t.l2.IncrementFloat64(k, n)
+}
+
+// IncrementInt64WithTTL 实现 driver.Counter 接口
+func (t *TieredDriver) IncrementInt64WithTTL(ctx context.Context, k string, n int64, d time.Duration) (int64, error) {
+	defer t.invalidate(k)
+	return t.l2.IncrementInt64WithTTL(ctx, k, n, d)
+}
+
+// Scan 实现 driver.Scanner 接口，遍历二级缓存中的键
+func (t *TieredDriver) Scan(pattern string, fn func(k string) bool) {
+	t.l2.Scan(pattern, fn)
+}
+
+// Len 实现 driver.Scanner 接口，返回二级缓存中键的数量
+func (t *TieredDriver) Len() int {
+	return t.l2.Len()
+}
+
+// ScanCtx 实现 driver.Scanner 接口
+func (t *TieredDriver) ScanCtx(ctx context.Context, pattern string, fn func(k string) bool) error {
+	return t.l2.ScanCtx(ctx, pattern, fn)
+}
+
+// LenCtx 实现 driver.Scanner 接口
+func (t *TieredDriver) LenCtx(ctx context.Context) (int, error) {
+	return t.l2.LenCtx(ctx)
+}
@@ -0,0 +1,298 @@
+package tiered_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/yu1ec/go-pkg/cachex/driver"
+	"github.com/yu1ec/go-pkg/cachex/driver/drivertest"
+	"github.com/yu1ec/go-pkg/cachex/driver/redis"
+	"github.com/yu1ec/go-pkg/cachex/driver/tiered"
+)
+
+func newTiered(t *testing.T, mr *miniredis.Miniredis) *tiered.TieredDriver {
+	d, err := driver.New("tiered", &tiered.TieredConfig{
+		L2:    &redis.RedisConfig{Addr: mr.Addr()},
+		L1TTL: time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("failed to create tiered driver: %v", err)
+	}
+	td := d.(*tiered.TieredDriver)
+	t.Cleanup(func() { td.Close() })
+	return td
+}
+
+func TestTieredDriver(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	defer mr.Close()
+
+	a := newTiered(t, mr)
+	b := newTiered(t, mr)
+
+	t.Run("write through and read through", func(t *testing.T) {
+		a.Set("key1", "value1", time.Minute)
+		assert.Equal(t, "value1", mustGet(t, mr, "key1"))
+		waitInvalidations(t, a, b)
+
+		val, exists := b.Get("key1")
+		assert.True(t, exists)
+		assert.Equal(t, "value1", val)
+
+		// 已提升到 b 的一级缓存，直接修改 Redis 不影响 b 的读取
+		mr.Set("key1", "changed")
+		val, _ = b.Get("key1")
+		assert.Equal(t, "value1", val)
+	})
+
+	t.Run("same type on every instance", func(t *testing.T) {
+		a.Set("number", 42, time.Minute)
+		va, _ := a.Get("number")
+		vb, _ := b.Get("number")
+		assert.Equal(t, "42", va)
+		assert.Equal(t, va, vb)
+	})
+
+	t.Run("invalidate other instances", func(t *testing.T) {
+		a.Set("key2", "old", time.Minute)
+		val, _ := b.Get("key2")
+		assert.Equal(t, "old", val)
+
+		a.Set("key2", "new", time.Minute)
+		assert.Eventually(t, func() bool {
+			val, _ := b.Get("key2")
+			return val == "new"
+		}, time.Second, 10*time.Millisecond)
+
+		a.Delete("key2")
+		assert.Eventually(t, func() bool {
+			_, exists := b.Get("key2")
+			return !exists
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("flush", func(t *testing.T) {
+		a.Set("key3", "value3", time.Minute)
+		b.Get("key3")
+		a.Flush()
+		assert.Eventually(t, func() bool {
+			_, exists := b.Get("key3")
+			return !exists
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("l1 expiration follows l2", func(t *testing.T) {
+		a.Set("key4", "value4", 50*time.Millisecond)
+		_, exists := a.Get("key4")
+		assert.True(t, exists)
+		time.Sleep(60 * time.Millisecond)
+		mr.FastForward(time.Second)
+		_, exists = a.Get("key4")
+		assert.False(t, exists)
+	})
+
+	t.Run("l1 hit returns l2 expiration", func(t *testing.T) {
+		a.Set("key5", "value5", time.Hour)
+		_, fromL2, found := a.GetWithExpiration("key5")
+		assert.True(t, found)
+		_, fromL1, found := a.GetWithExpiration("key5")
+		assert.True(t, found)
+		assert.Equal(t, fromL2, fromL1)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), fromL1, time.Second)
+	})
+
+	t.Run("numeric operations", func(t *testing.T) {
+		a.Set("counter", "10", time.Minute)
+		b.Get("counter")
+		n, err := a.IncrementInt64("counter", 5)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(15), n)
+		assert.Eventually(t, func() bool {
+			val, _ := b.Get("counter")
+			return val == "15"
+		}, time.Second, 10*time.Millisecond)
+	})
+}
+
+// waitInvalidations 等待 b 处理完 a 已经发布的失效通知，避免通知晚于 b 的读取到达而删除刚提升的副本
+// 通知按发布顺序处理：b 的一级缓存中保存旧值时，a 写入新值后 b 读到新值，说明之前的通知也已处理
+func waitInvalidations(t *testing.T, a, b *tiered.TieredDriver) {
+	a.Set("sync", "old", time.Minute)
+	assert.Eventually(t, func() bool {
+		v, _ := b.Get("sync")
+		return v == "old"
+	}, time.Second, 10*time.Millisecond)
+	a.Set("sync", "new", time.Minute)
+	assert.Eventually(t, func() bool {
+		v, _ := b.Get("sync")
+		return v == "new"
+	}, time.Second, 10*time.Millisecond)
+}
+
+func mustGet(t *testing.T, mr *miniredis.Miniredis, k string) string {
+	v, err := mr.Get(k)
+	if err != nil {
+		t.Fatalf("failed to get %s from miniredis: %v", k, err)
+	}
+	return v
+}
+
+func TestTieredDriverBatch(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	defer mr.Close()
+
+	a := newTiered(t, mr)
+	b := newTiered(t, mr)
+
+	a.SetMany(map[string]any{"k1": "v1", "k2": "v2"}, time.Minute)
+	b.Get("k1")
+
+	found, missing := b.GetMany([]string{"k1", "k2", "k3"})
+	assert.Equal(t, map[string]any{"k1": "v1", "k2": "v2"}, found)
+	assert.Equal(t, []string{"k3"}, missing)
+
+	a.DeleteMany([]string{"k1", "k2"})
+	assert.Eventually(t, func() bool {
+		found, _ := b.GetMany([]string{"k1", "k2"})
+		return len(found) == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestTieredDriverFillsL1OnRead(t *testing.T) {
+	mr := miniredis.RunT(t)
+	newDriver := func() *tiered.TieredDriver {
+		d, err := driver.New("tiered", &tiered.TieredConfig{
+			L2: &redis.RedisConfig{Addr: mr.Addr(), Codec: "json"},
+		})
+		if err != nil {
+			t.Fatalf("failed to create tiered driver: %v", err)
+		}
+		td := d.(*tiered.TieredDriver)
+		t.Cleanup(func() { td.Close() })
+		return td
+	}
+	a, b := newDriver(), newDriver()
+
+	user := map[string]any{"name": "alice", "age": 30}
+	assert.NoError(t, a.SetCtx(context.Background(), "user", user, time.Minute))
+	user["name"] = "changed"
+
+	want := map[string]any{"name": "alice", "age": float64(30)}
+	va, _ := a.Get("user")
+	vb, _ := b.Get("user")
+	assert.Equal(t, want, va)
+	assert.Equal(t, want, vb)
+}
+
+func TestTieredDriverConcurrentReadWrite(t *testing.T) {
+	mr := miniredis.RunT(t)
+	d := newTiered(t, mr)
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					d.Get("key")
+					time.Sleep(10 * time.Microsecond)
+				}
+			}
+		}()
+	}
+
+	// 写入之前开始的读取不能把旧值提升到一级缓存，写入返回后本实例读到的总是新值
+	for i := 0; i < 200; i++ {
+		want := fmt.Sprint(i)
+		d.Set("key", want, time.Minute)
+		v, _ := d.Get("key")
+		if !assert.Equal(t, want, v) {
+			break
+		}
+	}
+	close(stop)
+	wg.Wait()
+}
+
+func TestDriverConformance(t *testing.T) {
+	mr := miniredis.RunT(t)
+	drivertest.Run(t, drivertest.Harness{
+		New: func(t *testing.T, defaultExpiration time.Duration) driver.Driver {
+			mr.FlushAll()
+			d, err := driver.New("tiered", &tiered.TieredConfig{
+				L2: &redis.RedisConfig{Addr: mr.Addr(), DefaultExpiration: defaultExpiration},
+			})
+			if err != nil {
+				t.Fatalf("failed to create tiered driver: %v", err)
+			}
+			t.Cleanup(func() { d.(*tiered.TieredDriver).Close() })
+			return d
+		},
+		// 一级缓存按真实时间过期，miniredis 的时间需要手动前进，两者同时推进才能使两级缓存一起过期
+		Advance: func(d time.Duration) {
+			mr.FastForward(d)
+			time.Sleep(d)
+		},
+	})
+}
+
+func TestParseURL(t *testing.T) {
+	cfg, err := tiered.ParseURL("tiered://:secret@cache.internal:6380/2?prefix=app:&codec=json&l1_ttl=30s&l1_default_expiration=1m&channel=events")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"cache.internal:6380"}, cfg.L2.Addrs)
+	assert.Equal(t, "secret", cfg.L2.Password)
+	assert.Equal(t, 2, cfg.L2.DB)
+	assert.Equal(t, "app:", cfg.L2.Prefix)
+	assert.Equal(t, "json", cfg.L2.Codec)
+	assert.Nil(t, cfg.L2.TLSConfig)
+	assert.Equal(t, 30*time.Second, cfg.L1TTL)
+	assert.Equal(t, time.Minute, cfg.L1.DefaultExpiration)
+	assert.Equal(t, 10*time.Minute, cfg.L1.CleanupInterval)
+	assert.Equal(t, "events", cfg.Channel)
+
+	cfg, err = tiered.ParseURL("tiereds://node1")
+	assert.NoError(t, err)
+	assert.Nil(t, cfg.L1)
+	assert.Equal(t, "node1", cfg.L2.TLSConfig.ServerName)
+
+	for _, dsn := range []string{
+		"tiered://localhost:6379?l1_tl=30s",
+		"tiered://localhost:6379?prefx=app",
+		"tiered://localhost:6379?l1_ttl=soon",
+		"tiered://localhost:6379?l1_codec=unknown",
+		"tiered://",
+		"redis://localhost:6379",
+	} {
+		_, err := tiered.ParseURL(dsn)
+		assert.ErrorIs(t, err, driver.ErrInvalidConfig, dsn)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	mr := miniredis.RunT(t)
+
+	d, err := driver.Open("tiered://" + mr.Addr() + "?prefix=app:&l1_ttl=30s")
+	assert.NoError(t, err)
+	defer d.(*tiered.TieredDriver).Close()
+	d.Set("k", "v", 0)
+	assert.True(t, mr.Exists("app:k"))
+	v, ok := d.Get("k")
+	assert.True(t, ok)
+	assert.Equal(t, "v", v)
+}
@@ -0,0 +1,45 @@
+package lock
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/yu1ec/go-pkg/cachex/driver"
+)
+
+// DriverBackend 基于缓存驱动的 Backend 实现，驱动需要实现 driver.TokenLocker（如 Redis 驱动）
+// 锁键会加上驱动配置的前缀，连接类错误与驱动一样包装为 driver.ErrUnavailable
+type DriverBackend struct {
+	locker driver.TokenLocker
+}
+
+// NewDriverBackend 使用缓存驱动创建锁后端，驱动没有实现 driver.TokenLocker 时返回 driver.ErrNotSupported
+func NewDriverBackend(d driver.Driver) (*DriverBackend, error) {
+	locker, ok := driver.As[driver.TokenLocker](d)
+	if !ok {
+		return nil, fmt.Errorf("%w: %T does not support token locks", driver.ErrNotSupported, d)
+	}
+	return &DriverBackend{locker: locker}, nil
+}
+
+// NewDriver 创建使用缓存驱动作为后端的 Locker，如 lock.NewDriver(redisDriver)
+func NewDriver(d driver.Driver, opts ...Option) (*Locker, error) {
+	backend, err := NewDriverBackend(d)
+	if err != nil {
+		return nil, err
+	}
+	return New(backend, opts...), nil
+}
+
+func (b *DriverBackend) Acquire(ctx context.Context, key, token string, ttl time.Duration) (bool, error) {
+	return b.locker.AcquireLock(ctx, key, token, ttl)
+}
+
+func (b *DriverBackend) Extend(ctx context.Context, key, token string, ttl time.Duration) (bool, error) {
+	return b.locker.ExtendLock(ctx, key, token, ttl)
+}
+
+func (b *DriverBackend) Release(ctx context.Context, key, token string) (bool, error) {
+	return b.locker.ReleaseLock(ctx, key, token)
+}
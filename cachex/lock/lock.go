@@ -0,0 +1,267 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	mrand "math/rand"
+	"sync"
+	"time"
+
+	"github.com/yu1ec/go-pkg/cachex/driver"
+)
+
+var (
+	// ErrNotAcquired 锁已被其他持有者持有
+	ErrNotAcquired = errors.New("lock: not acquired")
+
+	// ErrNotHeld 锁已过期或已被其他持有者获取，当前持有者不能再续期或释放
+	ErrNotHeld = errors.New("lock: not held")
+)
+
+// DefaultPrefix 锁键的默认前缀，与 Redis 驱动 TryLock 使用的前缀相同
+const DefaultPrefix = "cachex:lock:"
+
+// Backend 锁的存储后端，所有操作都必须是原子的
+type Backend interface {
+	// Acquire 当 key 未被持有时以 token 持有 ttl 时长，返回是否获取成功
+	Acquire(ctx context.Context, key, token string, ttl time.Duration) (bool, error)
+	// Extend 仅当 key 由 token 持有时将剩余的持有时间重置为 ttl，返回是否仍由 token 持有
+	Extend(ctx context.Context, key, token string, ttl time.Duration) (bool, error)
+	// Release 仅当 key 由 token 持有时释放，返回是否仍由 token 持有
+	Release(ctx context.Context, key, token string) (bool, error)
+}
+
+// Locker 基于 Backend 获取锁
+type Locker struct {
+	backend    Backend
+	prefix     string
+	minBackoff time.Duration
+	maxBackoff time.Duration
+}
+
+// Option Locker 配置项
+type Option func(*Locker)
+
+// WithPrefix 设置锁键的前缀，默认为 DefaultPrefix
+func WithPrefix(prefix string) Option {
+	return func(l *Locker) {
+		l.prefix = prefix
+	}
+}
+
+// WithBackoff 设置 Lock 重试的退避时间，从 min 开始每次翻倍直到 max，实际等待时间带有随机抖动
+// 默认为 10ms 到 500ms
+func WithBackoff(min, max time.Duration) Option {
+	return func(l *Locker) {
+		l.minBackoff = min
+		l.maxBackoff = max
+	}
+}
+
+// New 创建使用 backend 的 Locker
+func New(backend Backend, opts ...Option) *Locker {
+	l := &Locker{
+		backend:    backend,
+		prefix:     DefaultPrefix,
+		minBackoff: 10 * time.Millisecond,
+		maxBackoff: 500 * time.Millisecond,
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	if l.minBackoff <= 0 {
+		l.minBackoff = time.Millisecond
+	}
+	if l.maxBackoff < l.minBackoff {
+		l.maxBackoff = l.minBackoff
+	}
+	return l
+}
+
+// TryLock 尝试获取锁，锁已被持有时立即返回 ErrNotAcquired
+// ttl 为锁的最长持有时间，持有者崩溃后锁在 ttl 后自动释放
+func (l *Locker) TryLock(ctx context.Context, key string, ttl time.Duration) (*Lock, error) {
+	if ttl <= 0 {
+		return nil, fmt.Errorf("lock: invalid ttl %s", ttl)
+	}
+	token, err := driver.NewLockToken()
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	ok, err := l.backend.Acquire(ctx, l.prefix+key, token, ttl)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotAcquired, key)
+	}
+	return &Lock{locker: l, key: key, token: token, ttl: ttl, expires: start.Add(ttl)}, nil
+}
+
+// Lock 获取锁，锁已被持有时按退避时间重试，直到获取成功或 ctx 结束
+// ctx 结束时返回的错误同时满足 errors.Is(err, ErrNotAcquired) 与 errors.Is(err, ctx.Err())
+func (l *Locker) Lock(ctx context.Context, key string, ttl time.Duration) (*Lock, error) {
+	backoff := l.minBackoff
+	for {
+		lk, err := l.TryLock(ctx, key, ttl)
+		if err != nil && ctx.Err() != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrNotAcquired, key, ctx.Err())
+		}
+		if !errors.Is(err, ErrNotAcquired) {
+			return lk, err
+		}
+
+		// 在 [backoff/2, backoff) 之间随机等待，避免多个等待者同时重试
+		wait := backoff/2 + time.Duration(mrand.Int63n(int64(backoff/2)+1))
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("%w: %s: %w", ErrNotAcquired, key, ctx.Err())
+		case <-timer.C:
+		}
+		backoff = min(backoff*2, l.maxBackoff)
+	}
+}
+
+// Lock 已获取的锁
+type Lock struct {
+	locker *Locker
+	key    string
+	token  string
+
+	mu       sync.Mutex
+	ttl      time.Duration
+	expires  time.Time
+	released bool
+
+	// 自动续期
+	stop chan struct{}
+	done chan struct{}
+	lost chan struct{}
+}
+
+// Key 返回锁的键，不包含前缀
+func (lk *Lock) Key() string {
+	return lk.key
+}
+
+// Token 返回持有者令牌，每次获取锁时随机生成
+func (lk *Lock) Token() string {
+	return lk.token
+}
+
+// Expires 返回锁在本地估计的过期时间
+func (lk *Lock) Expires() time.Time {
+	lk.mu.Lock()
+	defer lk.mu.Unlock()
+	return lk.expires
+}
+
+// Extend 将锁的剩余持有时间重置为 ttl，之后的自动续期也使用该 ttl
+// 锁已过期并被其他持有者获取或已释放时返回 ErrNotHeld
+func (lk *Lock) Extend(ctx context.Context, ttl time.Duration) error {
+	if ttl <= 0 {
+		return fmt.Errorf("lock: invalid ttl %s", ttl)
+	}
+	lk.mu.Lock()
+	released := lk.released
+	lk.mu.Unlock()
+	if released {
+		return fmt.Errorf("%w: %s", ErrNotHeld, lk.key)
+	}
+
+	start := time.Now()
+	ok, err := lk.locker.backend.Extend(ctx, lk.locker.prefix+lk.key, lk.token, ttl)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNotHeld, lk.key)
+	}
+	lk.mu.Lock()
+	lk.ttl = ttl
+	lk.expires = start.Add(ttl)
+	lk.mu.Unlock()
+	return nil
+}
+
+// Release 停止自动续期并释放锁，锁已过期并被其他持有者获取或重复释放时返回 ErrNotHeld
+func (lk *Lock) Release(ctx context.Context) error {
+	lk.mu.Lock()
+	if lk.released {
+		lk.mu.Unlock()
+		return fmt.Errorf("%w: %s", ErrNotHeld, lk.key)
+	}
+	lk.released = true
+	stop, done := lk.stop, lk.done
+	lk.mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+
+	ok, err := lk.locker.backend.Release(ctx, lk.locker.prefix+lk.key, lk.token)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNotHeld, lk.key)
+	}
+	return nil
+}
+
+// AutoRenew 在后台每隔 ttl/3 续期一次，直到 Release 被调用，适用于执行时间无法预估的持有者
+// 返回的通道在锁丢失时关闭：锁已被其他持有者获取，或续期持续失败直到锁过期；多次调用返回同一个通道
+func (lk *Lock) AutoRenew() <-chan struct{} {
+	lk.mu.Lock()
+	defer lk.mu.Unlock()
+	if lk.lost != nil {
+		return lk.lost
+	}
+	lk.lost = make(chan struct{})
+	if lk.released {
+		close(lk.lost)
+		return lk.lost
+	}
+	lk.stop = make(chan struct{})
+	lk.done = make(chan struct{})
+	go lk.renew()
+	return lk.lost
+}
+
+func (lk *Lock) renew() {
+	defer close(lk.done)
+	for {
+		lk.mu.Lock()
+		ttl, expires := lk.ttl, lk.expires
+		lk.mu.Unlock()
+
+		interval := ttl / 3
+		timer := time.NewTimer(interval)
+		select {
+		case <-lk.stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		start := time.Now()
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		ok, err := lk.locker.backend.Extend(ctx, lk.locker.prefix+lk.key, lk.token, ttl)
+		cancel()
+		switch {
+		case err == nil && ok:
+			lk.mu.Lock()
+			lk.expires = start.Add(ttl)
+			lk.mu.Unlock()
+		case err == nil || !time.Now().Before(expires):
+			close(lk.lost)
+			return
+		}
+	}
+}
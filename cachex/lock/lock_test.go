@@ -0,0 +1,203 @@
+package lock_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yu1ec/go-pkg/cachex/driver"
+	"github.com/yu1ec/go-pkg/cachex/driver/memory/gocache"
+	"github.com/yu1ec/go-pkg/cachex/driver/redis"
+	"github.com/yu1ec/go-pkg/cachex/lock"
+)
+
+// backend 测试用的锁后端，expire 使锁经过 d 时长
+type backend struct {
+	locker *lock.Locker
+	expire func(d time.Duration)
+}
+
+func backends(t *testing.T) map[string]backend {
+	mr := miniredis.RunT(t)
+	d, err := redis.New(&redis.RedisConfig{Addr: mr.Addr()})
+	require.NoError(t, err)
+	t.Cleanup(func() { d.(io.Closer).Close() })
+
+	opts := []lock.Option{lock.WithBackoff(time.Millisecond, 5*time.Millisecond)}
+	redisLocker, err := lock.NewDriver(d, opts...)
+	require.NoError(t, err)
+	return map[string]backend{
+		"memory": {locker: lock.NewMemory(opts...), expire: time.Sleep},
+		"redis":  {locker: redisLocker, expire: mr.FastForward},
+	}
+}
+
+func TestLock(t *testing.T) {
+	ctx := context.Background()
+	for name, b := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			lk, err := b.locker.TryLock(ctx, "job", time.Second)
+			require.NoError(t, err)
+			assert.Equal(t, "job", lk.Key())
+			assert.NotEmpty(t, lk.Token())
+
+			_, err = b.locker.TryLock(ctx, "job", time.Second)
+			assert.ErrorIs(t, err, lock.ErrNotAcquired)
+
+			assert.NoError(t, lk.Extend(ctx, time.Second))
+			assert.NoError(t, lk.Release(ctx))
+			assert.ErrorIs(t, lk.Release(ctx), lock.ErrNotHeld)
+
+			lk, err = b.locker.TryLock(ctx, "job", time.Second)
+			require.NoError(t, err)
+			assert.NoError(t, lk.Release(ctx))
+		})
+	}
+}
+
+func TestLockOwnerToken(t *testing.T) {
+	ctx := context.Background()
+	for name, b := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			stale, err := b.locker.TryLock(ctx, "job", 20*time.Millisecond)
+			require.NoError(t, err)
+			b.expire(30 * time.Millisecond)
+
+			current, err := b.locker.TryLock(ctx, "job", time.Second)
+			require.NoError(t, err)
+
+			// 过期的持有者不能续期或释放新持有者的锁
+			assert.ErrorIs(t, stale.Extend(ctx, time.Second), lock.ErrNotHeld)
+			assert.ErrorIs(t, stale.Release(ctx), lock.ErrNotHeld)
+
+			_, err = b.locker.TryLock(ctx, "job", time.Second)
+			assert.ErrorIs(t, err, lock.ErrNotAcquired)
+			assert.NoError(t, current.Release(ctx))
+		})
+	}
+}
+
+func TestLockBlocking(t *testing.T) {
+	ctx := context.Background()
+	for name, b := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			held, err := b.locker.TryLock(ctx, "job", time.Second)
+			require.NoError(t, err)
+
+			timeoutCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+			defer cancel()
+			_, err = b.locker.Lock(timeoutCtx, "job", time.Second)
+			assert.ErrorIs(t, err, lock.ErrNotAcquired)
+			assert.True(t, errors.Is(err, context.DeadlineExceeded))
+
+			go func() {
+				time.Sleep(20 * time.Millisecond)
+				held.Release(ctx)
+			}()
+			lk, err := b.locker.Lock(ctx, "job", time.Second)
+			require.NoError(t, err)
+			assert.NoError(t, lk.Release(ctx))
+		})
+	}
+}
+
+func TestLockAutoRenew(t *testing.T) {
+	ctx := context.Background()
+	locker := lock.NewMemory()
+
+	lk, err := locker.TryLock(ctx, "job", 30*time.Millisecond)
+	require.NoError(t, err)
+	lost := lk.AutoRenew()
+
+	time.Sleep(100 * time.Millisecond)
+	select {
+	case <-lost:
+		t.Fatal("lock lost while renewing")
+	default:
+	}
+	_, err = locker.TryLock(ctx, "job", time.Second)
+	assert.ErrorIs(t, err, lock.ErrNotAcquired)
+
+	assert.NoError(t, lk.Release(ctx))
+	lk, err = locker.TryLock(ctx, "job", time.Second)
+	require.NoError(t, err)
+	assert.NoError(t, lk.Release(ctx))
+}
+
+func TestLockAutoRenewLost(t *testing.T) {
+	ctx := context.Background()
+	backend := lock.NewMemoryBackend()
+	locker := lock.New(backend)
+
+	lk, err := locker.TryLock(ctx, "job", 30*time.Millisecond)
+	require.NoError(t, err)
+	lost := lk.AutoRenew()
+
+	// 模拟锁被强制释放
+	_, err = backend.Release(ctx, lock.DefaultPrefix+"job", lk.Token())
+	require.NoError(t, err)
+
+	select {
+	case <-lost:
+	case <-time.After(time.Second):
+		t.Fatal("lost channel not closed")
+	}
+	assert.ErrorIs(t, lk.Release(ctx), lock.ErrNotHeld)
+}
+
+func TestDriverBackend(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	d, err := redis.New(&redis.RedisConfig{Addr: mr.Addr(), Prefix: "app:"})
+	require.NoError(t, err)
+	defer d.(io.Closer).Close()
+
+	locker, err := lock.NewDriver(d)
+	require.NoError(t, err)
+
+	// 锁键加上驱动的前缀，与驱动的 TryLock 互斥
+	lk, err := locker.TryLock(ctx, "job", time.Second)
+	require.NoError(t, err)
+	assert.True(t, mr.Exists("app:"+lock.DefaultPrefix+"job"))
+	_, ok, err := d.(driver.Locker).TryLock(ctx, "job", time.Second)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.NoError(t, lk.Release(ctx))
+
+	// 连接类错误包装为 driver.ErrUnavailable
+	mr.Close()
+	_, err = locker.TryLock(ctx, "job", time.Second)
+	assert.ErrorIs(t, err, driver.ErrUnavailable)
+
+	memory, err := gocache.New(nil)
+	require.NoError(t, err)
+	_, err = lock.NewDriver(memory)
+	assert.ErrorIs(t, err, driver.ErrNotSupported)
+}
+
+func TestMemoryBackendPurge(t *testing.T) {
+	ctx := context.Background()
+	backend := lock.NewMemoryBackend()
+
+	for i := 0; i < 100; i++ {
+		ok, err := backend.Acquire(ctx, fmt.Sprintf("job:%d", i), "token", time.Millisecond)
+		require.NoError(t, err)
+		require.True(t, ok)
+	}
+	time.Sleep(5 * time.Millisecond)
+	backend.Purge()
+	assert.Equal(t, 0, backend.Len())
+
+	// 锁的数量增长时自动清理已过期的锁
+	for i := 0; i < 1000; i++ {
+		_, err := backend.Acquire(ctx, fmt.Sprintf("job:%d", i), "token", time.Nanosecond)
+		require.NoError(t, err)
+	}
+	assert.Less(t, backend.Len(), 100)
+}
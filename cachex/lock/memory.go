@@ -0,0 +1,116 @@
+package lock
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// minPurgeSize Acquire 自动清理过期锁的最小锁数量
+const minPurgeSize = 64
+
+// MemoryBackend 进程内的 Backend 实现，语义与 DriverBackend 相同，适用于单实例部署与测试
+// 锁的数量达到上次清理后的两倍时，Acquire 会清理已过期的锁，也可以调用 Purge 主动清理
+type MemoryBackend struct {
+	mu    sync.Mutex
+	locks map[string]memoryLock
+	// purgeAt 锁的数量达到该值时清理过期的锁
+	purgeAt int
+}
+
+type memoryLock struct {
+	token   string
+	expires time.Time
+}
+
+// NewMemoryBackend 创建进程内的锁后端
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{locks: make(map[string]memoryLock), purgeAt: minPurgeSize}
+}
+
+// NewMemory 创建使用进程内后端的 Locker
+func NewMemory(opts ...Option) *Locker {
+	return New(NewMemoryBackend(), opts...)
+}
+
+// held 返回 key 当前是否由 token 持有，已过期的锁会被删除，调用方需要持有锁
+func (m *MemoryBackend) held(key, token string, now time.Time) bool {
+	l, ok := m.locks[key]
+	if ok && !now.Before(l.expires) {
+		delete(m.locks, key)
+		return false
+	}
+	return ok && l.token == token
+}
+
+func (m *MemoryBackend) Acquire(ctx context.Context, key, token string, ttl time.Duration) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	m.held(key, token, now)
+	if _, ok := m.locks[key]; ok {
+		return false, nil
+	}
+	m.locks[key] = memoryLock{token: token, expires: now.Add(ttl)}
+	if len(m.locks) >= m.purgeAt {
+		m.purge(now)
+	}
+	return true, nil
+}
+
+// Purge 删除所有已过期的锁
+func (m *MemoryBackend) Purge() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.purge(time.Now())
+}
+
+// purge 删除已过期的锁并更新下次清理的阈值，调用方需要持有锁
+func (m *MemoryBackend) purge(now time.Time) {
+	for key, l := range m.locks {
+		if !now.Before(l.expires) {
+			delete(m.locks, key)
+		}
+	}
+	m.purgeAt = max(2*len(m.locks), minPurgeSize)
+}
+
+// Len 返回当前记录的锁数量，包括已过期但尚未清理的锁
+func (m *MemoryBackend) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.locks)
+}
+
+func (m *MemoryBackend) Extend(ctx context.Context, key, token string, ttl time.Duration) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if !m.held(key, token, now) {
+		return false, nil
+	}
+	m.locks[key] = memoryLock{token: token, expires: now.Add(ttl)}
+	return true, nil
+}
+
+func (m *MemoryBackend) Release(ctx context.Context, key, token string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.held(key, token, time.Now()) {
+		return false, nil
+	}
+	delete(m.locks, key)
+	return true, nil
+}
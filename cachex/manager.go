@@ -0,0 +1,241 @@
+package cachex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/yu1ec/go-pkg/cachex/driver"
+)
+
+// healthKey 驱动没有实现 driver.Pinger 时，健康检查读取的键
+const healthKey = "cachex:health"
+
+var (
+	// ErrStoreNotFound Manager 中没有配置该名称的缓存
+	ErrStoreNotFound = errors.New("cachex: store not found")
+
+	// ErrManagerClosed Manager 已关闭
+	ErrManagerClosed = errors.New("cachex: manager closed")
+)
+
+// StoreConfig 单个缓存的配置，DSN 与 Driver 二选一
+type StoreConfig struct {
+	// DSN 见 Open，如 redis://host:6379/0?codec=json
+	DSN string `json:"dsn,omitempty" yaml:"dsn,omitempty"`
+	// Driver 驱动名称，Config 为传给驱动的配置，同 New
+	// 从 JSON、YAML 读取时 Config 为 map，适用于 memory 驱动；yaml.v2 解码得到的 map[any]any 会转换为 map[string]any
+	Driver string `json:"driver,omitempty" yaml:"driver,omitempty"`
+	Config any    `json:"config,omitempty" yaml:"config,omitempty"`
+	// Prefix 键的命名空间前缀，同 WithPrefix
+	Prefix string `json:"prefix,omitempty" yaml:"prefix,omitempty"`
+	// Options 其他配置项，只能在代码中设置
+	Options []Option `json:"-" yaml:"-"`
+}
+
+// ManagerConfig Manager 的配置
+type ManagerConfig struct {
+	// Default 默认缓存的名称，只配置了一个缓存时可以为空
+	Default string `json:"default,omitempty" yaml:"default,omitempty"`
+	// Stores 缓存名称到配置的映射
+	Stores map[string]StoreConfig `json:"stores" yaml:"stores"`
+}
+
+// Manager 管理多个命名的缓存，缓存在第一次使用时创建，Close 时关闭所有已创建缓存的底层连接
+type Manager struct {
+	defaultName string
+	stores      map[string]*store
+	closed      atomic.Bool
+}
+
+type store struct {
+	cfg StoreConfig
+	// mu 保证同一个缓存只创建一次，创建失败时下次使用会重试
+	mu    sync.Mutex
+	cache *cacheImpl
+}
+
+// NewManager 校验配置并创建 Manager，此时不会连接任何缓存服务
+func NewManager(cfg ManagerConfig) (*Manager, error) {
+	if len(cfg.Stores) == 0 {
+		return nil, fmt.Errorf("%w: no stores configured", ErrInvalidConfig)
+	}
+
+	m := &Manager{defaultName: cfg.Default, stores: make(map[string]*store, len(cfg.Stores))}
+	for name, sc := range cfg.Stores {
+		if (sc.DSN == "") == (sc.Driver == "") {
+			return nil, fmt.Errorf("%w: store %s must set exactly one of dsn and driver", ErrInvalidConfig, name)
+		}
+		m.stores[name] = &store{cfg: sc}
+	}
+
+	if m.defaultName == "" {
+		if len(m.stores) > 1 {
+			return nil, fmt.Errorf("%w: default store is required when multiple stores are configured", ErrInvalidConfig)
+		}
+		for name := range m.stores {
+			m.defaultName = name
+		}
+	}
+	if _, ok := m.stores[m.defaultName]; !ok {
+		return nil, fmt.Errorf("%w: default store %s is not configured", ErrInvalidConfig, m.defaultName)
+	}
+	return m, nil
+}
+
+// Store 返回指定名称的缓存，第一次调用时创建
+func (m *Manager) Store(name string) (Cache, error) {
+	c, err := m.store(name)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Default 返回默认缓存
+func (m *Manager) Default() (Cache, error) {
+	return m.Store(m.defaultName)
+}
+
+// DefaultName 返回默认缓存的名称
+func (m *Manager) DefaultName() string {
+	return m.defaultName
+}
+
+// Names 返回所有配置的缓存名称，按名称排序
+func (m *Manager) Names() []string {
+	names := make([]string, 0, len(m.stores))
+	for name := range m.stores {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (m *Manager) store(name string) (*cacheImpl, error) {
+	s, ok := m.stores[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrStoreNotFound, name)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if m.closed.Load() {
+		return nil, ErrManagerClosed
+	}
+	if s.cache != nil {
+		return s.cache, nil
+	}
+
+	var (
+		d   driver.Driver
+		err error
+	)
+	if s.cfg.DSN != "" {
+		d, err = driver.Open(s.cfg.DSN)
+	} else {
+		d, err = driver.New(s.cfg.Driver, normalizeConfig(s.cfg.Config))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cachex: store %s: %w", name, err)
+	}
+
+	opts := s.cfg.Options
+	if s.cfg.Prefix != "" {
+		opts = append([]Option{WithPrefix(s.cfg.Prefix)}, opts...)
+	}
+	s.cache = newCache(d, opts...)
+	return s.cache, nil
+}
+
+// normalizeConfig 将 yaml.v2 解码得到的 map[any]any（包括嵌套的）转换为 map[string]any
+func normalizeConfig(v any) any {
+	switch c := v.(type) {
+	case map[any]any:
+		m := make(map[string]any, len(c))
+		for k, val := range c {
+			m[fmt.Sprint(k)] = normalizeConfig(val)
+		}
+		return m
+	case map[string]any:
+		m := make(map[string]any, len(c))
+		for k, val := range c {
+			m[k] = normalizeConfig(val)
+		}
+		return m
+	case []any:
+		s := make([]any, len(c))
+		for i, val := range c {
+			s[i] = normalizeConfig(val)
+		}
+		return s
+	default:
+		return v
+	}
+}
+
+// Ping 检查指定名称的缓存是否可用，缓存尚未创建时会先创建
+// 驱动实现了 driver.Pinger 时调用其 Ping，否则读取一个探测键
+func (m *Manager) Ping(ctx context.Context, name string) error {
+	c, err := m.store(name)
+	if err != nil {
+		return err
+	}
+	if pinger, ok := driver.As[driver.Pinger](c.driver); ok {
+		if err := pinger.Ping(ctx); !errors.Is(err, ErrNotSupported) {
+			return err
+		}
+	}
+	if _, err := c.driver.GetCtx(ctx, healthKey); err != nil && !errors.Is(err, ErrCacheMiss) {
+		return err
+	}
+	return nil
+}
+
+// Health 并发检查所有缓存，返回缓存名称到检查结果的映射，可用的缓存对应 nil
+func (m *Manager) Health(ctx context.Context) map[string]error {
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[string]error, len(m.stores))
+	)
+	for name := range m.stores {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			err := m.Ping(ctx, name)
+			mu.Lock()
+			results[name] = err
+			mu.Unlock()
+		}(name)
+	}
+	wg.Wait()
+	return results
+}
+
+// Close 关闭所有已创建的缓存中实现了 io.Closer 的驱动（如 Redis 客户端），之后 Store 返回 ErrManagerClosed
+// 多次调用是安全的，返回所有关闭错误的合并
+func (m *Manager) Close() error {
+	if m.closed.Swap(true) {
+		return nil
+	}
+	var errs []error
+	for _, name := range m.Names() {
+		s := m.stores[name]
+		s.mu.Lock()
+		if s.cache != nil {
+			if closer, ok := s.cache.driver.(io.Closer); ok {
+				if err := closer.Close(); err != nil {
+					errs = append(errs, fmt.Errorf("cachex: close store %s: %w", name, err))
+				}
+			}
+			s.cache = nil
+		}
+		s.mu.Unlock()
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,152 @@
+package cachex_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/yu1ec/go-pkg/cachex"
+	"gopkg.in/yaml.v3"
+)
+
+func newManager(t *testing.T, mr *miniredis.Miniredis) *cachex.Manager {
+	data := `{
+		"default": "local",
+		"stores": {
+			"local": {"driver": "memory", "config": {"implementation": "lru", "max_entries": 100, "default_expiration": "1m"}},
+			"shared": {"dsn": "redis://` + mr.Addr() + `/0?codec=json", "prefix": "app:"}
+		}
+	}`
+	var cfg cachex.ManagerConfig
+	if err := json.Unmarshal([]byte(data), &cfg); err != nil {
+		t.Fatalf("failed to decode manager config: %v", err)
+	}
+	m, err := cachex.NewManager(cfg)
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+	t.Cleanup(func() { m.Close() })
+	return m
+}
+
+func TestManager(t *testing.T) {
+	mr := miniredis.RunT(t)
+	m := newManager(t, mr)
+
+	assert.Equal(t, "local", m.DefaultName())
+	assert.Equal(t, []string{"local", "shared"}, m.Names())
+
+	local, err := m.Default()
+	assert.NoError(t, err)
+	local.Put("k", "v", 60)
+	again, err := m.Store("local")
+	assert.NoError(t, err)
+	assert.Same(t, local, again)
+	assert.True(t, again.Exists("k"))
+
+	shared, err := m.Store("shared")
+	assert.NoError(t, err)
+	shared.Put("k", "v", 60)
+	assert.True(t, mr.Exists("app:k"))
+	assert.False(t, mr.Exists("k"))
+
+	_, err = m.Store("missing")
+	assert.ErrorIs(t, err, cachex.ErrStoreNotFound)
+}
+
+func TestManagerYAMLConfig(t *testing.T) {
+	data := `
+default: local
+stores:
+  local:
+    driver: memory
+    config:
+      implementation: lfu
+      max_entries: 100
+      default_expiration: 1m
+    prefix: "app:"
+`
+	var cfg cachex.ManagerConfig
+	assert.NoError(t, yaml.Unmarshal([]byte(data), &cfg))
+	m, err := cachex.NewManager(cfg)
+	assert.NoError(t, err)
+	defer m.Close()
+
+	local, err := m.Default()
+	assert.NoError(t, err)
+	local.Put("k", "v", 60)
+	assert.True(t, local.Exists("k"))
+
+	// yaml.v2 解码得到的 map[any]any 同样可用
+	m2, err := cachex.NewManager(cachex.ManagerConfig{Stores: map[string]cachex.StoreConfig{
+		"local": {Driver: "memory", Config: map[any]any{"implementation": "lru", "max_entries": 100}},
+	}})
+	assert.NoError(t, err)
+	defer m2.Close()
+	_, err = m2.Default()
+	assert.NoError(t, err)
+}
+
+func TestManagerLazyConstruction(t *testing.T) {
+	m, err := cachex.NewManager(cachex.ManagerConfig{
+		Default: "local",
+		Stores: map[string]cachex.StoreConfig{
+			"local": {Driver: "memory", Config: map[string]any{}},
+			"down":  {DSN: "redis://127.0.0.1:1/0?dial_timeout=100ms"},
+		},
+	})
+	assert.NoError(t, err)
+	defer m.Close()
+
+	_, err = m.Store("down")
+	assert.ErrorIs(t, err, cachex.ErrUnavailable)
+
+	health := m.Health(context.Background())
+	assert.NoError(t, health["local"])
+	assert.ErrorIs(t, health["down"], cachex.ErrUnavailable)
+}
+
+func TestManagerHealth(t *testing.T) {
+	mr := miniredis.RunT(t)
+	m := newManager(t, mr)
+
+	assert.NoError(t, m.Ping(context.Background(), "shared"))
+	mr.Close()
+	health := m.Health(context.Background())
+	assert.NoError(t, health["local"])
+	assert.ErrorIs(t, health["shared"], cachex.ErrUnavailable)
+}
+
+func TestManagerClose(t *testing.T) {
+	mr := miniredis.RunT(t)
+	m := newManager(t, mr)
+
+	shared, err := m.Store("shared")
+	assert.NoError(t, err)
+	assert.NoError(t, m.Close())
+	assert.NoError(t, m.Close())
+
+	assert.ErrorIs(t, shared.PutCtx(context.Background(), "k", "v", 60), cachex.ErrUnavailable)
+	_, err = m.Store("shared")
+	assert.ErrorIs(t, err, cachex.ErrManagerClosed)
+}
+
+func TestManagerConfigValidation(t *testing.T) {
+	memory := cachex.StoreConfig{Driver: "memory"}
+	for name, cfg := range map[string]cachex.ManagerConfig{
+		"no stores":          {},
+		"no default":         {Stores: map[string]cachex.StoreConfig{"a": memory, "b": memory}},
+		"unknown default":    {Default: "c", Stores: map[string]cachex.StoreConfig{"a": memory}},
+		"dsn and driver":     {Stores: map[string]cachex.StoreConfig{"a": {DSN: "memory://", Driver: "memory"}}},
+		"neither dsn/driver": {Stores: map[string]cachex.StoreConfig{"a": {}}},
+	} {
+		_, err := cachex.NewManager(cfg)
+		assert.ErrorIs(t, err, cachex.ErrInvalidConfig, name)
+	}
+
+	m, err := cachex.NewManager(cachex.ManagerConfig{Stores: map[string]cachex.StoreConfig{"only": memory}})
+	assert.NoError(t, err)
+	assert.Equal(t, "only", m.DefaultName())
+}
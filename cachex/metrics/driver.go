@@ -0,0 +1,517 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/yu1ec/go-pkg/cachex/driver"
+)
+
+// Wrapped 是 Wrap 返回的驱动，Close 取消删除事件的订阅并关闭被包装的驱动，Unwrap 返回被包装的驱动
+// 被包装驱动实现的每个可选接口都可以通过 driver.As 获取，调用同样会被采集指标
+type Wrapped interface {
+	driver.Driver
+	driver.Capabilities
+	io.Closer
+	Unwrap() driver.Driver
+}
+
+// Driver 是采集指标的驱动装饰器，所有操作转发给被包装的驱动
+type Driver struct {
+	name     string
+	next     driver.Driver
+	stats    Stats
+	removals bool
+	cancel   func()
+}
+
+// Option Wrap 的配置项
+type Option func(*Driver)
+
+// WithRemovalEvents 订阅被包装驱动的删除事件以统计过期与淘汰，驱动需要实现 driver.Notifier
+// Redis 驱动会因此占用一个订阅连接并可能修改 notify-keyspace-events，因此默认不订阅
+func WithRemovalEvents() Option {
+	return func(m *Driver) {
+		m.removals = true
+	}
+}
+
+// Wrap 包装驱动，name 为指标中的缓存名称
+// 返回的驱动只实现 Wrapped，被包装的驱动实现的可选接口需要通过 driver.As 获取，不能直接类型断言
+func Wrap(name string, d driver.Driver, stats Stats, opts ...Option) Wrapped {
+	m := &Driver{name: name, next: d, stats: stats}
+	for _, opt := range opts {
+		opt(m)
+	}
+	if notifier, ok := driver.As[driver.Notifier](d); ok && m.removals {
+		cancel, err := notifier.OnRemoval(func(e driver.RemovalEvent) {
+			if e.Reason != driver.RemovalDeleted {
+				stats.ObserveRemoval(name, e.Reason)
+			}
+		})
+		if err == nil {
+			m.cancel = cancel
+		}
+	}
+	return m
+}
+
+// Capability 实现 driver.Capabilities 接口，被包装的驱动支持 target 指向的可选接口时写入转发该接口并采集指标的实现
+func (m *Driver) Capability(target any) bool {
+	return capability(m, target, func(next driver.PrefixFlusher) driver.PrefixFlusher { return prefixFlusherForwarder{m, next} }) ||
+		capability(m, target, func(next driver.Updater) driver.Updater { return updaterForwarder{m, next} }) ||
+		capability(m, target, func(next driver.FloatOperations) driver.FloatOperations { return floatOperationsForwarder{m, next} }) ||
+		capability(m, target, func(next driver.Counter) driver.Counter { return counterForwarder{m, next} }) ||
+		capability(m, target, func(next driver.Scanner) driver.Scanner { return scannerForwarder{m, next} }) ||
+		capability(m, target, func(next driver.Tagger) driver.Tagger { return taggerForwarder{m, next} }) ||
+		capability(m, target, func(next driver.Notifier) driver.Notifier { return notifierForwarder{m, next} }) ||
+		capability(m, target, func(next driver.Locker) driver.Locker { return lockerForwarder{m, next} }) ||
+		capability(m, target, func(next driver.ValueDecoder) driver.ValueDecoder { return valueDecoderForwarder{m, next} }) ||
+		capability(m, target, func(next driver.Pinger) driver.Pinger { return pingerForwarder{m, next} }) ||
+		capability(m, target, func(next driver.TokenLocker) driver.TokenLocker { return tokenLockerForwarder{m, next} })
+}
+
+// capability target 指向 T 且被包装的驱动实现了 T 时，将 wrap 返回的实现写入 target
+func capability[T any](m *Driver, target any, wrap func(next T) T) bool {
+	p, ok := target.(*T)
+	if !ok {
+		return false
+	}
+	next, ok := driver.As[T](m.next)
+	if !ok {
+		return false
+	}
+	*p = wrap(next)
+	return true
+}
+
+// Unwrap 返回被包装的驱动
+func (m *Driver) Unwrap() driver.Driver {
+	return m.next
+}
+
+// Close 取消删除事件的订阅，被包装的驱动实现了 io.Closer 时一并关闭
+func (m *Driver) Close() error {
+	if m.cancel != nil {
+		m.cancel()
+	}
+	if closer, ok := m.next.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+func (m *Driver) observe(op Op, start time.Time, o Observation) {
+	o.Cache = m.name
+	o.Op = op
+	o.Duration = time.Since(start)
+	if errors.Is(o.Err, driver.ErrCacheMiss) || errors.Is(o.Err, driver.ErrKeyExists) {
+		o.Err = nil
+	}
+	m.stats.ObserveOperation(o)
+}
+
+// lookup 根据读取结果生成观测值
+func lookup(err error) Observation {
+	if err == nil {
+		return Observation{Hits: 1}
+	}
+	if errors.Is(err, driver.ErrCacheMiss) {
+		return Observation{Misses: 1}
+	}
+	return Observation{Err: err}
+}
+
+// written 根据写入结果生成观测值
+func written(n int, err error) Observation {
+	if err != nil {
+		return Observation{Err: err}
+	}
+	return Observation{Sets: n}
+}
+
+// 实现 BaseDriver 接口
+func (m *Driver) Add(k string, v any, d time.Duration) error {
+	start := time.Now()
+	err := m.next.Add(k, v, d)
+	m.observe(OpAdd, start, written(1, err))
+	return err
+}
+
+func (m *Driver) Delete(k string) {
+	start := time.Now()
+	m.next.Delete(k)
+	m.observe(OpDelete, start, Observation{Deletes: 1})
+}
+
+func (m *Driver) DeleteExpired() {
+	m.next.DeleteExpired()
+}
+
+func (m *Driver) Flush() {
+	start := time.Now()
+	m.next.Flush()
+	m.observe(OpFlush, start, Observation{})
+}
+
+func (m *Driver) Get(k string) (any, bool) {
+	start := time.Now()
+	v, found := m.next.Get(k)
+	if found {
+		m.observe(OpGet, start, Observation{Hits: 1})
+	} else {
+		m.observe(OpGet, start, Observation{Misses: 1})
+	}
+	return v, found
+}
+
+func (m *Driver) GetWithExpiration(k string) (any, time.Time, bool) {
+	start := time.Now()
+	v, expiration, found := m.next.GetWithExpiration(k)
+	if found {
+		m.observe(OpGet, start, Observation{Hits: 1})
+	} else {
+		m.observe(OpGet, start, Observation{Misses: 1})
+	}
+	return v, expiration, found
+}
+
+func (m *Driver) Replace(k string, x any, d time.Duration) error {
+	start := time.Now()
+	err := m.next.Replace(k, x, d)
+	m.observe(OpReplace, start, written(1, err))
+	return err
+}
+
+func (m *Driver) Set(k string, x any, d time.Duration) {
+	start := time.Now()
+	m.next.Set(k, x, d)
+	m.observe(OpSet, start, Observation{Sets: 1})
+}
+
+func (m *Driver) SetDefault(k string, x any) {
+	start := time.Now()
+	m.next.SetDefault(k, x)
+	m.observe(OpSet, start, Observation{Sets: 1})
+}
+
+func (m *Driver) GetMany(keys []string) (map[string]any, []string) {
+	start := time.Now()
+	found, missing := m.next.GetMany(keys)
+	m.observe(OpGetMany, start, Observation{Hits: len(found), Misses: len(missing)})
+	return found, missing
+}
+
+func (m *Driver) SetMany(items map[string]any, d time.Duration) {
+	start := time.Now()
+	m.next.SetMany(items, d)
+	m.observe(OpSetMany, start, Observation{Sets: len(items)})
+}
+
+func (m *Driver) DeleteMany(keys []string) {
+	start := time.Now()
+	m.next.DeleteMany(keys)
+	m.observe(OpDeleteMany, start, Observation{Deletes: len(keys)})
+}
+
+// 实现 ContextDriver 接口
+func (m *Driver) AddCtx(ctx context.Context, k string, v any, d time.Duration) error {
+	start := time.Now()
+	err := m.next.AddCtx(ctx, k, v, d)
+	m.observe(OpAdd, start, written(1, err))
+	return err
+}
+
+func (m *Driver) DeleteCtx(ctx context.Context, k string) error {
+	start := time.Now()
+	err := m.next.DeleteCtx(ctx, k)
+	m.observe(OpDelete, start, Observation{Deletes: 1, Err: err})
+	return err
+}
+
+func (m *Driver) FlushCtx(ctx context.Context) error {
+	start := time.Now()
+	err := m.next.FlushCtx(ctx)
+	m.observe(OpFlush, start, Observation{Err: err})
+	return err
+}
+
+func (m *Driver) GetCtx(ctx context.Context, k string) (any, error) {
+	start := time.Now()
+	v, err := m.next.GetCtx(ctx, k)
+	m.observe(OpGet, start, lookup(err))
+	return v, err
+}
+
+func (m *Driver) GetWithExpirationCtx(ctx context.Context, k string) (any, time.Time, error) {
+	start := time.Now()
+	v, expiration, err := m.next.GetWithExpirationCtx(ctx, k)
+	m.observe(OpGet, start, lookup(err))
+	return v, expiration, err
+}
+
+func (m *Driver) ReplaceCtx(ctx context.Context, k string, x any, d time.Duration) error {
+	start := time.Now()
+	err := m.next.ReplaceCtx(ctx, k, x, d)
+	m.observe(OpReplace, start, written(1, err))
+	return err
+}
+
+func (m *Driver) SetCtx(ctx context.Context, k string, x any, d time.Duration) error {
+	start := time.Now()
+	err := m.next.SetCtx(ctx, k, x, d)
+	m.observe(OpSet, start, written(1, err))
+	return err
+}
+
+func (m *Driver) SetDefaultCtx(ctx context.Context, k string, x any) error {
+	start := time.Now()
+	err := m.next.SetDefaultCtx(ctx, k, x)
+	m.observe(OpSet, start, written(1, err))
+	return err
+}
+
+func (m *Driver) GetManyCtx(ctx context.Context, keys []string) (map[string]any, []string, error) {
+	start := time.Now()
+	found, missing, err := m.next.GetManyCtx(ctx, keys)
+	m.observe(OpGetMany, start, Observation{Hits: len(found), Misses: len(missing), Err: err})
+	return found, missing, err
+}
+
+func (m *Driver) SetManyCtx(ctx context.Context, items map[string]any, d time.Duration) error {
+	start := time.Now()
+	err := m.next.SetManyCtx(ctx, items, d)
+	m.observe(OpSetMany, start, written(len(items), err))
+	return err
+}
+
+func (m *Driver) DeleteManyCtx(ctx context.Context, keys []string) error {
+	start := time.Now()
+	err := m.next.DeleteManyCtx(ctx, keys)
+	m.observe(OpDeleteMany, start, Observation{Deletes: len(keys), Err: err})
+	return err
+}
+
+// 实现 NumericOperations 接口
+func (m *Driver) IncrementInt(k string, n int) (int, error) {
+	start := time.Now()
+	v, err := m.next.IncrementInt(k, n)
+	m.observe(OpIncrement, start, Observation{Err: err})
+	return v, err
+}
+
+func (m *Driver) DecrementInt(k string, n int) (int, error) {
+	start := time.Now()
+	v, err := m.next.DecrementInt(k, n)
+	m.observe(OpDecrement, start, Observation{Err: err})
+	return v, err
+}
+
+func (m *Driver) IncrementInt64(k string, n int64) (int64, error) {
+	start := time.Now()
+	v, err := m.next.IncrementInt64(k, n)
+	m.observe(OpIncrement, start, Observation{Err: err})
+	return v, err
+}
+
+func (m *Driver) DecrementInt64(k string, n int64) (int64, error) {
+	start := time.Now()
+	v, err := m.next.DecrementInt64(k, n)
+	m.observe(OpDecrement, start, Observation{Err: err})
+	return v, err
+}
+
+func (m *Driver) IncrementUint(k string, n uint) (uint, error) {
+	start := time.Now()
+	v, err := m.next.IncrementUint(k, n)
+	m.observe(OpIncrement, start, Observation{Err: err})
+	return v, err
+}
+
+func (m *Driver) DecrementUint(k string, n uint) (uint, error) {
+	start := time.Now()
+	v, err := m.next.DecrementUint(k, n)
+	m.observe(OpDecrement, start, Observation{Err: err})
+	return v, err
+}
+
+func (m *Driver) IncrementUint64(k string, n uint64) (uint64, error) {
+	start := time.Now()
+	v, err := m.next.IncrementUint64(k, n)
+	m.observe(OpIncrement, start, Observation{Err: err})
+	return v, err
+}
+
+func (m *Driver) DecrementUint64(k string, n uint64) (uint64, error) {
+	start := time.Now()
+	v, err := m.next.DecrementUint64(k, n)
+	m.observe(OpDecrement, start, Observation{Err: err})
+	return v, err
+}
+
+// 以下类型各自转发一个可选接口，m 为采集指标的驱动，next 为被包装驱动对该接口的实现
+
+type prefixFlusherForwarder struct {
+	m    *Driver
+	next driver.PrefixFlusher
+}
+
+func (f prefixFlusherForwarder) FlushPrefix(ctx context.Context, prefix string) error {
+	start := time.Now()
+	err := f.next.FlushPrefix(ctx, prefix)
+	f.m.observe(OpFlush, start, Observation{Err: err})
+	return err
+}
+
+type updaterForwarder struct {
+	m    *Driver
+	next driver.Updater
+}
+
+func (u updaterForwarder) CompareAndSwap(ctx context.Context, k string, old, new any, d time.Duration) (bool, error) {
+	start := time.Now()
+	swapped, err := u.next.CompareAndSwap(ctx, k, old, new, d)
+	o := Observation{Err: err}
+	if swapped {
+		o.Sets = 1
+	}
+	u.m.observe(OpCAS, start, o)
+	return swapped, err
+}
+
+// Update fn 返回的错误是调用方的错误，不计入缓存错误
+func (u updaterForwarder) Update(ctx context.Context, k string, fn func(old any, exists bool) (any, error)) (any, error) {
+	var fnErr error
+	start := time.Now()
+	v, err := u.next.Update(ctx, k, func(old any, exists bool) (any, error) {
+		v, err := fn(old, exists)
+		fnErr = err
+		return v, err
+	})
+	if err != nil && fnErr != nil && errors.Is(err, fnErr) {
+		u.m.observe(OpUpdate, start, Observation{})
+	} else {
+		u.m.observe(OpUpdate, start, written(1, err))
+	}
+	return v, err
+}
+
+type floatOperationsForwarder struct {
+	m    *Driver
+	next driver.FloatOperations
+}
+
+func (f floatOperationsForwarder) IncrementFloat64(k string, n float64) (float64, error) {
+	start := time.Now()
+	v, err := f.next.IncrementFloat64(k, n)
+	f.m.observe(OpIncrement, start, Observation{Err: err})
+	return v, err
+}
+
+type counterForwarder struct {
+	m    *Driver
+	next driver.Counter
+}
+
+func (c counterForwarder) IncrementInt64WithTTL(ctx context.Context, k string, n int64, d time.Duration) (int64, error) {
+	start := time.Now()
+	v, err := c.next.IncrementInt64WithTTL(ctx, k, n, d)
+	c.m.observe(OpIncrement, start, Observation{Err: err})
+	return v, err
+}
+
+type scannerForwarder struct {
+	m    *Driver
+	next driver.Scanner
+}
+
+func (s scannerForwarder) Scan(pattern string, fn func(k string) bool) {
+	s.next.Scan(pattern, fn)
+}
+
+func (s scannerForwarder) Len() int {
+	return s.next.Len()
+}
+
+func (s scannerForwarder) ScanCtx(ctx context.Context, pattern string, fn func(k string) bool) error {
+	return s.next.ScanCtx(ctx, pattern, fn)
+}
+
+func (s scannerForwarder) LenCtx(ctx context.Context) (int, error) {
+	return s.next.LenCtx(ctx)
+}
+
+type taggerForwarder struct {
+	m    *Driver
+	next driver.Tagger
+}
+
+func (t taggerForwarder) TagKeys(ctx context.Context, tag string, d time.Duration, keys ...string) error {
+	return t.next.TagKeys(ctx, tag, d, keys...)
+}
+
+func (t taggerForwarder) TaggedKeys(ctx context.Context, tag string) ([]string, error) {
+	return t.next.TaggedKeys(ctx, tag)
+}
+
+func (t taggerForwarder) DeleteTag(ctx context.Context, tag string) error {
+	return t.next.DeleteTag(ctx, tag)
+}
+
+type notifierForwarder struct {
+	m    *Driver
+	next driver.Notifier
+}
+
+func (n notifierForwarder) OnRemoval(fn func(driver.RemovalEvent)) (func(), error) {
+	return n.next.OnRemoval(fn)
+}
+
+type lockerForwarder struct {
+	m    *Driver
+	next driver.Locker
+}
+
+func (l lockerForwarder) TryLock(ctx context.Context, k string, ttl time.Duration) (func(), bool, error) {
+	return l.next.TryLock(ctx, k, ttl)
+}
+
+type valueDecoderForwarder struct {
+	m    *Driver
+	next driver.ValueDecoder
+}
+
+func (v valueDecoderForwarder) GetInto(ctx context.Context, k string, dst any) error {
+	start := time.Now()
+	err := v.next.GetInto(ctx, k, dst)
+	v.m.observe(OpGet, start, lookup(err))
+	return err
+}
+
+type pingerForwarder struct {
+	m    *Driver
+	next driver.Pinger
+}
+
+func (p pingerForwarder) Ping(ctx context.Context) error {
+	return p.next.Ping(ctx)
+}
+
+type tokenLockerForwarder struct {
+	m    *Driver
+	next driver.TokenLocker
+}
+
+func (l tokenLockerForwarder) AcquireLock(ctx context.Context, k, token string, ttl time.Duration) (bool, error) {
+	return l.next.AcquireLock(ctx, k, token, ttl)
+}
+
+func (l tokenLockerForwarder) ExtendLock(ctx context.Context, k, token string, ttl time.Duration) (bool, error) {
+	return l.next.ExtendLock(ctx, k, token, ttl)
+}
+
+func (l tokenLockerForwarder) ReleaseLock(ctx context.Context, k, token string) (bool, error) {
+	return l.next.ReleaseLock(ctx, k, token)
+}
@@ -0,0 +1,171 @@
+package metrics
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/yu1ec/go-pkg/cachex/driver"
+)
+
+// DefaultBuckets 耗时直方图默认的桶上界，单位/秒
+var DefaultBuckets = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5}
+
+// Memory 在内存中汇总指标的 Stats 实现，通过 Snapshot 读取
+type Memory struct {
+	mu      sync.Mutex
+	buckets []float64
+	caches  map[string]*CacheStats
+}
+
+// NewMemory 创建内存指标汇总，buckets 为耗时直方图的桶上界（单位/秒），为空时使用 DefaultBuckets
+func NewMemory(buckets ...float64) *Memory {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	return &Memory{buckets: buckets, caches: make(map[string]*CacheStats)}
+}
+
+// CacheStats 单个缓存的指标
+type CacheStats struct {
+	Hits        uint64
+	Misses      uint64
+	Sets        uint64
+	Deletes     uint64
+	Evictions   uint64
+	Expirations uint64
+	Errors      uint64
+
+	// Loads Remember 加载函数的调用次数
+	Loads uint64
+	// LoadErrors 加载函数返回错误的次数
+	LoadErrors uint64
+
+	// Latency 每种操作的耗时分布
+	Latency map[Op]Histogram
+	// LoadLatency 加载函数的耗时分布
+	LoadLatency Histogram
+}
+
+// HitRate 返回命中率，没有读取时返回 0
+func (s CacheStats) HitRate() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+// Histogram 耗时直方图
+type Histogram struct {
+	// Buckets 桶上界，单位/秒
+	Buckets []float64
+	// Counts 每个桶中的观测次数（不累计），最后一个元素为超过所有上界的次数
+	Counts []uint64
+	Count  uint64
+	// Sum 所有观测值之和，单位/秒
+	Sum float64
+}
+
+func (h *Histogram) observe(d time.Duration) {
+	seconds := d.Seconds()
+	i := sort.SearchFloat64s(h.Buckets, seconds)
+	h.Counts[i]++
+	h.Count++
+	h.Sum += seconds
+}
+
+func (h Histogram) clone() Histogram {
+	h.Counts = append([]uint64(nil), h.Counts...)
+	return h
+}
+
+func (m *Memory) newHistogram() Histogram {
+	return Histogram{Buckets: m.buckets, Counts: make([]uint64, len(m.buckets)+1)}
+}
+
+// cache 返回缓存的指标，调用方需要持有锁
+func (m *Memory) cache(name string) *CacheStats {
+	s, ok := m.caches[name]
+	if !ok {
+		s = &CacheStats{Latency: make(map[Op]Histogram), LoadLatency: m.newHistogram()}
+		m.caches[name] = s
+	}
+	return s
+}
+
+// ObserveOperation 实现 Stats 接口
+func (m *Memory) ObserveOperation(o Observation) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s := m.cache(o.Cache)
+	s.Hits += uint64(o.Hits)
+	s.Misses += uint64(o.Misses)
+	s.Sets += uint64(o.Sets)
+	s.Deletes += uint64(o.Deletes)
+	if o.Err != nil {
+		s.Errors++
+	}
+
+	h, ok := s.Latency[o.Op]
+	if !ok {
+		h = m.newHistogram()
+	}
+	h.observe(o.Duration)
+	s.Latency[o.Op] = h
+}
+
+// ObserveLoad 实现 Stats 接口
+func (m *Memory) ObserveLoad(cache string, d time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s := m.cache(cache)
+	s.Loads++
+	if err != nil {
+		s.LoadErrors++
+	}
+	s.LoadLatency.observe(d)
+}
+
+// ObserveRemoval 实现 Stats 接口
+func (m *Memory) ObserveRemoval(cache string, reason driver.RemovalReason) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s := m.cache(cache)
+	switch reason {
+	case driver.RemovalEvicted:
+		s.Evictions++
+	case driver.RemovalExpired:
+		s.Expirations++
+	}
+}
+
+// Snapshot 返回所有缓存指标的副本
+func (m *Memory) Snapshot() map[string]CacheStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snapshot := make(map[string]CacheStats, len(m.caches))
+	for name, s := range m.caches {
+		c := *s
+		c.Latency = make(map[Op]Histogram, len(s.Latency))
+		for op, h := range s.Latency {
+			c.Latency[op] = h.clone()
+		}
+		c.LoadLatency = s.LoadLatency.clone()
+		snapshot[name] = c
+	}
+	return snapshot
+}
+
+// Reset 清空所有指标
+func (m *Memory) Reset() {
+	m.mu.Lock()
+	m.caches = make(map[string]*CacheStats)
+	m.mu.Unlock()
+}
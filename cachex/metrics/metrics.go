@@ -0,0 +1,56 @@
+// Package metrics 为缓存驱动提供命中率、耗时等指标的采集
+// 通过 Wrap 包装驱动，或使用 cachex.WithMetrics 为缓存开启指标采集
+package metrics
+
+import (
+	"time"
+
+	"github.com/yu1ec/go-pkg/cachex/driver"
+)
+
+// Op 缓存操作名称
+type Op string
+
+const (
+	OpGet        Op = "get"
+	OpSet        Op = "set"
+	OpAdd        Op = "add"
+	OpReplace    Op = "replace"
+	OpDelete     Op = "delete"
+	OpFlush      Op = "flush"
+	OpGetMany    Op = "get_many"
+	OpSetMany    Op = "set_many"
+	OpDeleteMany Op = "delete_many"
+	OpIncrement  Op = "increment"
+	OpDecrement  Op = "decrement"
+	OpCAS        Op = "compare_and_swap"
+	OpUpdate     Op = "update"
+)
+
+// Observation 一次缓存操作的观测结果
+type Observation struct {
+	// Cache 缓存名称
+	Cache string
+	Op    Op
+	// Hits 读取操作中命中的键数量
+	Hits int
+	// Misses 读取操作中未命中的键数量
+	Misses int
+	// Sets 写入的键数量
+	Sets int
+	// Deletes 删除的键数量
+	Deletes int
+	// Err 操作返回的错误，键不存在与键已存在不视为错误
+	Err      error
+	Duration time.Duration
+}
+
+// Stats 是指标的收集接口，实现需要保证并发安全
+type Stats interface {
+	// ObserveOperation 记录一次缓存操作
+	ObserveOperation(o Observation)
+	// ObserveLoad 记录一次 Remember 加载函数的调用
+	ObserveLoad(cache string, d time.Duration, err error)
+	// ObserveRemoval 记录一次删除事件，显式删除已经通过 ObserveOperation 记录，通常只需要关注过期与淘汰
+	ObserveRemoval(cache string, reason driver.RemovalReason)
+}
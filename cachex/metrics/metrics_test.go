@@ -0,0 +1,234 @@
+package metrics_test
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/yu1ec/go-pkg/cachex"
+	"github.com/yu1ec/go-pkg/cachex/driver"
+	"github.com/yu1ec/go-pkg/cachex/driver/file"
+	_ "github.com/yu1ec/go-pkg/cachex/driver/memory"
+	"github.com/yu1ec/go-pkg/cachex/driver/memory/bounded"
+	"github.com/yu1ec/go-pkg/cachex/driver/redis"
+	"github.com/yu1ec/go-pkg/cachex/metrics"
+)
+
+func TestWrap(t *testing.T) {
+	d, err := bounded.New(&bounded.BoundedConfig{MaxEntries: 2})
+	if err != nil {
+		t.Fatalf("failed to create bounded driver: %v", err)
+	}
+	stats := metrics.NewMemory()
+	m := metrics.Wrap("local", d, stats, metrics.WithRemovalEvents())
+	defer m.Close()
+
+	m.Set("a", 1, 0)
+	m.SetMany(map[string]any{"b": 2, "c": 3}, 0)
+	m.Get("missing")
+	_, err = m.GetCtx(context.Background(), "c")
+	assert.NoError(t, err)
+	m.GetMany([]string{"b", "c", "d"})
+	m.Delete("c")
+	assert.True(t, errors.Is(m.Add("b", 1, 0), driver.ErrKeyExists))
+
+	s := stats.Snapshot()["local"]
+	assert.Equal(t, uint64(3), s.Hits)
+	assert.Equal(t, uint64(2), s.Misses)
+	assert.Equal(t, uint64(3), s.Sets)
+	assert.Equal(t, uint64(1), s.Deletes)
+	assert.Equal(t, uint64(1), s.Evictions)
+	assert.Equal(t, uint64(0), s.Errors)
+	assert.Equal(t, 0.6, s.HitRate())
+	assert.Equal(t, uint64(2), s.Latency[metrics.OpGet].Count)
+	assert.Equal(t, uint64(1), s.Latency[metrics.OpGetMany].Count)
+
+	// 只能获取被包装的驱动支持的可选接口
+	_, ok := driver.As[driver.Locker](m)
+	assert.False(t, ok)
+	_, ok = driver.As[driver.Tagger](m)
+	assert.True(t, ok)
+}
+
+func TestWrapCapabilities(t *testing.T) {
+	mr := miniredis.RunT(t)
+	redisDriver, err := redis.New(&redis.RedisConfig{Addr: mr.Addr()})
+	if err != nil {
+		t.Fatalf("failed to create Redis driver: %v", err)
+	}
+	fileDriver, err := file.New(&file.FileConfig{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("failed to create file driver: %v", err)
+	}
+	stats := metrics.NewMemory()
+
+	// 通过 driver.As 获取的可选接口与被包装的驱动一致
+	for name, d := range map[string]driver.Driver{"redis": redisDriver, "file": fileDriver} {
+		m := metrics.Wrap(name, d, stats)
+		for iface, check := range map[string]func(driver.Driver) bool{
+			"Locker":      func(d driver.Driver) bool { _, ok := driver.As[driver.Locker](d); return ok },
+			"TokenLocker": func(d driver.Driver) bool { _, ok := driver.As[driver.TokenLocker](d); return ok },
+			"Tagger":      func(d driver.Driver) bool { _, ok := driver.As[driver.Tagger](d); return ok },
+			"Notifier":    func(d driver.Driver) bool { _, ok := driver.As[driver.Notifier](d); return ok },
+			"Updater":     func(d driver.Driver) bool { _, ok := driver.As[driver.Updater](d); return ok },
+			"Scanner":     func(d driver.Driver) bool { _, ok := driver.As[driver.Scanner](d); return ok },
+			"Pinger":      func(d driver.Driver) bool { _, ok := driver.As[driver.Pinger](d); return ok },
+		} {
+			assert.Equal(t, check(d), check(m), "%s %s", name, iface)
+		}
+	}
+
+	// 默认不订阅删除事件，不占用 Redis 的订阅连接
+	const channel = "__keyevent@0__:expired"
+	m := metrics.Wrap("redis", redisDriver, stats)
+	assert.Equal(t, 0, mr.PubSubNumSub(channel)[channel])
+	m = metrics.Wrap("redis", redisDriver, stats, metrics.WithRemovalEvents())
+	assert.Equal(t, 1, mr.PubSubNumSub(channel)[channel])
+	m.Close()
+}
+
+// partialDriver 模拟只实现部分可选接口的第三方驱动：Counter 与 Scanner，但不实现 Tagger 等
+type partialDriver struct {
+	driver.Driver
+	counter driver.Counter
+	scanner driver.Scanner
+}
+
+func (p partialDriver) IncrementInt64WithTTL(ctx context.Context, k string, n int64, d time.Duration) (int64, error) {
+	return p.counter.IncrementInt64WithTTL(ctx, k, n, d)
+}
+
+func (p partialDriver) Scan(pattern string, fn func(k string) bool) { p.scanner.Scan(pattern, fn) }
+func (p partialDriver) Len() int                                    { return p.scanner.Len() }
+func (p partialDriver) ScanCtx(ctx context.Context, pattern string, fn func(k string) bool) error {
+	return p.scanner.ScanCtx(ctx, pattern, fn)
+}
+func (p partialDriver) LenCtx(ctx context.Context) (int, error) { return p.scanner.LenCtx(ctx) }
+
+func TestWrapPartialCapabilities(t *testing.T) {
+	d, err := bounded.New(&bounded.BoundedConfig{})
+	if err != nil {
+		t.Fatalf("failed to create bounded driver: %v", err)
+	}
+	partial := partialDriver{Driver: d, counter: d.(driver.Counter), scanner: d.(driver.Scanner)}
+	stats := metrics.NewMemory()
+	// 装饰器嵌套时同样可以逐级查找
+	m := metrics.Wrap("outer", metrics.Wrap("partial", partial, stats), stats)
+
+	// driver.As 可以得到被包装的驱动实现的每一个接口，未实现的接口无法得到
+	counter, ok := driver.As[driver.Counter](m)
+	assert.True(t, ok)
+	scanner, ok := driver.As[driver.Scanner](m)
+	assert.True(t, ok)
+	_, ok = driver.As[driver.Tagger](m)
+	assert.False(t, ok)
+	_, ok = driver.As[driver.Updater](m)
+	assert.False(t, ok)
+
+	n, err := counter.IncrementInt64WithTTL(context.Background(), "hits", 2, time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+	assert.Equal(t, 1, scanner.Len())
+	for _, name := range []string{"partial", "outer"} {
+		assert.Equal(t, uint64(1), stats.Snapshot()[name].Latency[metrics.OpIncrement].Count, name)
+	}
+
+	// cachex 通过 driver.As 使用可选接口，包装后不会丢失
+	driver.Register("metrics_partial", func(any) (driver.Driver, error) { return partial, nil })
+	c, err := cachex.New("metrics_partial", nil, cachex.WithMetrics("cachex", stats))
+	assert.NoError(t, err)
+	size, err := c.Len()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, size)
+	assert.ErrorIs(t, c.Tags("t").Flush(), cachex.ErrNotSupported)
+}
+
+func TestWrapUpdateErrors(t *testing.T) {
+	d, err := bounded.New(&bounded.BoundedConfig{})
+	if err != nil {
+		t.Fatalf("failed to create bounded driver: %v", err)
+	}
+	stats := metrics.NewMemory()
+	m := metrics.Wrap("local", d, stats)
+
+	// 回调函数返回的错误不计入缓存错误
+	errLoad := errors.New("load failed")
+	updater, _ := driver.As[driver.Updater](m)
+	_, err = updater.Update(context.Background(), "k", func(any, bool) (any, error) {
+		return nil, errLoad
+	})
+	assert.ErrorIs(t, err, errLoad)
+	_, err = updater.Update(context.Background(), "k", func(any, bool) (any, error) {
+		return 1, nil
+	})
+	assert.NoError(t, err)
+
+	s := stats.Snapshot()["local"]
+	assert.Equal(t, uint64(0), s.Errors)
+	assert.Equal(t, uint64(1), s.Sets)
+	assert.Equal(t, uint64(2), s.Latency[metrics.OpUpdate].Count)
+}
+
+func TestWrapErrors(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	d, err := redis.New(&redis.RedisConfig{Addr: mr.Addr()})
+	if err != nil {
+		t.Fatalf("failed to create Redis driver: %v", err)
+	}
+	stats := metrics.NewMemory()
+	m := metrics.Wrap("redis", d, stats)
+
+	_, err = m.GetCtx(context.Background(), "k")
+	assert.True(t, errors.Is(err, driver.ErrCacheMiss))
+
+	mr.Close()
+	_, err = m.GetCtx(context.Background(), "k")
+	assert.True(t, errors.Is(err, driver.ErrUnavailable))
+
+	s := stats.Snapshot()["redis"]
+	assert.Equal(t, uint64(1), s.Misses)
+	assert.Equal(t, uint64(1), s.Errors)
+}
+
+func TestWithMetrics(t *testing.T) {
+	stats := metrics.NewMemory(0.001, 0.01)
+	c, err := cachex.New("memory", map[string]any{}, cachex.WithMetrics("users", stats))
+	if err != nil {
+		t.Fatalf("failed to create memory cache: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		_, err := c.Remember("user:1", 60, func() (any, error) {
+			return "alice", nil
+		})
+		assert.NoError(t, err)
+	}
+	_, err = c.Remember("user:2", 60, func() (any, error) {
+		return nil, errors.New("not found")
+	})
+	assert.Error(t, err)
+
+	s := stats.Snapshot()["users"]
+	assert.Equal(t, uint64(2), s.Loads)
+	assert.Equal(t, uint64(1), s.LoadErrors)
+	assert.Equal(t, uint64(2), s.LoadLatency.Count)
+	assert.Equal(t, uint64(2), s.Hits)
+
+	rec := httptest.NewRecorder()
+	stats.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+	assert.True(t, strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain"))
+	assert.Contains(t, body, "# TYPE cachex_hits_total counter\ncachex_hits_total{cache=\"users\"} 2\n")
+	assert.Contains(t, body, "cachex_loads_total{cache=\"users\"} 2\n")
+	assert.Contains(t, body, "cachex_load_duration_seconds_bucket{cache=\"users\",le=\"+Inf\"} 2\n")
+	assert.Contains(t, body, "cachex_load_duration_seconds_count{cache=\"users\"} 2\n")
+	assert.Contains(t, body, "cachex_operation_duration_seconds_bucket{cache=\"users\",op=\"get\",le=\"0.001\"}")
+}
@@ -0,0 +1,99 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// counter Prometheus 计数器的定义
+type counter struct {
+	name  string
+	help  string
+	value func(CacheStats) uint64
+}
+
+var counters = []counter{
+	{"cachex_hits_total", "Number of cache hits.", func(s CacheStats) uint64 { return s.Hits }},
+	{"cachex_misses_total", "Number of cache misses.", func(s CacheStats) uint64 { return s.Misses }},
+	{"cachex_sets_total", "Number of keys written.", func(s CacheStats) uint64 { return s.Sets }},
+	{"cachex_deletes_total", "Number of keys deleted explicitly.", func(s CacheStats) uint64 { return s.Deletes }},
+	{"cachex_evictions_total", "Number of keys evicted due to capacity limits.", func(s CacheStats) uint64 { return s.Evictions }},
+	{"cachex_expirations_total", "Number of keys removed after expiring.", func(s CacheStats) uint64 { return s.Expirations }},
+	{"cachex_errors_total", "Number of failed cache operations.", func(s CacheStats) uint64 { return s.Errors }},
+	{"cachex_loads_total", "Number of Remember loader invocations.", func(s CacheStats) uint64 { return s.Loads }},
+	{"cachex_load_errors_total", "Number of Remember loader invocations that returned an error.", func(s CacheStats) uint64 { return s.LoadErrors }},
+}
+
+// WritePrometheus 以 Prometheus 文本格式写出所有指标
+func (m *Memory) WritePrometheus(w io.Writer) error {
+	snapshot := m.Snapshot()
+	names := make([]string, 0, len(snapshot))
+	for name := range snapshot {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	bw := bufio.NewWriter(w)
+	for _, c := range counters {
+		fmt.Fprintf(bw, "# HELP %s %s\n# TYPE %s counter\n", c.name, c.help, c.name)
+		for _, name := range names {
+			fmt.Fprintf(bw, "%s{cache=\"%s\"} %d\n", c.name, escapeLabel(name), c.value(snapshot[name]))
+		}
+	}
+
+	fmt.Fprint(bw, "# HELP cachex_operation_duration_seconds Latency of cache operations.\n")
+	fmt.Fprint(bw, "# TYPE cachex_operation_duration_seconds histogram\n")
+	for _, name := range names {
+		s := snapshot[name]
+		ops := make([]string, 0, len(s.Latency))
+		for op := range s.Latency {
+			ops = append(ops, string(op))
+		}
+		sort.Strings(ops)
+		for _, op := range ops {
+			labels := fmt.Sprintf("cache=\"%s\",op=\"%s\"", escapeLabel(name), escapeLabel(op))
+			writeHistogram(bw, "cachex_operation_duration_seconds", labels, s.Latency[Op(op)])
+		}
+	}
+
+	fmt.Fprint(bw, "# HELP cachex_load_duration_seconds Latency of Remember loader invocations.\n")
+	fmt.Fprint(bw, "# TYPE cachex_load_duration_seconds histogram\n")
+	for _, name := range names {
+		if s := snapshot[name]; s.Loads > 0 {
+			writeHistogram(bw, "cachex_load_duration_seconds", fmt.Sprintf("cache=\"%s\"", escapeLabel(name)), s.LoadLatency)
+		}
+	}
+	return bw.Flush()
+}
+
+// Handler 返回以 Prometheus 文本格式输出指标的 http.Handler
+func (m *Memory) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		if err := m.WritePrometheus(w); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+func writeHistogram(w io.Writer, name, labels string, h Histogram) {
+	var cumulative uint64
+	for i, le := range h.Buckets {
+		cumulative += h.Counts[i]
+		fmt.Fprintf(w, "%s_bucket{%s,le=\"%s\"} %d\n", name, labels, strconv.FormatFloat(le, 'g', -1, 64), cumulative)
+	}
+	fmt.Fprintf(w, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, labels, h.Count)
+	fmt.Fprintf(w, "%s_sum{%s} %s\n", name, labels, strconv.FormatFloat(h.Sum, 'g', -1, 64))
+	fmt.Fprintf(w, "%s_count{%s} %d\n", name, labels, h.Count)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
@@ -0,0 +1,56 @@
+package cachex
+
+import (
+	"time"
+
+	"github.com/yu1ec/go-pkg/cachex/metrics"
+)
+
+// Option 缓存配置项
+type Option func(*cacheImpl)
+
+// WithDistributedLock 开启分布式锁模式，仅对实现了 driver.Locker 的驱动生效（如 redis）
+// 缓存未命中时只有获取到锁的实例执行 create，其余实例在 wait 时间内等待缓存被填充，
+// 超时后自行执行 create。lockTTL 为锁的最长持有时间，应大于 create 的执行耗时
+func WithDistributedLock(lockTTL, wait time.Duration) Option {
+	return func(c *cacheImpl) {
+		c.lockTTL = lockTTL
+		c.lockWait = wait
+	}
+}
+
+// WithNegativeCache 开启负缓存，Remember 的 create 返回错误时写入 Missing 哨兵值并缓存 expireSeconds 秒
+// match 用于筛选需要负缓存的错误（如记录不存在），为 nil 时缓存所有错误
+func WithNegativeCache(expireSeconds int64, match func(error) bool) Option {
+	return func(c *cacheImpl) {
+		c.negativeSeconds = expireSeconds
+		c.negativeMatch = match
+	}
+}
+
+// WithPrefix 为所有键加上命名空间前缀，设置后 Flush 只删除该前缀下的键
+// 与驱动自身的前缀（如 RedisConfig.Prefix）可以叠加使用
+func WithPrefix(prefix string) Option {
+	return func(c *cacheImpl) {
+		c.prefix = prefix
+	}
+}
+
+// WithEarlyRefresh 为 RememberStale 开启概率性提前刷新（XFetch），使刷新分散在软过期之前，而不是集中在过期的瞬间
+// beta 越大越倾向于提前刷新，通常取 1；create 耗时越长，提前刷新的时间也越早
+func WithEarlyRefresh(beta float64) Option {
+	return func(c *cacheImpl) {
+		c.earlyBeta = beta
+	}
+}
+
+// WithMetrics 开启指标采集，name 为指标中的缓存名称
+// 驱动会被 metrics.Wrap 包装以统计命中、未命中、写入、删除、错误与耗时，Remember 的加载函数调用次数与耗时也会被记录
+// opts 传给 metrics.Wrap，如 metrics.WithRemovalEvents() 统计过期与淘汰
+func WithMetrics(name string, stats metrics.Stats, opts ...metrics.Option) Option {
+	return func(c *cacheImpl) {
+		c.name = name
+		c.stats = stats
+		c.driver = metrics.Wrap(name, c.driver, stats, opts...)
+	}
+}
@@ -0,0 +1,108 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/yu1ec/go-pkg/cachex/driver"
+)
+
+// tokenBucketScript 哈希中保存剩余令牌数与上次更新的时间（微秒），ARGV 依次为每微秒补充的令牌数、容量、当前时间与 n
+// 返回是否允许以及剩余令牌数，令牌数为小数，以字符串返回
+var tokenBucketScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local n = tonumber(ARGV[4])
+local state = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(state[1]) or burst
+local ts = tonumber(state[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate)
+local allowed = 0
+if tokens >= n then
+	tokens = tokens - n
+	allowed = 1
+end
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "ts", tostring(now))
+redis.call("PEXPIRE", KEYS[1], math.ceil((burst - tokens) / rate / 1000) + 1)
+return {allowed, tostring(tokens)}
+`)
+
+// tokenBucket 令牌桶：桶中最多 burst 个令牌，每个 period 补充 rate 个，每次请求消耗一个
+type tokenBucket struct {
+	// rate 每微秒补充的令牌数
+	rate  float64
+	burst int64
+}
+
+// NewTokenBucket 创建令牌桶限流器，平均每个 period 允许 rate 次请求，最多允许 burst 次突发
+// rate 与 burst 必须大于 0，period 不能小于 1 微秒，否则返回 driver.ErrInvalidConfig
+func NewTokenBucket(d driver.Driver, rate int64, period time.Duration, burst int64, opts ...Option) (*Limiter, error) {
+	switch {
+	case rate <= 0:
+		return nil, fmt.Errorf("%w: ratelimit rate must be positive, got %d", driver.ErrInvalidConfig, rate)
+	case period < time.Microsecond:
+		return nil, fmt.Errorf("%w: ratelimit period must be at least 1µs, got %s", driver.ErrInvalidConfig, period)
+	case burst <= 0:
+		return nil, fmt.Errorf("%w: ratelimit burst must be positive, got %d", driver.ErrInvalidConfig, burst)
+	}
+	return newLimiter(d, &tokenBucket{rate: float64(rate) / float64(period.Microseconds()), burst: burst}, opts), nil
+}
+
+// refill 返回 now 时桶中的令牌数
+func (b *tokenBucket) refill(tokens float64, ts, now int64) float64 {
+	return math.Min(float64(b.burst), tokens+float64(max(now-ts, 0))*b.rate)
+}
+
+// ttl 返回空桶补满所需的时间，桶满后状态可以丢弃
+func (b *tokenBucket) ttl() time.Duration {
+	return time.Duration(float64(b.burst)/b.rate)*time.Microsecond + time.Millisecond
+}
+
+func (b *tokenBucket) result(allowed bool, tokens float64, n int64, now time.Time) Result {
+	r := Result{
+		Allowed:   allowed,
+		Limit:     b.burst,
+		Remaining: int64(math.Floor(tokens)),
+		Reset:     now.Add(time.Duration((float64(b.burst)-tokens)/b.rate) * time.Microsecond),
+	}
+	if !allowed {
+		r.RetryAfter = time.Duration(math.Ceil((float64(n)-tokens)/b.rate)) * time.Microsecond
+	}
+	return r
+}
+
+func (b *tokenBucket) redis(ctx context.Context, r scriptRunner, key string, n int64, now time.Time) (Result, error) {
+	res, err := r.RunScript(ctx, tokenBucketScript, []string{key}, b.rate, b.burst, now.UnixMicro(), n).Slice()
+	if err != nil {
+		return Result{}, err
+	}
+	tokens, err := strconv.ParseFloat(res[1].(string), 64)
+	if err != nil {
+		return Result{}, err
+	}
+	return b.result(res[0].(int64) == 1, tokens, n, now), nil
+}
+
+// driver 以 []float64{剩余令牌数, 上次更新的时间（微秒）} 保存状态，通过 CompareAndSwap 原子地更新
+func (b *tokenBucket) driver(ctx context.Context, d driver.Driver, key string, n int64, now time.Time) (Result, error) {
+	var r Result
+	nowMicro := now.UnixMicro()
+	err := update(ctx, d, key, b.ttl(), func(old any, exists bool) (any, bool) {
+		tokens := float64(b.burst)
+		if state, ok := old.([]float64); ok && len(state) == 2 {
+			tokens = b.refill(state[0], int64(state[1]), nowMicro)
+		}
+		allowed := tokens >= float64(n)
+		if allowed {
+			tokens -= float64(n)
+		}
+		r = b.result(allowed, tokens, n, now)
+		return []float64{tokens, float64(nowMicro)}, true
+	})
+	return r, err
+}
@@ -0,0 +1,92 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/yu1ec/go-pkg/cachex/driver"
+)
+
+// fixedWindowScript 计数加上 ARGV[2] 后不超过 ARGV[1] 时递增计数，新窗口的计数在 ARGV[3] 毫秒后过期
+var fixedWindowScript = redis.NewScript(`
+local count = tonumber(redis.call("GET", KEYS[1]) or "0")
+local n = tonumber(ARGV[2])
+if count + n > tonumber(ARGV[1]) then
+	return {0, count}
+end
+count = redis.call("INCRBY", KEYS[1], n)
+if count == n then
+	redis.call("PEXPIRE", KEYS[1], ARGV[3])
+end
+return {1, count}
+`)
+
+// fixedWindow 固定窗口：按时间切分为长度为 window 的窗口，每个窗口最多 limit 次
+type fixedWindow struct {
+	limit  int64
+	window time.Duration
+}
+
+// NewFixedWindow 创建固定窗口限流器，每个长度为 window 的窗口内最多允许 limit 次请求
+// 实现简单、开销最小，但窗口边界前后可能出现两倍的突发
+// limit 必须大于 0，window 不能小于 1 毫秒，否则返回 driver.ErrInvalidConfig
+func NewFixedWindow(d driver.Driver, limit int64, window time.Duration, opts ...Option) (*Limiter, error) {
+	if err := validateWindow(limit, window); err != nil {
+		return nil, err
+	}
+	return newLimiter(d, &fixedWindow{limit: limit, window: window}, opts), nil
+}
+
+// bucket 返回当前窗口的键与窗口结束时间
+func (f *fixedWindow) bucket(key string, now time.Time) (string, time.Time) {
+	start := now.Truncate(f.window)
+	return key + ":" + strconv.FormatInt(start.UnixMilli(), 10), start.Add(f.window)
+}
+
+func (f *fixedWindow) result(allowed bool, count int64, reset, now time.Time) Result {
+	r := Result{Allowed: allowed, Limit: f.limit, Remaining: max(f.limit-count, 0), Reset: reset}
+	if !allowed {
+		r.RetryAfter = reset.Sub(now)
+	}
+	return r
+}
+
+func (f *fixedWindow) redis(ctx context.Context, r scriptRunner, key string, n int64, now time.Time) (Result, error) {
+	k, reset := f.bucket(key, now)
+	ttl := max(reset.Sub(now).Milliseconds(), 1)
+	res, err := r.RunScript(ctx, fixedWindowScript, []string{k}, f.limit, n, ttl).Int64Slice()
+	if err != nil {
+		return Result{}, err
+	}
+	return f.result(res[0] == 1, res[1], reset, now), nil
+}
+
+// driver 使用驱动的数值操作计数，超出限制时撤回本次递增
+// 驱动实现了 driver.Counter 时一步完成创建与递增，否则先以 Add 创建计数
+func (f *fixedWindow) driver(ctx context.Context, d driver.Driver, key string, n int64, now time.Time) (Result, error) {
+	k, reset := f.bucket(key, now)
+	var count int64
+	var err error
+	if counter, ok := driver.As[driver.Counter](d); ok {
+		count, err = counter.IncrementInt64WithTTL(ctx, k, n, reset.Sub(now))
+	} else {
+		if err := d.AddCtx(ctx, k, int64(0), reset.Sub(now)); err != nil && !errors.Is(err, driver.ErrKeyExists) {
+			return Result{}, err
+		}
+		count, err = d.IncrementInt64(k, n)
+	}
+	if err != nil {
+		return Result{}, err
+	}
+	if count > f.limit {
+		count, err = d.DecrementInt64(k, n)
+		if err != nil {
+			return Result{}, err
+		}
+		return f.result(false, count, reset, now), nil
+	}
+	return f.result(true, count, reset, now), nil
+}
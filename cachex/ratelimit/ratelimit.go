@@ -0,0 +1,172 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/yu1ec/go-pkg/cachex/driver"
+)
+
+// DefaultPrefix 限流键的默认前缀
+const DefaultPrefix = "cachex:ratelimit:"
+
+// Result 一次限流判断的结果
+type Result struct {
+	// Allowed 是否允许本次请求
+	Allowed bool
+	// Limit 窗口内允许的请求数，令牌桶为桶容量
+	Limit int64
+	// Remaining 本次判断后剩余的可用次数
+	Remaining int64
+	// Reset 配额完全恢复的时间
+	Reset time.Time
+	// RetryAfter 被拒绝时至少需要等待的时间，允许时为 0
+	RetryAfter time.Duration
+}
+
+// SetHeaders 写入 X-RateLimit-Limit、X-RateLimit-Remaining 与 X-RateLimit-Reset（Unix 秒），被拒绝时同时写入 Retry-After（秒）
+func (r Result) SetHeaders(h http.Header) {
+	h.Set("X-RateLimit-Limit", strconv.FormatInt(r.Limit, 10))
+	h.Set("X-RateLimit-Remaining", strconv.FormatInt(r.Remaining, 10))
+	h.Set("X-RateLimit-Reset", strconv.FormatInt(ceilUnix(r.Reset), 10))
+	if !r.Allowed {
+		h.Set("Retry-After", strconv.FormatInt(int64(math.Ceil(r.RetryAfter.Seconds())), 10))
+	}
+}
+
+func ceilUnix(t time.Time) int64 {
+	sec := t.Unix()
+	if t.Nanosecond() > 0 {
+		sec++
+	}
+	return sec
+}
+
+// algorithm 限流算法，Redis 驱动使用 Lua 脚本，其他驱动通过驱动的原子操作实现
+type algorithm interface {
+	redis(ctx context.Context, r scriptRunner, key string, n int64, now time.Time) (Result, error)
+	driver(ctx context.Context, d driver.Driver, key string, n int64, now time.Time) (Result, error)
+}
+
+// scriptRunner Redis 驱动执行 Lua 脚本的方法，键会加上驱动的前缀，错误经过驱动的转换
+type scriptRunner interface {
+	RunScript(ctx context.Context, script *redis.Script, keys []string, args ...any) *redis.Cmd
+}
+
+// Limiter 限流器，同一个 Limiter 可以用于任意多个键
+type Limiter struct {
+	algorithm algorithm
+	driver    driver.Driver
+	runner    scriptRunner
+	prefix    string
+	now       func() time.Time
+}
+
+// Option Limiter 配置项
+type Option func(*Limiter)
+
+// WithPrefix 设置限流键的前缀，默认为 DefaultPrefix
+func WithPrefix(prefix string) Option {
+	return func(l *Limiter) {
+		l.prefix = prefix
+	}
+}
+
+// WithClock 设置获取当前时间的函数，主要用于测试
+func WithClock(now func() time.Time) Option {
+	return func(l *Limiter) {
+		l.now = now
+	}
+}
+
+func newLimiter(d driver.Driver, a algorithm, opts []Option) *Limiter {
+	l := &Limiter{algorithm: a, driver: d, prefix: DefaultPrefix, now: time.Now}
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	// Redis 驱动（包括被 metrics.Wrap 等装饰器包装的）直接在 Redis 驱动上执行 Lua 脚本，
+	// 键加上驱动的前缀，错误经过驱动的转换；经过装饰器时脚本不会被装饰器记录（如不计入 metrics 指标）
+	for next := d; next != nil; {
+		if r, ok := next.(scriptRunner); ok {
+			l.runner = r
+			break
+		}
+		u, ok := next.(interface{ Unwrap() driver.Driver })
+		if !ok {
+			break
+		}
+		next = u.Unwrap()
+	}
+	return l
+}
+
+// validateWindow 校验固定窗口与滑动窗口的参数
+func validateWindow(limit int64, window time.Duration) error {
+	switch {
+	case limit <= 0:
+		return fmt.Errorf("%w: ratelimit limit must be positive, got %d", driver.ErrInvalidConfig, limit)
+	case window < time.Millisecond:
+		// 固定窗口的键以毫秒区分窗口
+		return fmt.Errorf("%w: ratelimit window must be at least 1ms, got %s", driver.ErrInvalidConfig, window)
+	}
+	return nil
+}
+
+// Allow 判断 key 是否允许一次请求
+func (l *Limiter) Allow(ctx context.Context, key string) (Result, error) {
+	return l.AllowN(ctx, key, 1)
+}
+
+// AllowN 判断 key 是否允许 n 次请求，被拒绝时不消耗配额
+func (l *Limiter) AllowN(ctx context.Context, key string, n int64) (Result, error) {
+	if n <= 0 {
+		return Result{}, fmt.Errorf("ratelimit: invalid n %d", n)
+	}
+	if l.runner != nil {
+		return l.algorithm.redis(ctx, l.runner, l.prefix+key, n, l.now())
+	}
+	return l.algorithm.driver(ctx, l.driver, l.prefix+key, n, l.now())
+}
+
+// maxUpdateRetries 通过 CompareAndSwap 更新状态时的最大重试次数
+const maxUpdateRetries = 100
+
+// update 通过 Add 与 CompareAndSwap 原子地更新键的状态，并将过期时间设置为 ttl
+// fn 根据当前状态返回新状态，write 为 false 时不写入；并发冲突时 fn 会被重新调用
+func update(ctx context.Context, d driver.Driver, k string, ttl time.Duration, fn func(old any, exists bool) (v any, write bool)) error {
+	updater, ok := driver.As[driver.Updater](d)
+	if !ok {
+		return driver.ErrNotSupported
+	}
+	for i := 0; i < maxUpdateRetries; i++ {
+		old, err := d.GetCtx(ctx, k)
+		exists := err == nil
+		if err != nil && !errors.Is(err, driver.ErrCacheMiss) {
+			return err
+		}
+
+		v, write := fn(old, exists)
+		if !write {
+			return nil
+		}
+		if !exists {
+			err := d.AddCtx(ctx, k, v, ttl)
+			if errors.Is(err, driver.ErrKeyExists) {
+				continue
+			}
+			return err
+		}
+		swapped, err := updater.CompareAndSwap(ctx, k, old, v, ttl)
+		if err != nil || swapped {
+			return err
+		}
+	}
+	return fmt.Errorf("%w: %s", driver.ErrConflict, k)
+}
@@ -0,0 +1,214 @@
+package ratelimit_test
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yu1ec/go-pkg/cachex/driver"
+	_ "github.com/yu1ec/go-pkg/cachex/driver/memory"
+	"github.com/yu1ec/go-pkg/cachex/driver/redis"
+	"github.com/yu1ec/go-pkg/cachex/ratelimit"
+)
+
+// clock 测试用的可调时钟
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+func drivers(t *testing.T) map[string]driver.Driver {
+	mr := miniredis.RunT(t)
+	redisDriver, err := driver.New("redis", &redis.RedisConfig{Addr: mr.Addr()})
+	require.NoError(t, err)
+	memoryDriver, err := driver.New("memory", map[string]any{})
+	require.NoError(t, err)
+	return map[string]driver.Driver{"memory": memoryDriver, "redis": redisDriver}
+}
+
+func TestFixedWindow(t *testing.T) {
+	ctx := context.Background()
+	for name, d := range drivers(t) {
+		t.Run(name, func(t *testing.T) {
+			c := &clock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+			l, err := ratelimit.NewFixedWindow(d, 3, time.Minute, ratelimit.WithClock(c.Now))
+			require.NoError(t, err)
+
+			for i := int64(2); i >= 0; i-- {
+				r, err := l.Allow(ctx, "api")
+				require.NoError(t, err)
+				assert.True(t, r.Allowed)
+				assert.Equal(t, i, r.Remaining)
+			}
+
+			c.Advance(20 * time.Second)
+			r, err := l.Allow(ctx, "api")
+			require.NoError(t, err)
+			assert.False(t, r.Allowed)
+			assert.Equal(t, int64(0), r.Remaining)
+			assert.Equal(t, 40*time.Second, r.RetryAfter)
+			assert.Equal(t, c.now.Add(40*time.Second), r.Reset)
+
+			// 其他键不受影响
+			r, err = l.Allow(ctx, "other")
+			require.NoError(t, err)
+			assert.True(t, r.Allowed)
+
+			c.Advance(40 * time.Second)
+			r, err = l.AllowN(ctx, "api", 2)
+			require.NoError(t, err)
+			assert.True(t, r.Allowed)
+			assert.Equal(t, int64(1), r.Remaining)
+
+			// 被拒绝时不消耗配额
+			r, err = l.AllowN(ctx, "api", 2)
+			require.NoError(t, err)
+			assert.False(t, r.Allowed)
+			r, err = l.Allow(ctx, "api")
+			require.NoError(t, err)
+			assert.True(t, r.Allowed)
+		})
+	}
+}
+
+func TestSlidingLog(t *testing.T) {
+	ctx := context.Background()
+	for name, d := range drivers(t) {
+		t.Run(name, func(t *testing.T) {
+			c := &clock{now: time.Date(2024, 1, 1, 0, 0, 30, 0, time.UTC)}
+			l, err := ratelimit.NewSlidingLog(d, 2, time.Minute, ratelimit.WithClock(c.Now))
+			require.NoError(t, err)
+
+			r, err := l.Allow(ctx, "api")
+			require.NoError(t, err)
+			assert.True(t, r.Allowed)
+			assert.Equal(t, int64(1), r.Remaining)
+
+			c.Advance(20 * time.Second)
+			r, err = l.Allow(ctx, "api")
+			require.NoError(t, err)
+			assert.True(t, r.Allowed)
+			assert.Equal(t, int64(0), r.Remaining)
+			assert.WithinDuration(t, c.now.Add(time.Minute), r.Reset, 0)
+
+			// 窗口跨越整分钟也不会重置
+			c.Advance(20 * time.Second)
+			r, err = l.Allow(ctx, "api")
+			require.NoError(t, err)
+			assert.False(t, r.Allowed)
+			assert.Equal(t, 20*time.Second, r.RetryAfter)
+
+			c.Advance(20 * time.Second)
+			r, err = l.Allow(ctx, "api")
+			require.NoError(t, err)
+			assert.True(t, r.Allowed)
+			assert.Equal(t, int64(0), r.Remaining)
+		})
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	ctx := context.Background()
+	for name, d := range drivers(t) {
+		t.Run(name, func(t *testing.T) {
+			c := &clock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+			// 每秒补充 1 个令牌，最多 3 个
+			l, err := ratelimit.NewTokenBucket(d, 1, time.Second, 3, ratelimit.WithClock(c.Now))
+			require.NoError(t, err)
+
+			r, err := l.AllowN(ctx, "api", 3)
+			require.NoError(t, err)
+			assert.True(t, r.Allowed)
+			assert.Equal(t, int64(0), r.Remaining)
+			assert.Equal(t, c.now.Add(3*time.Second), r.Reset)
+
+			r, err = l.Allow(ctx, "api")
+			require.NoError(t, err)
+			assert.False(t, r.Allowed)
+			assert.Equal(t, time.Second, r.RetryAfter)
+
+			c.Advance(1500 * time.Millisecond)
+			r, err = l.Allow(ctx, "api")
+			require.NoError(t, err)
+			assert.True(t, r.Allowed)
+			assert.Equal(t, int64(0), r.Remaining)
+
+			c.Advance(time.Hour)
+			r, err = l.Allow(ctx, "api")
+			require.NoError(t, err)
+			assert.True(t, r.Allowed)
+			assert.Equal(t, int64(2), r.Remaining)
+		})
+	}
+}
+
+func TestResultSetHeaders(t *testing.T) {
+	h := http.Header{}
+	ratelimit.Result{
+		Allowed:    false,
+		Limit:      100,
+		Remaining:  0,
+		Reset:      time.Unix(1700000000, 500),
+		RetryAfter: 1500 * time.Millisecond,
+	}.SetHeaders(h)
+
+	assert.Equal(t, "100", h.Get("X-RateLimit-Limit"))
+	assert.Equal(t, "0", h.Get("X-RateLimit-Remaining"))
+	assert.Equal(t, "1700000001", h.Get("X-RateLimit-Reset"))
+	assert.Equal(t, "2", h.Get("Retry-After"))
+}
+
+func TestInvalidConfig(t *testing.T) {
+	d, err := driver.New("memory", map[string]any{})
+	require.NoError(t, err)
+
+	_, err = ratelimit.NewFixedWindow(d, 0, time.Minute)
+	assert.ErrorIs(t, err, driver.ErrInvalidConfig)
+	_, err = ratelimit.NewFixedWindow(d, 1, 0)
+	assert.ErrorIs(t, err, driver.ErrInvalidConfig)
+	_, err = ratelimit.NewSlidingLog(d, -1, time.Minute)
+	assert.ErrorIs(t, err, driver.ErrInvalidConfig)
+	_, err = ratelimit.NewSlidingLog(d, 1, time.Microsecond)
+	assert.ErrorIs(t, err, driver.ErrInvalidConfig)
+	_, err = ratelimit.NewTokenBucket(d, 0, time.Second, 1)
+	assert.ErrorIs(t, err, driver.ErrInvalidConfig)
+	_, err = ratelimit.NewTokenBucket(d, 1, time.Nanosecond, 1)
+	assert.ErrorIs(t, err, driver.ErrInvalidConfig)
+	_, err = ratelimit.NewTokenBucket(d, 1, time.Second, 0)
+	assert.ErrorIs(t, err, driver.ErrInvalidConfig)
+}
+
+func TestRedisPrefixAndErrors(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	d, err := driver.New("redis", &redis.RedisConfig{Addr: mr.Addr(), Prefix: "app:"})
+	require.NoError(t, err)
+
+	l, err := ratelimit.NewTokenBucket(d, 1, time.Second, 3)
+	require.NoError(t, err)
+	_, err = l.Allow(ctx, "api")
+	require.NoError(t, err)
+	assert.True(t, mr.Exists("app:"+ratelimit.DefaultPrefix+"api"))
+
+	// 连接类错误与驱动的其他操作一样包装为 driver.ErrUnavailable
+	mr.Close()
+	_, err = l.Allow(ctx, "api")
+	assert.ErrorIs(t, err, driver.ErrUnavailable)
+}
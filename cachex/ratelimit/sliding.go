@@ -0,0 +1,128 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sort"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/yu1ec/go-pkg/cachex/driver"
+)
+
+// slidingLogScript 有序集合中保存每次请求的时间（微秒），ARGV 依次为当前时间、窗口长度（微秒）、limit、n 与成员前缀
+// 返回是否允许、窗口内的请求数、最新请求的时间以及被拒绝时需要等待其过期的请求时间
+var slidingLogScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+local n = tonumber(ARGV[4])
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", now - window)
+local count = redis.call("ZCARD", KEYS[1])
+local allowed = 0
+local wait = 0
+if count + n <= limit then
+	for i = 1, n do
+		redis.call("ZADD", KEYS[1], now, ARGV[5] .. i)
+	end
+	count = count + n
+	allowed = 1
+	redis.call("PEXPIRE", KEYS[1], math.ceil(window / 1000))
+elseif n <= limit then
+	wait = tonumber(redis.call("ZRANGE", KEYS[1], count + n - limit - 1, count + n - limit - 1, "WITHSCORES")[2])
+end
+local newest = redis.call("ZRANGE", KEYS[1], -1, -1, "WITHSCORES")[2]
+return {allowed, count, tonumber(newest or now), wait}
+`)
+
+// slidingLog 滑动窗口日志：记录窗口内每次请求的时间，任意长度为 window 的时间段内最多 limit 次
+type slidingLog struct {
+	limit  int64
+	window time.Duration
+}
+
+// NewSlidingLog 创建滑动窗口日志限流器，任意长度为 window 的时间段内最多允许 limit 次请求
+// 限流精确，但每个键需要保存窗口内所有请求的时间，适合 limit 较小的场景
+// limit 必须大于 0，window 不能小于 1 毫秒，否则返回 driver.ErrInvalidConfig
+func NewSlidingLog(d driver.Driver, limit int64, window time.Duration, opts ...Option) (*Limiter, error) {
+	if err := validateWindow(limit, window); err != nil {
+		return nil, err
+	}
+	return newLimiter(d, &slidingLog{limit: limit, window: window}, opts), nil
+}
+
+// result 根据请求时间（微秒）生成结果，wait 为被拒绝时需要等待其过期的请求时间
+func (s *slidingLog) result(allowed bool, count, newest, wait int64, now time.Time) Result {
+	r := Result{
+		Allowed:   allowed,
+		Limit:     s.limit,
+		Remaining: max(s.limit-count, 0),
+		Reset:     time.UnixMicro(newest).Add(s.window),
+	}
+	if !allowed {
+		if wait > 0 {
+			r.RetryAfter = time.UnixMicro(wait).Add(s.window).Sub(now)
+		} else {
+			// n 超过 limit，永远不会被允许
+			r.RetryAfter = s.window
+		}
+	}
+	return r
+}
+
+func (s *slidingLog) redis(ctx context.Context, r scriptRunner, key string, n int64, now time.Time) (Result, error) {
+	member, err := memberPrefix()
+	if err != nil {
+		return Result{}, err
+	}
+	res, err := r.RunScript(ctx, slidingLogScript, []string{key}, now.UnixMicro(), s.window.Microseconds(), s.limit, n, member).Int64Slice()
+	if err != nil {
+		return Result{}, err
+	}
+	return s.result(res[0] == 1, res[1], res[2], res[3], now), nil
+}
+
+// driver 以 []int64 保存窗口内请求的时间（微秒，升序），通过 CompareAndSwap 原子地更新
+func (s *slidingLog) driver(ctx context.Context, d driver.Driver, key string, n int64, now time.Time) (Result, error) {
+	var r Result
+	nowMicro := now.UnixMicro()
+	err := update(ctx, d, key, s.window, func(old any, exists bool) (any, bool) {
+		log, _ := old.([]int64)
+		// 丢弃窗口之外的请求，不修改 old 以免影响 CompareAndSwap 的比较
+		start := sort.Search(len(log), func(i int) bool { return log[i] > nowMicro-s.window.Microseconds() })
+		log = log[start:]
+		count := int64(len(log))
+
+		newest := nowMicro
+		if count > 0 {
+			newest = log[count-1]
+		}
+		if count+n > s.limit {
+			var wait int64
+			if n <= s.limit {
+				wait = log[count+n-s.limit-1]
+			}
+			r = s.result(false, count, newest, wait, now)
+			return nil, false
+		}
+
+		next := make([]int64, count, count+n)
+		copy(next, log)
+		for i := int64(0); i < n; i++ {
+			next = append(next, nowMicro)
+		}
+		r = s.result(true, count+n, nowMicro, 0, now)
+		return next, true
+	})
+	return r, err
+}
+
+// memberPrefix 返回有序集合成员的随机前缀，使同一微秒内的请求互不覆盖
+func memberPrefix() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b) + ":", nil
+}
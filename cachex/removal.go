@@ -0,0 +1,40 @@
+package cachex
+
+import (
+	"strings"
+
+	"github.com/yu1ec/go-pkg/cachex/driver"
+)
+
+// RemovalEvent 删除事件，包含键、值（驱动能够提供时）以及原因
+type RemovalEvent = driver.RemovalEvent
+
+// RemovalReason 项目离开缓存的原因
+type RemovalReason = driver.RemovalReason
+
+const (
+	// RemovalDeleted 被显式删除
+	RemovalDeleted = driver.RemovalDeleted
+	// RemovalExpired 已过期
+	RemovalExpired = driver.RemovalExpired
+	// RemovalEvicted 因容量上限被淘汰
+	RemovalEvicted = driver.RemovalEvicted
+)
+
+// OnRemoval 设置了前缀时只报告该前缀下的键，事件中的键不包含前缀
+func (c *cacheImpl) OnRemoval(fn func(RemovalEvent)) (func(), error) {
+	notifier, ok := driver.As[driver.Notifier](c.driver)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	if c.prefix == "" {
+		return notifier.OnRemoval(fn)
+	}
+	return notifier.OnRemoval(func(e RemovalEvent) {
+		if !strings.HasPrefix(e.Key, c.prefix) {
+			return
+		}
+		e.Key = strings.TrimPrefix(e.Key, c.prefix)
+		fn(e)
+	})
+}
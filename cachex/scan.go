@@ -0,0 +1,83 @@
+package cachex
+
+import (
+	"context"
+	"strings"
+
+	"github.com/yu1ec/go-pkg/cachex/driver"
+)
+
+// forgetBatch ForgetPattern 每批删除的键数量
+const forgetBatch = 1000
+
+func (c *cacheImpl) Scan(pattern string, fn func(k string) bool) error {
+	return c.ScanCtx(context.Background(), pattern, fn)
+}
+
+func (c *cacheImpl) Len() (int, error) {
+	return c.LenCtx(context.Background())
+}
+
+func (c *cacheImpl) ForgetPattern(pattern string) error {
+	return c.ForgetPatternCtx(context.Background(), pattern)
+}
+
+// ScanCtx 需要驱动实现 driver.Scanner，设置了前缀时只遍历该前缀下的键，返回的键不含前缀
+func (c *cacheImpl) ScanCtx(ctx context.Context, pattern string, fn func(k string) bool) error {
+	scanner, ok := driver.As[driver.Scanner](c.driver)
+	if !ok {
+		return ErrNotSupported
+	}
+	return scanner.ScanCtx(ctx, driver.EscapePattern(c.prefix)+pattern, func(k string) bool {
+		return fn(strings.TrimPrefix(k, c.prefix))
+	})
+}
+
+// LenCtx 需要驱动实现 driver.Scanner，设置了前缀时通过遍历统计该前缀下的键
+func (c *cacheImpl) LenCtx(ctx context.Context) (int, error) {
+	scanner, ok := driver.As[driver.Scanner](c.driver)
+	if !ok {
+		return 0, ErrNotSupported
+	}
+	if c.prefix == "" {
+		return scanner.LenCtx(ctx)
+	}
+	var n int
+	err := c.ScanCtx(ctx, "*", func(string) bool {
+		n++
+		return true
+	})
+	return n, err
+}
+
+// ForgetPatternCtx 遍历一轮，每收集 forgetBatch 个键删除一批，内存占用不随键的数量增长
+// 遍历期间新写入的键可能不会被删除
+func (c *cacheImpl) ForgetPatternCtx(ctx context.Context, pattern string) error {
+	scanner, ok := driver.As[driver.Scanner](c.driver)
+	if !ok {
+		return ErrNotSupported
+	}
+	var (
+		keys   = make([]string, 0, forgetBatch)
+		delErr error
+	)
+	err := scanner.ScanCtx(ctx, driver.EscapePattern(c.prefix)+pattern, func(k string) bool {
+		keys = append(keys, k)
+		if len(keys) < forgetBatch {
+			return true
+		}
+		delErr = c.driver.DeleteManyCtx(ctx, keys)
+		keys = keys[:0]
+		return delErr == nil
+	})
+	if err != nil {
+		return err
+	}
+	if delErr != nil {
+		return delErr
+	}
+	if len(keys) > 0 {
+		return c.driver.DeleteManyCtx(ctx, keys)
+	}
+	return nil
+}
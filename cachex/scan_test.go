@@ -0,0 +1,126 @@
+package cachex_test
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/yu1ec/go-pkg/cachex"
+	"github.com/yu1ec/go-pkg/cachex/driver/file"
+	"github.com/yu1ec/go-pkg/cachex/driver/redis"
+)
+
+func TestScanAndForgetPattern(t *testing.T) {
+	mr := miniredis.RunT(t)
+
+	newCache := func(driverName string, config any) cachex.Cache {
+		c, err := cachex.New(driverName, config, cachex.WithPrefix("app:"))
+		if err != nil {
+			t.Fatalf("failed to create %s cache: %v", driverName, err)
+		}
+		return c
+	}
+	caches := map[string]cachex.Cache{
+		"gocache": newMemoryCache(t, cachex.WithPrefix("app:")),
+		"lru":     newCache("memory", map[string]any{"implementation": "lru", "max_entries": 100}),
+		"file":    newCache("file", &file.FileConfig{Dir: t.TempDir()}),
+		"redis":   newCache("redis", &redis.RedisConfig{Addr: mr.Addr()}),
+	}
+
+	for name, c := range caches {
+		t.Run(name, func(t *testing.T) {
+			c.Put("user:1", "alice", 60)
+			c.Put("user:2", "bob", 60)
+			c.Put("post:1", "hello", 60)
+
+			var keys []string
+			assert.NoError(t, c.Scan("user:*", func(k string) bool {
+				keys = append(keys, k)
+				return true
+			}))
+			sort.Strings(keys)
+			assert.Equal(t, []string{"user:1", "user:2"}, keys)
+
+			n, err := c.Len()
+			assert.NoError(t, err)
+			assert.Equal(t, 3, n)
+
+			assert.NoError(t, c.ForgetPattern("user:*"))
+			assert.False(t, c.Exists("user:1"))
+			assert.False(t, c.Exists("user:2"))
+			assert.True(t, c.Exists("post:1"))
+		})
+	}
+}
+
+// miniredis 的 SCAN 游标是偏移量，边遍历边删除会跳过键（真实的 Redis 不会），因此这里不包含 redis
+func TestForgetPatternManyBatches(t *testing.T) {
+	newCache := func(driverName string, config any) cachex.Cache {
+		c, err := cachex.New(driverName, config)
+		if err != nil {
+			t.Fatalf("failed to create %s cache: %v", driverName, err)
+		}
+		return c
+	}
+	caches := map[string]cachex.Cache{
+		"gocache": newMemoryCache(t),
+		"lru":     newCache("memory", map[string]any{"implementation": "lru", "max_entries": 10000}),
+		"file":    newCache("file", &file.FileConfig{Dir: t.TempDir()}),
+	}
+
+	for name, c := range caches {
+		t.Run(name, func(t *testing.T) {
+			// 超过一批的键也能被全部删除
+			items := make(map[string]any, 2500)
+			for i := 0; i < 2500; i++ {
+				items[fmt.Sprintf("user:%d", i)] = i
+			}
+			c.PutMany(items, 60)
+			c.Put("post:1", "hello", 60)
+
+			assert.NoError(t, c.ForgetPattern("user:*"))
+			n, err := c.Len()
+			assert.NoError(t, err)
+			assert.Equal(t, 1, n)
+			assert.True(t, c.Exists("post:1"))
+		})
+	}
+}
+
+func TestScanSkipsInternalKeys(t *testing.T) {
+	mr := miniredis.RunT(t)
+	c, err := cachex.New("redis", &redis.RedisConfig{Addr: mr.Addr()})
+	if err != nil {
+		t.Fatalf("failed to create redis cache: %v", err)
+	}
+	assert.NoError(t, c.Tags("users").Put("user:1", "alice", 60))
+	mr.Set("other", "value")
+
+	var keys []string
+	assert.NoError(t, c.Scan("*", func(k string) bool {
+		keys = append(keys, k)
+		return true
+	}))
+	sort.Strings(keys)
+	assert.Equal(t, []string{"other", "user:1"}, keys)
+}
+
+func TestForgetPatternKeepsOtherPrefixes(t *testing.T) {
+	mr := miniredis.RunT(t)
+	newCache := func(prefix string) cachex.Cache {
+		c, err := cachex.New("redis", &redis.RedisConfig{Addr: mr.Addr()}, cachex.WithPrefix(prefix))
+		if err != nil {
+			t.Fatalf("failed to create redis cache: %v", err)
+		}
+		return c
+	}
+	a, b := newCache("a:"), newCache("b:")
+	a.Put("user:1", "alice", 60)
+	b.Put("user:1", "bob", 60)
+
+	assert.NoError(t, a.ForgetPattern("*"))
+	assert.False(t, a.Exists("user:1"))
+	assert.True(t, b.Exists("user:1"))
+}
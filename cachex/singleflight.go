@@ -0,0 +1,89 @@
+package cachex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+)
+
+// call 表示一次正在进行或已完成的 singleflight 调用
+type call struct {
+	done chan struct{}
+	val  any
+	err  error
+}
+
+// panicError fn 发生 panic 时传递给等待者的错误，保留 panic 的值与堆栈
+type panicError struct {
+	value any
+	stack []byte
+}
+
+func (p *panicError) Error() string {
+	return fmt.Sprintf("cachex: loader panicked: %v\n\n%s", p.value, p.stack)
+}
+
+// group 保证同一个键在同一时刻只有一个调用者执行 fn，其余调用者等待并共享结果
+type group struct {
+	mu sync.Mutex
+	m  map[string]*call
+}
+
+// Do 执行 fn 并返回结果，如果同一个键已有调用在执行，则等待该调用完成并返回其结果
+// 等待中的调用者在 ctx 取消时立即返回 ctx.Err()，不影响正在执行的 fn；
+// 执行者因自身的 ctx 被取消或超时而失败时，ctx 仍然有效的等待者会重新执行，而不是收到其他请求的取消错误
+// fn 发生 panic 时执行者重新 panic，等待者以 *panicError panic
+func (g *group) Do(ctx context.Context, key string, fn func() (any, error)) (any, error) {
+	for {
+		g.mu.Lock()
+		if g.m == nil {
+			g.m = make(map[string]*call)
+		}
+		c, ok := g.m[key]
+		if !ok {
+			break
+		}
+		g.mu.Unlock()
+
+		select {
+		case <-c.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		var perr *panicError
+		if errors.As(c.err, &perr) {
+			panic(perr)
+		}
+		if isContextError(c.err) && ctx.Err() == nil {
+			continue
+		}
+		return c.val, c.err
+	}
+
+	c := &call{done: make(chan struct{})}
+	g.m[key] = c
+	g.mu.Unlock()
+
+	defer func() {
+		r := recover()
+		if r != nil {
+			c.val, c.err = nil, &panicError{value: r, stack: debug.Stack()}
+		}
+		g.mu.Lock()
+		delete(g.m, key)
+		g.mu.Unlock()
+		close(c.done)
+		if r != nil {
+			panic(r)
+		}
+	}()
+
+	c.val, c.err = fn()
+	return c.val, c.err
+}
+
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
@@ -0,0 +1,269 @@
+package cachex
+
+import (
+	"bytes"
+	"context"
+	"encoding"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yu1ec/go-pkg/cachex/driver"
+)
+
+func init() {
+	gob.Register(&staleEntry{})
+}
+
+// staleMagic 没有编解码器的 Redis 中软过期值的前缀
+const staleMagic = "\x00cachex:stale\x00"
+
+// staleEntry RememberStale 写入缓存的值，与值一起保存软过期时间以及最近一次 create 的耗时（用于提前刷新）
+// 内存驱动直接保存指针；json、msgpack 通过字段标签编码为 map，gob 使用 GobEncode，
+// 没有编解码器的 Redis 使用 MarshalBinary 编码为带前缀的字符串，读取时由 unwrapStale 还原
+type staleEntry struct {
+	Value any   `json:"cachex_stale_value"`
+	Soft  int64 `json:"cachex_stale_soft"`
+	Delta int64 `json:"cachex_stale_delta,omitempty"`
+}
+
+// staleGob staleEntry 的 gob 编码格式，避免 gob 使用 MarshalBinary
+type staleGob struct {
+	Value any
+	Soft  int64
+	Delta int64
+}
+
+func (e *staleEntry) GobEncode() ([]byte, error) {
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(staleGob{Value: e.Value, Soft: e.Soft, Delta: e.Delta})
+	return buf.Bytes(), err
+}
+
+func (e *staleEntry) GobDecode(data []byte) error {
+	var g staleGob
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&g); err != nil {
+		return err
+	}
+	*e = staleEntry{Value: g.Value, Soft: g.Soft, Delta: g.Delta}
+	return nil
+}
+
+// MarshalBinary 供没有编解码器的 Redis 使用，值按 go-redis 的规则格式化，读取时与直接写入的值一样得到字符串
+func (e *staleEntry) MarshalBinary() ([]byte, error) {
+	value, err := formatArg(e.Value)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(staleMagic + strconv.FormatInt(e.Soft, 10) + ":" + strconv.FormatInt(e.Delta, 10) + ":" + value), nil
+}
+
+// formatArg 与 go-redis 格式化命令参数的规则相同
+func formatArg(v any) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprint(v), nil
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		if v {
+			return "1", nil
+		}
+		return "0", nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	case encoding.BinaryMarshaler:
+		b, err := v.MarshalBinary()
+		return string(b), err
+	default:
+		return "", fmt.Errorf("cachex: can't marshal %T (implement encoding.BinaryMarshaler)", v)
+	}
+}
+
+// unwrapStale 识别各种驱动与编解码器往返后的 staleEntry
+func unwrapStale(v any) (*staleEntry, bool) {
+	switch v := v.(type) {
+	case *staleEntry:
+		return v, true
+	case string:
+		return parseStale(v)
+	case []byte:
+		return parseStale(string(v))
+	case map[string]any:
+		soft, ok := v["cachex_stale_soft"]
+		if !ok {
+			return nil, false
+		}
+		return &staleEntry{Value: v["cachex_stale_value"], Soft: toInt64(soft), Delta: toInt64(v["cachex_stale_delta"])}, true
+	}
+	return nil, false
+}
+
+func parseStale(s string) (*staleEntry, bool) {
+	rest, ok := strings.CutPrefix(s, staleMagic)
+	if !ok {
+		return nil, false
+	}
+	parts := strings.SplitN(rest, ":", 3)
+	if len(parts) != 3 {
+		return nil, false
+	}
+	soft, err1 := strconv.ParseInt(parts[0], 10, 64)
+	delta, err2 := strconv.ParseInt(parts[1], 10, 64)
+	if err1 != nil || err2 != nil {
+		return nil, false
+	}
+	return &staleEntry{Value: parts[2], Soft: soft, Delta: delta}, true
+}
+
+// toInt64 转换 json、msgpack 解码得到的数值
+func toInt64(v any) int64 {
+	switch n := v.(type) {
+	case int64:
+		return n
+	case int:
+		return int64(n)
+	case uint64:
+		return int64(n)
+	case float64:
+		return int64(n)
+	case json.Number:
+		i, _ := n.Int64()
+		return i
+	}
+	return 0
+}
+
+// unwrap 返回 RememberStale 读取到的缓存值中的实际值，去掉软过期的元数据
+// 只在 RememberStale 的读取路径上使用，Get 等方法按原样返回缓存值，不会改写与 staleEntry 编码形式相同的用户值
+func unwrap(v any) any {
+	if e, ok := unwrapStale(v); ok {
+		return e.Value
+	}
+	return v
+}
+
+// RememberStale 软过期模式的 Remember，值在 softSeconds 后变为陈旧，hardSeconds 后从缓存中删除
+// 命中陈旧值时立即返回该值，并在后台刷新，同一个键在进程内同时只有一个刷新
+// hardSeconds 必须大于 softSeconds，否则等同于 Remember(k, hardSeconds, create)
+func (c *cacheImpl) RememberStale(k string, softSeconds, hardSeconds int64, create func() (any, error)) (any, error) {
+	return c.RememberStaleCtx(context.Background(), k, softSeconds, hardSeconds, func(context.Context) (any, error) {
+		return create()
+	})
+}
+
+// RememberStaleCtx RememberStale 的 context 版本
+// 软过期时间与值一起保存在缓存中，写入的键只应通过 RememberStale 读取，Get 返回的是带有元数据的值；
+// 键存在但不是由 RememberStale 写入时（如通过 Put 写入），视为陈旧值
+// 后台刷新使用脱离了取消信号的 ctx，调用方返回后刷新仍会继续；刷新失败时保留陈旧值直到硬过期
+func (c *cacheImpl) RememberStaleCtx(ctx context.Context, k string, softSeconds, hardSeconds int64, create func(ctx context.Context) (any, error)) (any, error) {
+	if softSeconds <= 0 || hardSeconds <= softSeconds {
+		return c.RememberCtx(ctx, k, hardSeconds, create)
+	}
+
+	v, err := c.driver.GetCtx(ctx, c.key(k))
+	if err != nil {
+		v, err := c.RememberCtx(ctx, k, hardSeconds, c.withSoftExpiration(softSeconds, create))
+		return unwrap(v), err
+	}
+	if IsMissing(v) {
+		return nil, ErrMissing
+	}
+
+	e, ok := unwrapStale(v)
+	if !ok {
+		c.refresh(ctx, k, softSeconds, hardSeconds, create)
+		return c.cached(v)
+	}
+	if c.shouldRefresh(time.Unix(0, e.Soft), time.Duration(e.Delta)) {
+		c.refresh(ctx, k, softSeconds, hardSeconds, create)
+	}
+	return c.cached(e.Value)
+}
+
+// withSoftExpiration 将 create 的结果包装为带软过期时间的 staleEntry，并记录 create 的耗时作为 XFetch 的 delta
+func (c *cacheImpl) withSoftExpiration(softSeconds int64, create func(ctx context.Context) (any, error)) func(ctx context.Context) (any, error) {
+	return func(ctx context.Context) (any, error) {
+		start := time.Now()
+		v, err := create(ctx)
+		if err != nil {
+			return nil, err
+		}
+		now := time.Now()
+		return &staleEntry{
+			Value: v,
+			Soft:  now.Add(time.Duration(softSeconds) * time.Second).UnixNano(),
+			Delta: int64(now.Sub(start)),
+		}, nil
+	}
+}
+
+// shouldRefresh 判断是否需要刷新，开启提前刷新时按 XFetch 算法以一定概率在软过期之前刷新：
+// now - delta * beta * ln(rand) >= softExpiration，delta 为最近一次 create 的耗时
+func (c *cacheImpl) shouldRefresh(softExpiration time.Time, delta time.Duration) bool {
+	now := time.Now()
+	if !now.Before(softExpiration) {
+		return true
+	}
+	if c.earlyBeta <= 0 || delta <= 0 {
+		return false
+	}
+	// 1 - rand.Float64() 的取值范围为 (0, 1]，避免 ln(0)
+	gap := -float64(delta) * c.earlyBeta * math.Log(1-rand.Float64())
+	return !now.Add(time.Duration(gap)).Before(softExpiration)
+}
+
+// refresh 在后台重新执行 create 并写入缓存
+func (c *cacheImpl) refresh(ctx context.Context, k string, softSeconds, hardSeconds int64, create func(ctx context.Context) (any, error)) {
+	c.refreshMu.Lock()
+	if c.refreshing == nil {
+		c.refreshing = make(map[string]struct{})
+	}
+	if _, ok := c.refreshing[k]; ok {
+		c.refreshMu.Unlock()
+		return
+	}
+	c.refreshing[k] = struct{}{}
+	c.refreshMu.Unlock()
+
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		// create 发生 panic 时放弃本次刷新，后台刷新没有可以接收 panic 的调用方，不能使进程崩溃
+		defer func() {
+			_ = recover()
+			c.refreshMu.Lock()
+			delete(c.refreshing, k)
+			c.refreshMu.Unlock()
+		}()
+
+		// 开启分布式锁时，其他实例正在刷新则跳过，继续使用陈旧值
+		if locker, ok := driver.As[driver.Locker](c.driver); ok && c.lockTTL > 0 {
+			unlock, ok, err := locker.TryLock(ctx, c.key(k), c.lockTTL)
+			if err == nil && !ok {
+				return
+			}
+			if ok {
+				defer unlock()
+			}
+		}
+
+		v, err := c.call(ctx, c.withSoftExpiration(softSeconds, create))
+		if err != nil {
+			return
+		}
+		_ = c.PutCtx(ctx, k, v, hardSeconds)
+	}()
+}
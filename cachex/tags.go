@@ -0,0 +1,118 @@
+package cachex
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/yu1ec/go-pkg/cachex/driver"
+)
+
+// TaggedCache 是带标签的缓存，写入的键会被记录到所有标签下，
+// 通过 Flush 可以删除任一标签下的所有键。读取仍然使用 Cache.Get
+type TaggedCache interface {
+	// Put 写入缓存并将键记录到标签下，过期时间语义与 Cache.Put 相同
+	Put(k string, value any, expireSeconds int64) error
+	// PutCtx Put 的 context 版本
+	PutCtx(ctx context.Context, k string, value any, expireSeconds int64) error
+	// Remember 与 Cache.Remember 相同，新创建的值会被记录到标签下
+	Remember(k string, expireSeconds int64, create func() (any, error)) (any, error)
+	// RememberCtx Remember 的 context 版本
+	RememberCtx(ctx context.Context, k string, expireSeconds int64, create func(ctx context.Context) (any, error)) (any, error)
+	// Flush 删除所有标签下的键
+	Flush() error
+	// FlushCtx Flush 的 context 版本
+	FlushCtx(ctx context.Context) error
+}
+
+func (c *cacheImpl) Tags(names ...string) TaggedCache {
+	tagger, _ := driver.As[driver.Tagger](c.driver)
+	return &taggedCache{cache: c, tagger: tagger, names: names}
+}
+
+type taggedCache struct {
+	cache  *cacheImpl
+	tagger driver.Tagger
+	names  []string
+}
+
+func (t *taggedCache) Put(k string, v any, expireSeconds int64) error {
+	return t.PutCtx(context.Background(), k, v, expireSeconds)
+}
+
+func (t *taggedCache) PutCtx(ctx context.Context, k string, v any, expireSeconds int64) error {
+	if t.tagger == nil {
+		return ErrNotSupported
+	}
+	// 写入成功后再记录从属关系，写入失败时不会留下永不过期的多余记录
+	if err := t.cache.PutCtx(ctx, k, v, expireSeconds); err != nil {
+		return err
+	}
+	return t.tag(ctx, k, expireSeconds)
+}
+
+func (t *taggedCache) Remember(k string, expireSeconds int64, create func() (any, error)) (any, error) {
+	return t.RememberCtx(context.Background(), k, expireSeconds, func(context.Context) (any, error) {
+		return create()
+	})
+}
+
+func (t *taggedCache) RememberCtx(ctx context.Context, k string, expireSeconds int64, create func(ctx context.Context) (any, error)) (any, error) {
+	if t.tagger == nil {
+		return nil, ErrNotSupported
+	}
+	var created bool
+	v, err := t.cache.RememberCtx(ctx, k, expireSeconds, func(ctx context.Context) (any, error) {
+		created = true
+		return create(ctx)
+	})
+	if err != nil || !created {
+		return v, err
+	}
+	// 新创建的值写入后再记录从属关系
+	if err := t.tag(ctx, k, expireSeconds); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func (t *taggedCache) Flush() error {
+	return t.FlushCtx(context.Background())
+}
+
+func (t *taggedCache) FlushCtx(ctx context.Context) error {
+	if t.tagger == nil {
+		return ErrNotSupported
+	}
+	for _, name := range t.names {
+		tag := t.cache.key(name)
+		keys, err := t.tagger.TaggedKeys(ctx, tag)
+		if err != nil {
+			return fmt.Errorf("cachex: flush tag %s: %w", name, err)
+		}
+		// 记录的是加上前缀后的键，直接交给驱动删除
+		if len(keys) > 0 {
+			if err := t.cache.driver.DeleteManyCtx(ctx, keys); err != nil {
+				return fmt.Errorf("cachex: flush tag %s: %w", name, err)
+			}
+		}
+		if err := t.tagger.DeleteTag(ctx, tag); err != nil {
+			return fmt.Errorf("cachex: flush tag %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func (t *taggedCache) tag(ctx context.Context, k string, expireSeconds int64) error {
+	if t.tagger == nil {
+		return ErrNotSupported
+	}
+	for _, name := range t.names {
+		if err := t.tagger.TagKeys(ctx, t.cache.key(name), time.Duration(expireSeconds)*time.Second, t.cache.key(k)); err != nil {
+			// 未能记录的键无法通过 Flush 删除，撤回本次写入
+			_ = t.cache.driver.DeleteCtx(ctx, t.cache.key(k))
+			return fmt.Errorf("cachex: tag %s: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package cachex_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/yu1ec/go-pkg/cachex"
+	"github.com/yu1ec/go-pkg/cachex/driver"
+	"github.com/yu1ec/go-pkg/cachex/driver/memory/bounded"
+	"github.com/yu1ec/go-pkg/cachex/driver/redis"
+)
+
+func TestTags(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	defer mr.Close()
+
+	redisCache, err := cachex.New("redis", &redis.RedisConfig{Addr: mr.Addr()})
+	if err != nil {
+		t.Fatalf("failed to create redis cache: %v", err)
+	}
+
+	// 驱动配置了前缀时标签集合中记录的是不带驱动前缀的键
+	prefixedCache, err := cachex.New("redis", &redis.RedisConfig{Addr: mr.Addr(), Prefix: "app:"})
+	if err != nil {
+		t.Fatalf("failed to create redis cache: %v", err)
+	}
+
+	caches := map[string]cachex.Cache{
+		"memory":       newMemoryCache(t),
+		"redis":        redisCache,
+		"redis prefix": prefixedCache,
+	}
+
+	for name, c := range caches {
+		t.Run(name, func(t *testing.T) {
+			assert.NoError(t, c.Tags("user:42", "users").Put("user:42:profile", "alice", 60))
+			assert.NoError(t, c.Tags("users").Put("users:page:1", "list", 60))
+			_, err := c.Tags("user:42").Remember("user:42:slug", 60, func() (any, error) {
+				return "alice-slug", nil
+			})
+			assert.NoError(t, err)
+			c.Put("other", "value", 60)
+
+			assert.NoError(t, c.Tags("user:42").Flush())
+			assert.False(t, c.Exists("user:42:profile"))
+			assert.False(t, c.Exists("user:42:slug"))
+			assert.True(t, c.Exists("users:page:1"))
+
+			assert.NoError(t, c.Tags("users").Flush())
+			assert.False(t, c.Exists("users:page:1"))
+			assert.True(t, c.Exists("other"))
+		})
+	}
+}
+
+func TestTagsFailedPut(t *testing.T) {
+	sizer := func(k string, v any) int64 { return int64(len(v.(string))) }
+	d, err := bounded.New(&bounded.BoundedConfig{MaxBytes: 1000, Sizer: sizer})
+	if err != nil {
+		t.Fatalf("failed to create bounded driver: %v", err)
+	}
+	driver.Register("tags_bounded", func(any) (driver.Driver, error) { return d, nil })
+	c, err := cachex.New("tags_bounded", nil)
+	assert.NoError(t, err)
+
+	// 写入被拒绝时不记录从属关系
+	assert.ErrorIs(t, c.Tags("users").Put("big", strings.Repeat("x", 1001), -1), bounded.ErrTooLarge)
+	_, err = c.Tags("users").Remember("huge", -1, func() (any, error) {
+		return strings.Repeat("x", 1001), nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, d.(*bounded.BoundedDriver).TagCount())
+
+	assert.NoError(t, c.Tags("users").Put("small", "v", -1))
+	assert.Equal(t, 1, d.(*bounded.BoundedDriver).TagCount())
+	assert.NoError(t, c.Tags("users").Flush())
+	assert.False(t, c.Exists("small"))
+}
@@ -0,0 +1,168 @@
+package cachex
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// Typed 是 Cache 之上的泛型访问器
+// 值以 JSON 编码后的字符串写入缓存，读取时解码为 T，保证在内存与 Redis 等驱动上的往返语义一致
+// T 为 string 或 []byte 时直接保存字符串，可以读取通过 Cache.Put 写入的值
+type Typed[T any] struct {
+	cache Cache
+}
+
+// NewTyped 基于 c 创建一个类型为 T 的访问器
+func NewTyped[T any](c Cache) *Typed[T] {
+	return &Typed[T]{cache: c}
+}
+
+// Get 获取并解码缓存值，键不存在时返回 T 的零值和 false
+func (t *Typed[T]) Get(k string) (T, bool, error) {
+	return t.GetCtx(context.Background(), k)
+}
+
+// GetCtx Get 的 context 版本
+func (t *Typed[T]) GetCtx(ctx context.Context, k string) (T, bool, error) {
+	var zero T
+	raw, err := t.cache.GetCtx(ctx, k)
+	if errors.Is(err, ErrCacheMiss) {
+		return zero, false, nil
+	}
+	if err != nil {
+		return zero, false, err
+	}
+	if IsMissing(raw) {
+		return zero, false, nil
+	}
+
+	v, err := t.decode(raw)
+	if err != nil {
+		return zero, false, fmt.Errorf("cachex: decode %s: %w", k, err)
+	}
+	return v, true, nil
+}
+
+// Put 编码并写入缓存，过期时间语义与 Cache.Put 相同
+func (t *Typed[T]) Put(k string, v T, expireSeconds int64) error {
+	return t.PutCtx(context.Background(), k, v, expireSeconds)
+}
+
+// PutCtx Put 的 context 版本
+func (t *Typed[T]) PutCtx(ctx context.Context, k string, v T, expireSeconds int64) error {
+	raw, err := t.encode(v)
+	if err != nil {
+		return fmt.Errorf("cachex: encode %s: %w", k, err)
+	}
+	return t.cache.PutCtx(ctx, k, raw, expireSeconds)
+}
+
+// Remember 与 Cache.Remember 相同，但 create 返回的是类型化的值
+func (t *Typed[T]) Remember(k string, expireSeconds int64, create func() (T, error)) (T, error) {
+	return t.RememberCtx(context.Background(), k, expireSeconds, func(context.Context) (T, error) {
+		return create()
+	})
+}
+
+// RememberCtx Remember 的 context 版本
+func (t *Typed[T]) RememberCtx(ctx context.Context, k string, expireSeconds int64, create func(ctx context.Context) (T, error)) (T, error) {
+	var zero T
+	raw, err := t.cache.RememberCtx(ctx, k, expireSeconds, func(ctx context.Context) (any, error) {
+		v, err := create(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return t.encode(v)
+	})
+	if err != nil {
+		return zero, err
+	}
+
+	v, err := t.decode(raw)
+	if err != nil {
+		return zero, fmt.Errorf("cachex: decode %s: %w", k, err)
+	}
+	return v, nil
+}
+
+// RememberStale 与 Cache.RememberStale 相同，但 create 返回的是类型化的值
+func (t *Typed[T]) RememberStale(k string, softSeconds, hardSeconds int64, create func() (T, error)) (T, error) {
+	return t.RememberStaleCtx(context.Background(), k, softSeconds, hardSeconds, func(context.Context) (T, error) {
+		return create()
+	})
+}
+
+// RememberStaleCtx RememberStale 的 context 版本
+func (t *Typed[T]) RememberStaleCtx(ctx context.Context, k string, softSeconds, hardSeconds int64, create func(ctx context.Context) (T, error)) (T, error) {
+	var zero T
+	raw, err := t.cache.RememberStaleCtx(ctx, k, softSeconds, hardSeconds, func(ctx context.Context) (any, error) {
+		v, err := create(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return t.encode(v)
+	})
+	if err != nil {
+		return zero, err
+	}
+
+	v, err := t.decode(raw)
+	if err != nil {
+		return zero, fmt.Errorf("cachex: decode %s: %w", k, err)
+	}
+	return v, nil
+}
+
+// Forget 删除给定的键
+func (t *Typed[T]) Forget(k string) error {
+	return t.cache.ForgetCtx(context.Background(), k)
+}
+
+// encode 将值编码为 JSON，string 与 []byte 直接以字符串保存，与通过 Cache.Put 写入的值相同
+func (t *Typed[T]) encode(v T) (string, error) {
+	switch x := any(v).(type) {
+	case string:
+		return x, nil
+	case []byte:
+		return string(x), nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// decode 先检查缓存值是否已经是 T（如内存驱动中通过 Cache.Put 写入的值，或 T 为 string、[]byte），否则按 JSON 解码
+func (t *Typed[T]) decode(raw any) (T, error) {
+	var v T
+	if reflect.TypeOf(&v).Elem().Kind() != reflect.Interface {
+		if r, ok := raw.(T); ok {
+			return r, nil
+		}
+	}
+	switch any(v).(type) {
+	case string:
+		if b, ok := raw.([]byte); ok {
+			return any(string(b)).(T), nil
+		}
+	case []byte:
+		if s, ok := raw.(string); ok {
+			return any([]byte(s)).(T), nil
+		}
+	}
+
+	switch r := raw.(type) {
+	case string:
+		err := json.Unmarshal([]byte(r), &v)
+		return v, err
+	case []byte:
+		err := json.Unmarshal(r, &v)
+		return v, err
+	default:
+		return v, fmt.Errorf("unexpected cached value of type %T", raw)
+	}
+}
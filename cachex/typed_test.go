@@ -0,0 +1,126 @@
+package cachex_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/yu1ec/go-pkg/cachex"
+	"github.com/yu1ec/go-pkg/cachex/driver/redis"
+)
+
+type user struct {
+	ID   int      `json:"id"`
+	Name string   `json:"name"`
+	Tags []string `json:"tags"`
+}
+
+func TestTyped(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	defer mr.Close()
+
+	redisCache, err := cachex.New("redis", &redis.RedisConfig{Addr: mr.Addr()})
+	if err != nil {
+		t.Fatalf("failed to create redis cache: %v", err)
+	}
+
+	caches := map[string]cachex.Cache{
+		"memory": newMemoryCache(t),
+		"redis":  redisCache,
+	}
+
+	for name, c := range caches {
+		t.Run(name, func(t *testing.T) {
+			users := cachex.NewTyped[user](c)
+
+			_, found, err := users.Get("user:1")
+			assert.NoError(t, err)
+			assert.False(t, found)
+
+			want := user{ID: 1, Name: "alice", Tags: []string{"admin"}}
+			assert.NoError(t, users.Put("user:1", want, 60))
+
+			got, found, err := users.Get("user:1")
+			assert.NoError(t, err)
+			assert.True(t, found)
+			assert.Equal(t, want, got)
+
+			counts := cachex.NewTyped[int64](c)
+			n, err := counts.Remember("count", 60, func() (int64, error) {
+				return 42, nil
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, int64(42), n)
+
+			n, err = counts.Remember("count", 60, func() (int64, error) {
+				return 0, errors.New("should not be called")
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, int64(42), n)
+
+			assert.NoError(t, users.Forget("user:1"))
+			_, found, err = users.Get("user:1")
+			assert.NoError(t, err)
+			assert.False(t, found)
+		})
+	}
+}
+
+func TestTypedMixedWithPut(t *testing.T) {
+	mr := miniredis.RunT(t)
+	redisCache, err := cachex.New("redis", &redis.RedisConfig{Addr: mr.Addr()})
+	if err != nil {
+		t.Fatalf("failed to create redis cache: %v", err)
+	}
+
+	caches := map[string]cachex.Cache{
+		"memory": newMemoryCache(t),
+		"redis":  redisCache,
+	}
+	for _, codec := range []string{"json", "gob", "msgpack"} {
+		c, err := cachex.New("redis", &redis.RedisConfig{Addr: mr.Addr(), Prefix: codec + ":", Codec: codec})
+		if err != nil {
+			t.Fatalf("failed to create redis %s cache: %v", codec, err)
+		}
+		caches["redis "+codec] = c
+	}
+
+	for name, c := range caches {
+		t.Run(name, func(t *testing.T) {
+			// 通过 Cache.Put 写入的字符串可以由 Typed 读取，反之亦然
+			c.Put("greeting", "hello", 60)
+			strs := cachex.NewTyped[string](c)
+			s, found, err := strs.Get("greeting")
+			assert.NoError(t, err)
+			assert.True(t, found)
+			assert.Equal(t, "hello", s)
+
+			assert.NoError(t, strs.Put("greeting", "hi", 60))
+			v, _ := c.Get("greeting")
+			assert.Equal(t, "hi", v)
+
+			bytes := cachex.NewTyped[[]byte](c)
+			b, found, err := bytes.Get("greeting")
+			assert.NoError(t, err)
+			assert.True(t, found)
+			assert.Equal(t, []byte("hi"), b)
+
+			assert.NoError(t, bytes.Put("raw", []byte("data"), 60))
+			s, found, err = strs.Get("raw")
+			assert.NoError(t, err)
+			assert.True(t, found)
+			assert.Equal(t, "data", s)
+
+			// JSON 编码的值仍然可以解码
+			c.Put("json", `{"id":2,"name":"bob"}`, 60)
+			u, found, err := cachex.NewTyped[user](c).Get("json")
+			assert.NoError(t, err)
+			assert.True(t, found)
+			assert.Equal(t, user{ID: 2, Name: "bob"}, u)
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package cachex
+
+import (
+	"context"
+	"time"
+
+	"github.com/yu1ec/go-pkg/cachex/driver"
+)
+
+func (c *cacheImpl) CompareAndSwap(k string, old, new any, expireSeconds int64) (bool, error) {
+	return c.CompareAndSwapCtx(context.Background(), k, old, new, expireSeconds)
+}
+
+func (c *cacheImpl) Update(k string, fn func(old any, exists bool) (any, error)) (any, error) {
+	return c.UpdateCtx(context.Background(), k, fn)
+}
+
+// CompareAndSwapCtx 需要驱动实现 driver.Updater
+func (c *cacheImpl) CompareAndSwapCtx(ctx context.Context, k string, old, new any, expireSeconds int64) (bool, error) {
+	updater, ok := driver.As[driver.Updater](c.driver)
+	if !ok {
+		return false, ErrNotSupported
+	}
+	d := time.Duration(expireSeconds) * time.Second
+	return updater.CompareAndSwap(ctx, c.key(k), old, new, d)
+}
+
+// UpdateCtx 需要驱动实现 driver.Updater
+func (c *cacheImpl) UpdateCtx(ctx context.Context, k string, fn func(old any, exists bool) (any, error)) (any, error) {
+	updater, ok := driver.As[driver.Updater](c.driver)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	return updater.Update(ctx, c.key(k), fn)
+}
@@ -0,0 +1,143 @@
+package cachex_test
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/yu1ec/go-pkg/cachex"
+	"github.com/yu1ec/go-pkg/cachex/driver"
+	"github.com/yu1ec/go-pkg/cachex/driver/file"
+	"github.com/yu1ec/go-pkg/cachex/driver/redis"
+)
+
+// counter 将 Update 中读到的旧值转换为整数，JSON 编解码后的数字为 float64
+func counter(old any, exists bool) (any, error) {
+	switch v := old.(type) {
+	case int:
+		return v + 1, nil
+	case float64:
+		return int(v) + 1, nil
+	}
+	return 1, nil
+}
+
+func TestUpdate(t *testing.T) {
+	mr := miniredis.RunT(t)
+
+	newCache := func(driverName string, config any) cachex.Cache {
+		c, err := cachex.New(driverName, config)
+		if err != nil {
+			t.Fatalf("failed to create %s cache: %v", driverName, err)
+		}
+		return c
+	}
+	caches := map[string]cachex.Cache{
+		"gocache": newMemoryCache(t),
+		"lru":     newCache("memory", map[string]any{"implementation": "lru", "max_entries": 100}),
+		"file":    newCache("file", &file.FileConfig{Dir: t.TempDir()}),
+		"redis":   newCache("redis", &redis.RedisConfig{Addr: mr.Addr(), Codec: "json"}),
+	}
+
+	for name, c := range caches {
+		t.Run(name, func(t *testing.T) {
+			var wg sync.WaitGroup
+			for i := 0; i < 20; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					_, err := c.Update("counter", counter)
+					assert.NoError(t, err)
+				}()
+			}
+			wg.Wait()
+
+			v, err := c.Update("counter", counter)
+			assert.NoError(t, err)
+			assert.Equal(t, 21, v)
+
+			// fn 返回错误时不写入
+			errStop := errors.New("stop")
+			_, err = c.Update("counter", func(any, bool) (any, error) {
+				return nil, errStop
+			})
+			assert.ErrorIs(t, err, errStop)
+			v, err = c.Update("counter", counter)
+			assert.NoError(t, err)
+			assert.Equal(t, 22, v)
+
+			// 已存在的键保留过期时间
+			c.Put("ttl", "a", 60)
+			_, err = c.Update("ttl", func(any, bool) (any, error) {
+				return "b", nil
+			})
+			assert.NoError(t, err)
+			if name == "redis" {
+				assert.Greater(t, mr.TTL("ttl"), 50*time.Second)
+			}
+
+			// fn 中可以读写缓存
+			v, err = c.Update("reentrant", func(old any, exists bool) (any, error) {
+				c.Get("reentrant")
+				c.Put("reentrant:copy", "x", 60)
+				return "y", nil
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, "y", v)
+
+			// fn 每次都修改同一个键时不会无限重试
+			_, err = c.Update("reentrant", func(old any, exists bool) (any, error) {
+				c.Put("reentrant", "z", 60)
+				return "y", nil
+			})
+			assert.ErrorIs(t, err, driver.ErrConflict)
+
+			// 与自身不相等的值（如 NaN）也可以更新，JSON 无法编码 NaN
+			if name != "redis" {
+				c.Put("nan", math.NaN(), 60)
+				v, err = c.Update("nan", func(any, bool) (any, error) {
+					return 1.5, nil
+				})
+				assert.NoError(t, err)
+				assert.Equal(t, 1.5, v)
+			}
+		})
+	}
+}
+
+func TestCompareAndSwap(t *testing.T) {
+	mr := miniredis.RunT(t)
+	redisCache, err := cachex.New("redis", &redis.RedisConfig{Addr: mr.Addr()})
+	if err != nil {
+		t.Fatalf("failed to create redis cache: %v", err)
+	}
+
+	caches := map[string]cachex.Cache{
+		"memory": newMemoryCache(t),
+		"redis":  redisCache,
+	}
+
+	for name, c := range caches {
+		t.Run(name, func(t *testing.T) {
+			swapped, err := c.CompareAndSwap("state", "pending", "done", 60)
+			assert.NoError(t, err)
+			assert.False(t, swapped, "missing key")
+
+			c.Put("state", "pending", 60)
+			swapped, err = c.CompareAndSwap("state", "running", "done", 60)
+			assert.NoError(t, err)
+			assert.False(t, swapped)
+
+			swapped, err = c.CompareAndSwap("state", "pending", "running", 60)
+			assert.NoError(t, err)
+			assert.True(t, swapped)
+
+			v, _ := c.Get("state")
+			assert.Equal(t, "running", v)
+		})
+	}
+}